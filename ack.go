@@ -0,0 +1,51 @@
+package workpool
+
+import "fmt"
+
+// Acker is implemented by the items of a TypedPool which have to be acknowledged to their source, such as the messages
+// of a broker with at-least-once delivery. The pool acknowledges every item taken by a worker exactly once:
+//
+//   - Ack once the handler returns without an error.
+//   - Nack with requeue set to RequeueFailed when the handler returns an error or panics.
+//   - Nack with requeue set when the pool was cancelled while the item was being processed, or before a worker could
+//     process it, so that it is delivered again.
+//   - Nack without requeue when its job was cancelled with JobHandle.Cancel, or when it is quarantined because of
+//     MaxFailures.
+//
+// Items still queued when the pool is cancelled are neither acknowledged nor negatively acknowledged, brokers deliver
+// them again once the consumer goes away. Errors from Ack and Nack are returned to the pool like handler errors.
+//
+// The Message types of the amqpsource, natssource and sqssource modules implement Acker, and their Feed handlers submit
+// them to a TypedPool.
+type Acker interface {
+	// Ack reports that the item was processed.
+	Ack() error
+
+	// Nack reports that the item was not processed. The source delivers it again if requeue is set.
+	Nack(requeue bool) error
+}
+
+// acker tracks the acknowledgement of an item which is being processed, so that it is acknowledged once. The zero
+// value, for items which do not implement Acker, does nothing.
+type acker struct {
+	acker Acker
+	done  bool
+}
+
+// ack acknowledges the item, see Acker.
+func (a *acker) ack(processed, requeue bool) error {
+	if a.acker == nil || a.done {
+		return nil
+	}
+	a.done = true
+	if processed {
+		if err := a.acker.Ack(); err != nil {
+			return fmt.Errorf("workpool: ack: %w", err)
+		}
+		return nil
+	}
+	if err := a.acker.Nack(requeue); err != nil {
+		return fmt.Errorf("workpool: nack: %w", err)
+	}
+	return nil
+}
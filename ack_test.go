@@ -0,0 +1,148 @@
+package workpool
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// message is an item which records how it was acknowledged.
+type message struct {
+	id     int
+	mu     *sync.Mutex
+	acks   map[int]string
+	ackErr error
+}
+
+func (m message) Ack() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.acks[m.id] += "ack"
+	return m.ackErr
+}
+
+func (m message) Nack(requeue bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if requeue {
+		m.acks[m.id] += "requeue"
+	} else {
+		m.acks[m.id] += "nack"
+	}
+	return nil
+}
+
+// messages creates n messages sharing their record of acknowledgements.
+func messages(n int) ([]message, map[int]string) {
+	mu := &sync.Mutex{}
+	acks := make(map[int]string)
+	var msgs []message
+	for i := 0; i < n; i++ {
+		msgs = append(msgs, message{id: i, mu: mu, acks: acks})
+	}
+	return msgs, acks
+}
+
+// failOddMessage fails the messages with an odd id.
+func failOddMessage(abort <-chan struct{}, m message) (int, error) {
+	return failOdd(abort, m.id)
+}
+
+func TestAckerSuccessAndFailure(t *testing.T) {
+	for _, requeue := range []bool{false, true} {
+		msgs, acks := messages(4)
+		pool := NewTypedPool(2, failOddMessage)
+		pool.RequeueFailed = requeue
+		pool.Start()
+		for _, m := range msgs {
+			require.NoError(t, pool.Submit(m))
+		}
+		pool.Finish()
+		for range pool.Results() {
+		}
+		assert.Error(t, pool.Wait())
+
+		failed := "nack"
+		if requeue {
+			failed = "requeue"
+		}
+		assert.Equal(t, map[int]string{0: "ack", 1: failed, 2: "ack", 3: failed}, acks)
+	}
+}
+
+func TestAckerCancelled(t *testing.T) {
+	msgs, acks := messages(1)
+	started := make(chan struct{})
+	pool := NewTypedPool(1, func(abort <-chan struct{}, m message) (int, error) {
+		close(started)
+		<-abort
+		return 0, errors.New("aborted")
+	})
+	pool.Start()
+	require.NoError(t, pool.Submit(msgs[0]))
+	<-started
+	pool.Cancel()
+	for range pool.Results() {
+	}
+	assert.Error(t, pool.Wait())
+	assert.Equal(t, map[int]string{0: "requeue"}, acks, "work interrupted by cancellation is delivered again")
+}
+
+func TestAckerJobCancelled(t *testing.T) {
+	msgs, acks := messages(1)
+	started := make(chan struct{})
+	pool := NewTypedPool(1, func(abort <-chan struct{}, m message) (int, error) {
+		close(started)
+		<-abort
+		return 0, errors.New("aborted")
+	})
+	pool.Start()
+	job, err := pool.SubmitJob(msgs[0])
+	require.NoError(t, err)
+	<-started
+	assert.True(t, job.Cancel())
+	pool.Finish()
+	for range pool.Results() {
+	}
+	assert.NoError(t, pool.Wait())
+	assert.Equal(t, map[int]string{0: "nack"}, acks)
+}
+
+func TestAckerPanic(t *testing.T) {
+	msgs, acks := messages(2)
+	pool := NewTypedPool(1, func(abort <-chan struct{}, m message) (int, error) {
+		if m.id == 0 {
+			panic("boom")
+		}
+		return m.id, nil
+	})
+	pool.RecoverPanics = true
+	pool.RequeueFailed = true
+	pool.Start()
+	for _, m := range msgs {
+		require.NoError(t, pool.Submit(m))
+	}
+	pool.Finish()
+	for range pool.Results() {
+	}
+	assert.NoError(t, pool.Wait())
+	assert.Equal(t, map[int]string{0: "requeue", 1: "ack"}, acks)
+}
+
+func TestAckerError(t *testing.T) {
+	msgs, acks := messages(1)
+	msgs[0].ackErr = errors.New("connection closed")
+	pool := NewTypedPool(1, func(abort <-chan struct{}, m message) (int, error) {
+		return m.id, nil
+	})
+	pool.Start()
+	require.NoError(t, pool.Submit(msgs[0]))
+	pool.Finish()
+	result := <-pool.Results()
+	assert.NoError(t, result.Err, "the result is the outcome of the handler")
+	assert.EqualError(t, pool.Wait(), "workpool: ack: connection closed")
+	assert.Equal(t, map[int]string{0: "ack"}, acks)
+}
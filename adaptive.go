@@ -0,0 +1,108 @@
+package workpool
+
+import (
+	"sync"
+	"time"
+)
+
+// AdaptiveConcurrency adjusts the number of running workers to keep handler latency under a target, which suits
+// handlers calling a remote service whose capacity is unknown or changes over time. It uses additive increase,
+// multiplicative decrease: after every Window calls the average latency is compared with TargetLatency, and the
+// limit on workers is cut by Backoff if it was exceeded, or raised by one, up to Workers, if it was not.
+//
+// An AdaptiveConcurrency must not be shared between pools, and should not be combined with Autoscale.
+type AdaptiveConcurrency struct {
+	// TargetLatency is the average handler call duration to stay under.
+	TargetLatency time.Duration
+
+	// MinWorkers is the lowest the limit goes. Values smaller than one are treated as one.
+	MinWorkers int
+
+	// Window is the number of handler calls which found work between adjustments. Zero uses 10.
+	Window int
+
+	// Backoff is the factor the limit is multiplied by when the target is exceeded. Values outside (0, 1) use 0.75.
+	Backoff float64
+
+	mu      sync.Mutex
+	limit   int
+	calls   int
+	elapsed time.Duration
+}
+
+// Limit returns the current limit on the number of workers, or zero before the pool has made any calls.
+func (a *AdaptiveConcurrency) Limit() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.limit
+}
+
+// observe records a handler call which found work. It returns the new limit at the end of a window, and zero
+// otherwise.
+func (a *AdaptiveConcurrency) observe(elapsed time.Duration, workers int) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.limit == 0 {
+		a.limit = workers
+	}
+	a.calls++
+	a.elapsed += elapsed
+	window := a.Window
+	if window <= 0 {
+		window = 10
+	}
+	if a.calls < window {
+		return 0
+	}
+
+	average := a.elapsed / time.Duration(a.calls)
+	a.calls, a.elapsed = 0, 0
+	if average > a.TargetLatency {
+		backoff := a.Backoff
+		if backoff <= 0 || backoff >= 1 {
+			backoff = 0.75
+		}
+		a.limit = int(float64(a.limit) * backoff)
+	} else {
+		a.limit++
+	}
+
+	min := a.MinWorkers
+	if min < 1 {
+		min = 1
+	}
+	if a.limit < min {
+		a.limit = min
+	}
+	if a.limit > workers {
+		a.limit = workers
+	}
+	return a.limit
+}
+
+// adapt feeds a handler call to the AdaptiveConcurrency controller, and starts or retires workers to match the limit
+// at the end of each window.
+func (p *WorkPool) adapt(foundWork bool, elapsed time.Duration) {
+	if p.Adaptive == nil || !foundWork {
+		return
+	}
+	p.mu.Lock()
+	workers := p.Workers
+	p.mu.Unlock()
+	limit := p.Adaptive.observe(elapsed, workers)
+	if limit == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.running {
+		return
+	}
+	for len(p.workers) < limit {
+		p.startWorker()
+	}
+	for len(p.workers) > limit {
+		p.retireWorker()
+	}
+}
@@ -0,0 +1,52 @@
+package workpool
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptiveConcurrencyObserve(t *testing.T) {
+	a := &AdaptiveConcurrency{TargetLatency: 10 * time.Millisecond, MinWorkers: 2, Window: 2, Backoff: 0.5}
+
+	assert.Zero(t, a.observe(time.Millisecond, 8))
+	assert.Equal(t, 8, a.Limit())
+	assert.Equal(t, 8, a.observe(time.Millisecond, 8), "the limit never exceeds Workers")
+
+	a.observe(20*time.Millisecond, 8)
+	assert.Equal(t, 4, a.observe(20*time.Millisecond, 8))
+	a.observe(20*time.Millisecond, 8)
+	assert.Equal(t, 2, a.observe(20*time.Millisecond, 8))
+	a.observe(20*time.Millisecond, 8)
+	assert.Equal(t, 2, a.observe(20*time.Millisecond, 8), "the limit never drops below MinWorkers")
+
+	a.observe(time.Millisecond, 8)
+	assert.Equal(t, 3, a.observe(time.Millisecond, 8))
+}
+
+func TestAdaptiveConcurrency(t *testing.T) {
+	// The handler slows down with the number of concurrent calls, like an overloaded service.
+	var inflight int32
+	done := make(chan struct{})
+	pool := New(10, func(abort <-chan struct{}) bool {
+		n := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		select {
+		case <-done:
+			return false
+		case <-time.After(time.Duration(n) * time.Millisecond):
+			return true
+		}
+	})
+	pool.Adaptive = &AdaptiveConcurrency{TargetLatency: 3500 * time.Microsecond, Window: 5}
+	pool.Start()
+
+	assert.Eventually(t, func() bool {
+		limit := pool.Adaptive.Limit()
+		return limit > 0 && limit <= 4 && pool.ActiveWorkers() <= 4
+	}, 5*time.Second, time.Millisecond)
+	close(done)
+	assert.NoError(t, pool.Wait())
+}
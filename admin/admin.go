@@ -0,0 +1,196 @@
+// Package admin serves an HTTP endpoint for operating the pools of a workpool.Manager in production: inspecting their
+// status and the state of their workers, resizing, pausing, resuming, draining and cancelling them.
+//
+// It is opt-in, the handler has to be mounted by the service, which should protect it like any other admin endpoint:
+//
+//	http.Handle("/admin/pools/", http.StripPrefix("/admin", admin.New(manager)))
+//
+// The routes are:
+//
+//	GET  /pools                     the status of every pool
+//	GET  /pools/{name}              the status of a pool
+//	POST /pools/{name}/resize?n=4   resize a pool to n workers
+//	POST /pools/{name}/pause        pause a pool
+//	POST /pools/{name}/resume       resume a pool
+//	POST /pools/{name}/drain        stop a pool once its queued work is done, for pools with a Finish method
+//	POST /pools/{name}/cancel       cancel a pool
+//
+// Actions respond with the status of the pool after the action.
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/algorand/workpool"
+)
+
+// Status is the JSON representation of a pool.
+type Status struct {
+	Name      string `json:"name"`
+	Running   bool   `json:"running"`
+	Paused    bool   `json:"paused"`
+	Cancelled bool   `json:"cancelled"`
+
+	// StopReason is why the pool stopped, once it has finished.
+	StopReason string `json:"stop_reason,omitempty"`
+
+	Workers  int `json:"workers"`
+	Retiring int `json:"retiring"`
+
+	// Queued is the number of items waiting for a worker, for pools which own their queue.
+	Queued *int `json:"queued,omitempty"`
+
+	Invocations     int64   `json:"invocations"`
+	Finished        int64   `json:"finished"`
+	Timeouts        int64   `json:"timeouts"`
+	Errors          int     `json:"errors"`
+	Done            int64   `json:"done"`
+	Total           int64   `json:"total"`
+	Throughput      float64 `json:"throughput"`
+	DurationSeconds float64 `json:"duration_seconds"`
+
+	WorkerStates []WorkerState `json:"worker_states"`
+}
+
+// WorkerState is the JSON representation of a worker.
+type WorkerState struct {
+	ID             int     `json:"id"`
+	Busy           bool    `json:"busy"`
+	RunningSeconds float64 `json:"running_seconds"`
+	Retiring       bool    `json:"retiring"`
+}
+
+// Handler is the http.Handler of the admin endpoint.
+type Handler struct {
+	manager *workpool.Manager
+}
+
+// New creates a Handler for the pools of manager.
+func New(manager *workpool.Manager) *Handler {
+	return &Handler{manager: manager}
+}
+
+// PoolStatus returns the Status of a pool.
+func PoolStatus(name string, pool workpool.Pool) Status {
+	stats := pool.Stats()
+	progress := pool.Progress()
+	status := Status{
+		Name:            name,
+		Running:         stats.Running,
+		Paused:          pool.Paused(),
+		Cancelled:       stats.Cancelled,
+		Workers:         stats.ActiveWorkers,
+		Retiring:        stats.RetiringWorkers,
+		Invocations:     stats.Invocations,
+		Finished:        stats.Finished,
+		Timeouts:        stats.Timeouts,
+		Errors:          pool.ErrorCount(),
+		Done:            progress.Done,
+		Total:           progress.Total,
+		Throughput:      progress.Throughput,
+		DurationSeconds: stats.Duration.Seconds(),
+		WorkerStates:    []WorkerState{},
+	}
+	if reason := pool.StopReason(); reason != workpool.StopNone {
+		status.StopReason = reason.String()
+	}
+	if queue, ok := pool.(interface{ QueueLen() int }); ok {
+		queued := queue.QueueLen()
+		status.Queued = &queued
+	}
+	for _, w := range pool.WorkerStatuses() {
+		status.WorkerStates = append(status.WorkerStates, WorkerState{
+			ID:             w.ID,
+			Busy:           w.Busy,
+			RunningSeconds: w.Running.Seconds(),
+			Retiring:       w.Retiring,
+		})
+	}
+	return status
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	parts := strings.Split(path, "/")
+	if parts[0] != "pools" || len(parts) > 3 {
+		http.NotFound(w, r)
+		return
+	}
+
+	if len(parts) == 1 {
+		if !allow(w, r, http.MethodGet) {
+			return
+		}
+		statuses := []Status{}
+		for _, name := range h.manager.Names() {
+			pool, _ := h.manager.Pool(name)
+			statuses = append(statuses, PoolStatus(name, pool))
+		}
+		respond(w, statuses)
+		return
+	}
+
+	name := parts[1]
+	pool, ok := h.manager.Pool(name)
+	if !ok {
+		http.Error(w, "unknown pool "+name, http.StatusNotFound)
+		return
+	}
+	if len(parts) == 2 {
+		if allow(w, r, http.MethodGet) {
+			respond(w, PoolStatus(name, pool))
+		}
+		return
+	}
+
+	if !allow(w, r, http.MethodPost) {
+		return
+	}
+	switch parts[2] {
+	case "resize":
+		n, err := strconv.Atoi(r.URL.Query().Get("n"))
+		if err != nil || n < 0 {
+			http.Error(w, "n must be a number of workers", http.StatusBadRequest)
+			return
+		}
+		pool.Resize(n)
+	case "pause":
+		pool.Pause()
+	case "resume":
+		pool.Resume()
+	case "drain":
+		finisher, ok := pool.(interface{ Finish() })
+		if !ok {
+			http.Error(w, "pool "+name+" cannot be drained, it does not own its queue", http.StatusConflict)
+			return
+		}
+		finisher.Finish()
+	case "cancel":
+		pool.Cancel()
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	respond(w, PoolStatus(name, pool))
+}
+
+// allow responds with 405 Method Not Allowed unless the request uses method.
+func allow(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	return false
+}
+
+// respond writes v as JSON.
+func respond(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,100 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/algorand/workpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// request sends a request to h and decodes the JSON response into v, returning the status code.
+func request(t *testing.T, h http.Handler, method, target string, v any) int {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	if v != nil && rec.Code == http.StatusOK {
+		require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), v))
+	}
+	return rec.Code
+}
+
+func TestHandler(t *testing.T) {
+	release := make(chan struct{})
+	typed := workpool.NewTypedPool(2, func(abort <-chan struct{}, item int) (int, error) {
+		<-release
+		return item, nil
+	})
+	go func() {
+		for range typed.Results() {
+		}
+	}()
+	plain := workpool.New(1, func(abort <-chan struct{}) bool {
+		<-abort
+		return false
+	})
+	m := workpool.NewManager()
+	require.NoError(t, m.Add("typed", typed))
+	require.NoError(t, m.Add("plain", plain))
+	m.Start()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, typed.Submit(i))
+	}
+	h := New(m)
+
+	var statuses []Status
+	require.Equal(t, http.StatusOK, request(t, h, http.MethodGet, "/pools", &statuses))
+	require.Len(t, statuses, 2)
+	assert.Equal(t, "typed", statuses[0].Name)
+	assert.Equal(t, "plain", statuses[1].Name)
+	assert.Nil(t, statuses[1].Queued)
+
+	var status Status
+	require.Eventually(t, func() bool {
+		request(t, h, http.MethodGet, "/pools/typed", &status)
+		return status.Queued != nil && *status.Queued == 3
+	}, time.Second, time.Millisecond)
+	assert.True(t, status.Running)
+	assert.Equal(t, 2, status.Workers)
+	assert.Equal(t, int64(5), status.Total)
+	require.Len(t, status.WorkerStates, 2)
+	assert.True(t, status.WorkerStates[0].Busy)
+
+	require.Equal(t, http.StatusOK, request(t, h, http.MethodPost, "/pools/typed/resize?n=1", &status))
+	assert.Equal(t, 1, status.Workers)
+	assert.Equal(t, 1, status.Retiring)
+	require.Equal(t, http.StatusOK, request(t, h, http.MethodPost, "/pools/typed/pause", &status))
+	assert.True(t, status.Paused)
+	require.Equal(t, http.StatusOK, request(t, h, http.MethodPost, "/pools/typed/resume", &status))
+	assert.False(t, status.Paused)
+
+	close(release)
+	require.Equal(t, http.StatusOK, request(t, h, http.MethodPost, "/pools/typed/drain", &status))
+	assert.Equal(t, http.StatusConflict, request(t, h, http.MethodPost, "/pools/plain/drain", nil))
+	require.NoError(t, typed.Wait())
+	assert.False(t, typed.Cancelled())
+	require.Equal(t, http.StatusOK, request(t, h, http.MethodGet, "/pools/typed", &status))
+	assert.Equal(t, "exhausted", status.StopReason)
+
+	require.Equal(t, http.StatusOK, request(t, h, http.MethodPost, "/pools/plain/cancel", &status))
+	assert.True(t, status.Cancelled)
+	require.NoError(t, plain.Wait())
+}
+
+func TestHandlerErrors(t *testing.T) {
+	m := workpool.NewManager()
+	require.NoError(t, m.Add("pool", workpool.New(1, func(abort <-chan struct{}) bool { return false })))
+	h := New(m)
+
+	assert.Equal(t, http.StatusNotFound, request(t, h, http.MethodGet, "/other", nil))
+	assert.Equal(t, http.StatusNotFound, request(t, h, http.MethodGet, "/pools/missing", nil))
+	assert.Equal(t, http.StatusNotFound, request(t, h, http.MethodPost, "/pools/pool/explode", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, request(t, h, http.MethodPost, "/pools", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, request(t, h, http.MethodGet, "/pools/pool/cancel", nil))
+	assert.Equal(t, http.StatusBadRequest, request(t, h, http.MethodPost, "/pools/pool/resize?n=many", nil))
+	assert.Equal(t, http.StatusBadRequest, request(t, h, http.MethodPost, "/pools/pool/resize?n=-1", nil))
+}
@@ -0,0 +1,77 @@
+package workpool
+
+// claimKey makes the calling worker responsible for a key. False is returned if another worker is already processing
+// the key, in which case the entry is left for that worker.
+func (p *TypedPool[In, Out]) claimKey(key string, e entry[In]) bool {
+	p.keyedMu.Lock()
+	defer p.keyedMu.Unlock()
+	if waiting, active := p.keyed[key]; active {
+		p.keyed[key] = append(waiting, e)
+		return false
+	}
+	if p.keyed == nil {
+		p.keyed = make(map[string][]entry[In])
+	}
+	p.keyed[key] = nil
+	return true
+}
+
+// nextKeyed returns the next entry waiting for a key. False is returned, and the key is given up, if there is none.
+func (p *TypedPool[In, Out]) nextKeyed(key string) (entry[In], bool) {
+	p.keyedMu.Lock()
+	defer p.keyedMu.Unlock()
+	waiting := p.keyed[key]
+	if len(waiting) == 0 {
+		delete(p.keyed, key)
+		return entry[In]{}, false
+	}
+	p.keyed[key] = waiting[1:]
+	return waiting[0], true
+}
+
+// takeOrphan returns an orphaned key and its next entry.
+func (p *TypedPool[In, Out]) takeOrphan() (string, entry[In], bool) {
+	for {
+		p.keyedMu.Lock()
+		if len(p.orphans) == 0 {
+			p.keyedMu.Unlock()
+			return "", entry[In]{}, false
+		}
+		key := p.orphans[0]
+		p.orphans = p.orphans[1:]
+		p.keyedMu.Unlock()
+
+		if e, ok := p.nextKeyed(key); ok {
+			return key, e, true
+		}
+	}
+}
+
+// processKeyed processes e and then the entries which arrived for its key in the meantime. If the handler panics the
+// key is orphaned, so that the next call to the work handler carries on with it.
+func (p *TypedPool[In, Out]) processKeyed(handler TypedHandler[In, Out], key string, e entry[In], abort <-chan struct{}) (bool, error) {
+	returned := false
+	defer func() {
+		if !returned {
+			p.keyedMu.Lock()
+			p.orphans = append(p.orphans, key)
+			p.keyedMu.Unlock()
+		}
+	}()
+
+	var firstErr error
+	for {
+		ok, err := p.process(handler, e, abort)
+		if firstErr == nil {
+			firstErr = err
+		}
+		if !ok {
+			returned = true
+			return false, firstErr
+		}
+		if e, ok = p.nextKeyed(key); !ok {
+			returned = true
+			return true, firstErr
+		}
+	}
+}
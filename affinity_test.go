@@ -0,0 +1,99 @@
+package workpool
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type keyedItem struct {
+	key string
+	seq int
+}
+
+func TestAffinity(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[string][]int)
+	running := make(map[string]bool)
+	var overlapped, concurrent, peak int32
+
+	pool := NewTypedPool(8, func(abort <-chan struct{}, item keyedItem) (int, error) {
+		mu.Lock()
+		if running[item.key] {
+			atomic.StoreInt32(&overlapped, 1)
+		}
+		running[item.key] = true
+		seen[item.key] = append(seen[item.key], item.seq)
+		mu.Unlock()
+
+		n := atomic.AddInt32(&concurrent, 1)
+		if n > atomic.LoadInt32(&peak) {
+			atomic.StoreInt32(&peak, n)
+		}
+		time.Sleep(100 * time.Microsecond)
+		atomic.AddInt32(&concurrent, -1)
+
+		mu.Lock()
+		running[item.key] = false
+		mu.Unlock()
+		return item.seq, nil
+	})
+	pool.Affinity = func(item keyedItem) string { return item.key }
+	pool.Start()
+
+	go func() {
+		for i := 0; i < 400; i++ {
+			pool.Submit(keyedItem{key: fmt.Sprint(i % 4), seq: i})
+		}
+		pool.Finish()
+	}()
+	count := 0
+	for result := range pool.Results() {
+		require.NoError(t, result.Err)
+		count++
+	}
+	require.NoError(t, pool.Wait())
+
+	assert.Equal(t, 400, count)
+	assert.Zero(t, atomic.LoadInt32(&overlapped))
+	assert.Greater(t, atomic.LoadInt32(&peak), int32(1))
+	for key, seqs := range seen {
+		assert.Len(t, seqs, 100, key)
+		assert.IsIncreasing(t, seqs, key)
+	}
+	assert.Empty(t, pool.keyed)
+}
+
+func TestAffinityPanic(t *testing.T) {
+	release := make(chan struct{})
+	pool := NewTypedPool(2, func(abort <-chan struct{}, item keyedItem) (int, error) {
+		if item.seq == 0 {
+			<-release
+			panic("boom")
+		}
+		return item.seq, nil
+	})
+	pool.RecoverPanics = true
+	pool.Affinity = func(item keyedItem) string { return item.key }
+	pool.Start()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, pool.Submit(keyedItem{key: "a", seq: i}))
+	}
+	// Wait for the other worker to park the items behind the first one.
+	assert.Eventually(t, func() bool { return pool.QueueLen() == 0 }, time.Second, time.Millisecond)
+	close(release)
+	pool.Finish()
+
+	var values []int
+	for result := range pool.Results() {
+		values = append(values, result.Value)
+	}
+	assert.Equal(t, []int{1, 2}, values)
+	assert.NoError(t, pool.Wait())
+}
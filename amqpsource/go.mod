@@ -0,0 +1,17 @@
+module github.com/algorand/workpool/amqpsource
+
+go 1.23
+
+require (
+	github.com/algorand/workpool v0.0.0-00010101000000-000000000000
+	github.com/rabbitmq/amqp091-go v1.15.0
+	github.com/stretchr/testify v1.7.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
+
+replace github.com/algorand/workpool => ../
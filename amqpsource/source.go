@@ -0,0 +1,146 @@
+// Package amqpsource feeds messages from an AMQP queue, such as RabbitMQ, to WorkPool workers. Deliveries are
+// acknowledged when they are processed successfully and negatively acknowledged when processing fails. They are either
+// processed by the workers of a WorkPool, see Handler, or submitted to a TypedPool as Messages, see Feed, which
+// acknowledges them through workpool.Acker.
+//
+// It is a separate module so that the workpool package itself does not depend on an AMQP client.
+package amqpsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/algorand/workpool"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Consumer is the part of *amqp.Channel used by Source.
+type Consumer interface {
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	Cancel(consumer string, noWait bool) error
+}
+
+// Message is a delivery which implements workpool.Acker, so that a TypedPool acknowledges it.
+type Message struct {
+	amqp.Delivery
+}
+
+// Ack acknowledges the delivery.
+func (m Message) Ack() error {
+	return m.Delivery.Ack(false)
+}
+
+// Nack negatively acknowledges the delivery. The server delivers it again if requeue is set, otherwise it is discarded
+// or dead-lettered.
+func (m Message) Nack(requeue bool) error {
+	return m.Delivery.Nack(false, requeue)
+}
+
+// Source is a subscription to an AMQP queue. The deliveries are processed by the handler returned from Handler.
+type Source struct {
+	// Requeue decides what happens to a delivery which failed to be processed. When true it is returned to the queue,
+	// otherwise it is discarded, or dead-lettered if the queue is configured to do so.
+	Requeue bool
+
+	consumer   Consumer
+	tag        string
+	deliveries <-chan amqp.Delivery
+}
+
+// NewSource starts consuming queue with manual acknowledgements. The consumer tag identifies the subscription so that
+// it can be cancelled by Close, an empty tag lets the server pick one but then the subscription can only be stopped by
+// closing the channel.
+func NewSource(consumer Consumer, queue, tag string) (*Source, error) {
+	deliveries, err := consumer.Consume(queue, tag, false, false, false, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("amqpsource: consume %s: %w", queue, err)
+	}
+	return &Source{
+		consumer:   consumer,
+		tag:        tag,
+		deliveries: deliveries,
+	}, nil
+}
+
+// Handler creates a WorkHandler which calls fn for each delivery. The delivery is acknowledged if fn returns nil and
+// negatively acknowledged otherwise. The context given to fn is cancelled when the pool is cancelled. Errors from fn or
+// from acknowledging are reported to the pool, and the worker keeps going until the subscription ends or the pool is
+// cancelled.
+func (s *Source) Handler(fn func(ctx context.Context, delivery amqp.Delivery) error) workpool.ErrWorkHandler {
+	return func(abort <-chan struct{}) (bool, error) {
+		message, ok := s.receive(abort)
+		if !ok {
+			return false, nil
+		}
+
+		ctx, cancel := workpool.AbortContext(abort)
+		defer cancel()
+		if err := fn(ctx, message.Delivery); err != nil {
+			if nackErr := message.Nack(s.Requeue); nackErr != nil {
+				return true, fmt.Errorf("amqpsource: nack after %v: %w", err, nackErr)
+			}
+			return true, err
+		}
+		if err := message.Ack(); err != nil {
+			return true, fmt.Errorf("amqpsource: ack: %w", err)
+		}
+		return true, nil
+	}
+}
+
+// Feed creates a WorkHandler which passes each delivery to submit as a Message, usually the Submit method of a
+// TypedPool which then acknowledges it:
+//
+//	pool := workpool.NewTypedPool(4, process)
+//	pool.RequeueFailed = true
+//	pool.Start()
+//	feeder := workpool.NewWithError(1, source.Feed(pool.Submit))
+//
+// A delivery which cannot be submitted because the pool was closed is returned to the queue, and the worker stops.
+// Other submit errors are reported to the pool and the worker keeps going until the subscription ends or the pool is
+// cancelled.
+func (s *Source) Feed(submit func(Message) error) workpool.ErrWorkHandler {
+	return func(abort <-chan struct{}) (bool, error) {
+		message, ok := s.receive(abort)
+		if !ok {
+			return false, nil
+		}
+		if err := submit(message); err != nil {
+			closed := errors.Is(err, workpool.ErrPoolClosed)
+			if nackErr := message.Nack(true); nackErr != nil {
+				return !closed, fmt.Errorf("amqpsource: nack after %v: %w", err, nackErr)
+			}
+			if closed {
+				return false, nil
+			}
+			return true, err
+		}
+		return true, nil
+	}
+}
+
+// receive waits for a delivery. False is returned if the subscription ended or abort was closed.
+func (s *Source) receive(abort <-chan struct{}) (Message, bool) {
+	select {
+	case delivery, ok := <-s.deliveries:
+		return Message{Delivery: delivery}, ok
+	case <-abort:
+		return Message{}, false
+	}
+}
+
+// Close cancels the subscription. Deliveries which were received but not processed are redelivered by the server. It
+// is meant to be called once the pool has finished:
+//
+//	pool := workpool.NewWithError(4, source.Handler(process))
+//	err := errors.Join(pool.Run(), source.Close())
+func (s *Source) Close() error {
+	if s.tag == "" {
+		return nil
+	}
+	if err := s.consumer.Cancel(s.tag, false); err != nil {
+		return fmt.Errorf("amqpsource: cancel %s: %w", s.tag, err)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package amqpsource
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/algorand/workpool"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChannel implements Consumer and amqp.Acknowledger.
+type fakeChannel struct {
+	mu        sync.Mutex
+	acked     []uint64
+	nacked    []uint64
+	requeued  []bool
+	cancelled []string
+	cancelErr error
+
+	deliveries chan amqp.Delivery
+}
+
+func newFakeChannel(bodies ...string) *fakeChannel {
+	c := &fakeChannel{deliveries: make(chan amqp.Delivery, len(bodies))}
+	for i, body := range bodies {
+		c.deliveries <- amqp.Delivery{Acknowledger: c, DeliveryTag: uint64(i + 1), Body: []byte(body)}
+	}
+	return c
+}
+
+func (c *fakeChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	if queue == "missing" {
+		return nil, errors.New("not found")
+	}
+	return c.deliveries, nil
+}
+
+func (c *fakeChannel) Cancel(consumer string, noWait bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cancelled = append(c.cancelled, consumer)
+	return c.cancelErr
+}
+
+func (c *fakeChannel) Ack(tag uint64, multiple bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.acked = append(c.acked, tag)
+	return nil
+}
+
+func (c *fakeChannel) Nack(tag uint64, multiple, requeue bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nacked = append(c.nacked, tag)
+	c.requeued = append(c.requeued, requeue)
+	return nil
+}
+
+func (c *fakeChannel) Reject(tag uint64, requeue bool) error {
+	return c.Nack(tag, false, requeue)
+}
+
+func TestSource(t *testing.T) {
+	channel := newFakeChannel("ok", "bad", "ok")
+	close(channel.deliveries)
+
+	source, err := NewSource(channel, "jobs", "worker")
+	require.NoError(t, err)
+	source.Requeue = true
+
+	pool := workpool.NewWithError(1, source.Handler(func(ctx context.Context, delivery amqp.Delivery) error {
+		if string(delivery.Body) == "bad" {
+			return errors.New("bad message")
+		}
+		return nil
+	}))
+
+	assert.EqualError(t, pool.Run(), "bad message")
+	assert.NoError(t, source.Close())
+	assert.Equal(t, []uint64{1, 3}, channel.acked)
+	assert.Equal(t, []uint64{2}, channel.nacked)
+	assert.Equal(t, []bool{true}, channel.requeued)
+	assert.Equal(t, []string{"worker"}, channel.cancelled)
+}
+
+func TestSourceAbort(t *testing.T) {
+	// The subscription stays open, cancelling the pool must stop the workers.
+	channel := newFakeChannel()
+	source, err := NewSource(channel, "jobs", "worker")
+	require.NoError(t, err)
+
+	pool := workpool.NewWithError(2, source.Handler(func(ctx context.Context, delivery amqp.Delivery) error {
+		return nil
+	}))
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		pool.Cancel()
+	}()
+
+	assert.NoError(t, pool.Run())
+	assert.NoError(t, source.Close())
+	assert.Equal(t, []string{"worker"}, channel.cancelled)
+}
+
+func TestSourceAbortsHandler(t *testing.T) {
+	channel := newFakeChannel("slow")
+	source, err := NewSource(channel, "jobs", "worker")
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	pool := workpool.NewWithError(1, source.Handler(func(ctx context.Context, delivery amqp.Delivery) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	go func() {
+		<-started
+		pool.Cancel()
+	}()
+
+	assert.ErrorIs(t, pool.Run(), context.Canceled)
+	assert.Equal(t, []uint64{1}, channel.nacked)
+}
+
+func TestSourceCloseError(t *testing.T) {
+	channel := newFakeChannel()
+	channel.cancelErr = errors.New("channel closed")
+	source, err := NewSource(channel, "jobs", "worker")
+	require.NoError(t, err)
+	assert.EqualError(t, source.Close(), "amqpsource: cancel worker: channel closed")
+}
+
+func TestNewSourceError(t *testing.T) {
+	_, err := NewSource(newFakeChannel(), "missing", "worker")
+	assert.EqualError(t, err, "amqpsource: consume missing: not found")
+}
+
+func TestSourceFeed(t *testing.T) {
+	channel := newFakeChannel("ok", "bad", "ok")
+	close(channel.deliveries)
+	source, err := NewSource(channel, "jobs", "worker")
+	require.NoError(t, err)
+
+	pool := workpool.NewTypedPool(1, func(abort <-chan struct{}, message Message) (string, error) {
+		if string(message.Body) == "bad" {
+			return "", errors.New("bad message")
+		}
+		return string(message.Body), nil
+	})
+	pool.Start()
+	go func() {
+		for range pool.Results() {
+		}
+	}()
+
+	feeder := workpool.NewWithError(1, source.Feed(pool.Submit))
+	assert.NoError(t, feeder.Run())
+	pool.Finish()
+	assert.EqualError(t, pool.Wait(), "bad message")
+	assert.NoError(t, source.Close())
+
+	assert.Equal(t, []uint64{1, 3}, channel.acked)
+	assert.Equal(t, []uint64{2}, channel.nacked)
+	assert.Equal(t, []bool{false}, channel.requeued)
+}
+
+func TestSourceFeedClosedPool(t *testing.T) {
+	channel := newFakeChannel("late")
+	source, err := NewSource(channel, "jobs", "worker")
+	require.NoError(t, err)
+
+	pool := workpool.NewTypedPool(1, func(abort <-chan struct{}, message Message) (string, error) {
+		return string(message.Body), nil
+	})
+	pool.Start()
+	pool.Finish()
+	require.NoError(t, pool.Wait())
+
+	// The pool no longer accepts work, so the delivery goes back to the queue and the feeder stops.
+	feeder := workpool.NewWithError(1, source.Feed(pool.Submit))
+	assert.NoError(t, feeder.Run())
+	assert.Equal(t, []uint64{1}, channel.nacked)
+	assert.Equal(t, []bool{true}, channel.requeued)
+}
@@ -0,0 +1,72 @@
+package workpool
+
+import (
+	"errors"
+)
+
+// ErrNoWork may be returned by an ErrWorkHandler to report that there was no work available, as opposed to there being
+// no more work at all. It is not treated as an error and is never returned from Run, but it lets an Autoscale policy
+// know that the worker was idle.
+var ErrNoWork = errors.New("workpool: no work available")
+
+// Autoscale shrinks the number of workers while handlers report ErrNoWork, and grows it back towards Workers while they
+// find work.
+type Autoscale struct {
+	// MinWorkers is the number of workers to keep when idle. At least one worker is always kept so that new work can
+	// be noticed.
+	MinWorkers int
+
+	// IdleCalls is the number of consecutive ErrNoWork results after which a worker is stopped. Values smaller than one
+	// are treated as one.
+	IdleCalls int
+}
+
+// autoscale updates the idle state of w after a handler call which returned foundWork and err. It returns whether w
+// should exit to scale the pool down, and err with ErrNoWork removed.
+func (p *WorkPool) autoscale(w *worker, foundWork bool, err error) (bool, error) {
+	idle := errors.Is(err, ErrNoWork)
+	if idle {
+		err = nil
+	}
+	if p.Autoscale == nil || !foundWork {
+		return false, err
+	}
+
+	if !idle {
+		w.idle = 0
+		p.scaleUp()
+		return false, err
+	}
+
+	w.idle++
+	if w.idle < p.Autoscale.IdleCalls {
+		return false, err
+	}
+	w.idle = 0
+	return p.scaleDown(w), err
+}
+
+// scaleUp starts another worker if there are fewer than Workers.
+func (p *WorkPool) scaleUp() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.running && len(p.workers) < p.Workers {
+		p.startWorker()
+	}
+}
+
+// scaleDown stops w if there are more than the minimum number of workers. True is returned if w should exit.
+func (p *WorkPool) scaleDown(w *worker) bool {
+	min := p.Autoscale.MinWorkers
+	if min < 1 {
+		min = 1
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.workers) <= min {
+		return false
+	}
+	p.removeWorker(w)
+	return true
+}
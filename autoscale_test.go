@@ -0,0 +1,72 @@
+package workpool
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoscale(t *testing.T) {
+	var busy int32
+	done := make(chan struct{})
+	worker := func(abort <-chan struct{}) (bool, error) {
+		select {
+		case <-done:
+			return false, nil
+		case <-time.After(time.Millisecond):
+		}
+		if atomic.LoadInt32(&busy) == 0 {
+			return true, ErrNoWork
+		}
+		return true, nil
+	}
+
+	pool := NewWithError(5, worker)
+	pool.Autoscale = &Autoscale{MinWorkers: 2, IdleCalls: 3}
+	pool.Start()
+
+	// Idle workers are stopped down to the minimum.
+	assert.Eventually(t, func() bool { return pool.ActiveWorkers() == 2 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 2, pool.ActiveWorkers())
+
+	// Busy workers grow back to the maximum.
+	atomic.StoreInt32(&busy, 1)
+	assert.Eventually(t, func() bool { return pool.ActiveWorkers() == 5 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 5, pool.ActiveWorkers())
+
+	close(done)
+	assert.NoError(t, pool.Wait())
+}
+
+func TestAutoscaleKeepsOneWorker(t *testing.T) {
+	var calls int32
+	worker := func(abort <-chan struct{}) (bool, error) {
+		if atomic.AddInt32(&calls, 1) > 100 {
+			return false, nil
+		}
+		return true, ErrNoWork
+	}
+
+	pool := NewWithError(3, worker)
+	pool.Autoscale = &Autoscale{}
+
+	// ErrNoWork is never returned as an error.
+	assert.NoError(t, pool.Run())
+	assert.Greater(t, atomic.LoadInt32(&calls), int32(100))
+}
+
+func TestNoWorkWithoutAutoscale(t *testing.T) {
+	calls := 0
+	worker := func(abort <-chan struct{}) (bool, error) {
+		calls++
+		return calls < 3, ErrNoWork
+	}
+
+	pool := NewWithError(1, worker)
+	assert.NoError(t, pool.Run())
+	assert.Equal(t, 3, calls)
+}
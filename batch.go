@@ -0,0 +1,161 @@
+package workpool
+
+import (
+	"time"
+)
+
+// BatchHandler processes a batch of items submitted to a BatchPool. Like a WorkHandler, it returns false to indicate
+// that the worker should stop. The batch is not reused by the pool.
+type BatchHandler[T any] func(abort <-chan struct{}, batch []T) bool
+
+// BatchPool is a WorkPool which accumulates submitted items into batches. A batch is given to the handler once it has
+// BatchSize items, or MaxWait after its first item was taken from the queue, whichever comes first.
+//
+// If the pool is cancelled, items which have not yet been given to the handler are dropped.
+//
+// The embedded WorkPool may be configured before calling Start, but its Handler, ErrHandler and Close fields are managed
+// by the BatchPool.
+type BatchPool[T any] struct {
+	*WorkPool
+
+	// BatchSize is the maximum number of items in a batch.
+	BatchSize int
+
+	// MaxWait is the longest time a partial batch waits for more items. Zero flushes whatever is queued right away.
+	MaxWait time.Duration
+
+	queue *queue[T]
+}
+
+// NewBatchPool creates a BatchPool which calls handler with batches of up to batchSize items, waiting at most maxWait
+// to fill a batch.
+func NewBatchPool[T any](numWorkers, batchSize int, maxWait time.Duration, handler BatchHandler[T]) *BatchPool[T] {
+	p := &BatchPool[T]{
+		BatchSize: batchSize,
+		MaxWait:   maxWait,
+		queue:     newQueue[T](),
+	}
+	p.WorkPool = &WorkPool{
+		Handler: p.work(handler),
+		Workers: numWorkers,
+	}
+	trackQueue(p.WorkPool, p.queue)
+	return p
+}
+
+// Submit adds an item to the pool. ErrPoolClosed is returned if Finish or Cancel have been called.
+func (p *BatchPool[T]) Submit(item T) error {
+	p.init()
+	if p.ctx.Err() != nil || !p.queue.push(item, 0, 0, nil) {
+		return ErrPoolClosed
+	}
+	return nil
+}
+
+// Finish signals that no more items will be submitted. Remaining items are flushed and then the pool exits.
+func (p *BatchPool[T]) Finish() {
+	p.queue.close()
+}
+
+// work creates the WorkHandler used by the underlying WorkPool.
+func (p *BatchPool[T]) work(handler BatchHandler[T]) WorkHandler {
+	return func(abort <-chan struct{}) bool {
+		first, ok := p.queue.pop(abort)
+		if !ok {
+			return false
+		}
+
+		size := p.BatchSize
+		if size < 1 {
+			size = 1
+		}
+		batch := make([]T, 1, size)
+		batch[0] = first
+
+		timer := p.clock().NewTimer(p.MaxWait)
+		defer timer.Stop()
+		for len(batch) < size {
+			item, ok := p.queue.popWithin(abort, timer.C())
+			if !ok {
+				break
+			}
+			batch = append(batch, item)
+		}
+
+		select {
+		case <-abort:
+			return false
+		default:
+		}
+		return handler(abort, batch)
+	}
+}
+
+// Batch adds a stage which groups the items read from in into batches sent to the returned channel, for feeding bulk
+// APIs from a trickle of items. A batch is sent once it has size items, or maxAge after its first item was read,
+// whichever comes first. A maxAge of zero or less sends whatever is ready straight away. Once in is closed the last
+// partial batch is sent, then the returned channel is closed.
+func Batch[T any](p *Pipeline, in <-chan T, size int, maxAge time.Duration) <-chan []T {
+	size = max(size, 1)
+	out := make(chan []T)
+	p.add(&WorkPool{
+		Workers: 1,
+		Handler: func(abort <-chan struct{}) bool {
+			first, ok := receive(abort, in)
+			if !ok {
+				return false
+			}
+			batch, more, ok := fillBatch(abort, in, []T{first}, size, maxAge)
+			if !ok {
+				return false
+			}
+			select {
+			case out <- batch:
+				return more
+			case <-abort:
+				return false
+			}
+		},
+		Close: func() {
+			close(out)
+		},
+	})
+	return out
+}
+
+// fillBatch adds the items read from in to batch until it has size items or maxAge has passed. It also returns
+// whether in is still open, and false if abort is closed first.
+func fillBatch[T any](abort <-chan struct{}, in <-chan T, batch []T, size int, maxAge time.Duration) ([]T, bool, bool) {
+	var expired <-chan time.Time
+	if maxAge > 0 {
+		timer := time.NewTimer(maxAge)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	for len(batch) < size {
+		if expired == nil {
+			select {
+			case item, open := <-in:
+				if !open {
+					return batch, false, true
+				}
+				batch = append(batch, item)
+				continue
+			default:
+				return batch, true, true
+			}
+		}
+		select {
+		case item, open := <-in:
+			if !open {
+				return batch, false, true
+			}
+			batch = append(batch, item)
+		case <-expired:
+			return batch, true, true
+		case <-abort:
+			return nil, false, false
+		}
+	}
+	return batch, true, true
+}
@@ -0,0 +1,154 @@
+package workpool
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchRecorder returns a BatchHandler which records the batches it receives.
+func batchRecorder(mu *sync.Mutex, batches *[][]int) BatchHandler[int] {
+	return func(abort <-chan struct{}, batch []int) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		*batches = append(*batches, batch)
+		return true
+	}
+}
+
+func TestBatchPoolSize(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]int
+	pool := NewBatchPool(1, 3, time.Hour, batchRecorder(&mu, &batches))
+
+	for i := 0; i < 7; i++ {
+		require.NoError(t, pool.Submit(i))
+	}
+	pool.Finish()
+	pool.Start()
+	assert.NoError(t, pool.Wait())
+
+	// The last partial batch is flushed when the queue is closed.
+	assert.Equal(t, [][]int{{0, 1, 2}, {3, 4, 5}, {6}}, batches)
+}
+
+func TestBatchPoolMaxWait(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]int
+	pool := NewBatchPool(1, 100, 10*time.Millisecond, batchRecorder(&mu, &batches))
+	pool.Start()
+
+	require.NoError(t, pool.Submit(1))
+	require.NoError(t, pool.Submit(2))
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(batches) == 1
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, pool.Submit(3))
+	pool.Finish()
+	assert.NoError(t, pool.Wait())
+	assert.Equal(t, [][]int{{1, 2}, {3}}, batches)
+}
+
+func TestBatchPoolCancel(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]int
+	pool := NewBatchPool(2, 10, time.Hour, batchRecorder(&mu, &batches))
+	pool.Start()
+
+	require.NoError(t, pool.Submit(1))
+	pool.Cancel()
+	assert.NoError(t, pool.Wait())
+	assert.ErrorIs(t, pool.Submit(2), ErrPoolClosed)
+	assert.Empty(t, batches)
+}
+
+func TestBatchPoolHandlerStops(t *testing.T) {
+	calls := 0
+	handler := func(abort <-chan struct{}, batch []int) bool {
+		calls++
+		return false
+	}
+	pool := NewBatchPool(1, 1, 0, handler)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, pool.Submit(i))
+	}
+	pool.Start()
+	assert.NoError(t, pool.Wait())
+	assert.Equal(t, 1, calls)
+}
+
+func TestBatchStageSize(t *testing.T) {
+	p := NewPipeline()
+	numbers := Source(p, 1, 2, 3, 4, 5)
+	var batches [][]int
+	Sink(p, 1, Batch(p, numbers, 2, time.Hour), func(abort <-chan struct{}, batch []int) error {
+		batches = append(batches, batch)
+		return nil
+	})
+
+	assert.NoError(t, p.Run())
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, batches)
+}
+
+func TestBatchStageMaxAge(t *testing.T) {
+	p := NewPipeline()
+	in := make(chan int)
+	batches := make(chan []int, 10)
+	Sink(p, 1, Batch(p, (<-chan int)(in), 10, 20*time.Millisecond), func(abort <-chan struct{}, batch []int) error {
+		batches <- batch
+		return nil
+	})
+	done := make(chan error)
+	go func() {
+		done <- p.Run()
+	}()
+
+	// A trickle of items is flushed by age rather than size.
+	in <- 1
+	in <- 2
+	assert.Equal(t, []int{1, 2}, <-batches)
+	in <- 3
+	assert.Equal(t, []int{3}, <-batches)
+	close(in)
+	assert.NoError(t, <-done)
+	assert.Empty(t, batches)
+}
+
+func TestBatchStageNoWait(t *testing.T) {
+	p := NewPipeline()
+	in := make(chan int, 3)
+	in <- 1
+	in <- 2
+	in <- 3
+	close(in)
+	var batches [][]int
+	Sink(p, 1, Batch(p, (<-chan int)(in), 2, 0), func(abort <-chan struct{}, batch []int) error {
+		batches = append(batches, batch)
+		return nil
+	})
+
+	assert.NoError(t, p.Run())
+	assert.Equal(t, [][]int{{1, 2}, {3}}, batches)
+}
+
+func TestBatchStageCancel(t *testing.T) {
+	p := NewPipeline()
+	// The input never closes and the batch never fills, only cancellation stops the stage.
+	in := make(chan int, 1)
+	in <- 1
+	batched := Batch(p, (<-chan int)(in), 10, time.Hour)
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		p.Cancel()
+	}()
+
+	assert.NoError(t, p.Run())
+	_, open := <-batched
+	assert.False(t, open)
+}
@@ -0,0 +1,69 @@
+package workpool
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// bloomFilter is a Bloom filter of strings.
+type bloomFilter struct {
+	bits   []uint64
+	hashes int
+}
+
+// newBloomFilter creates a filter which holds n keys with a false positive rate of p, one percent if zero.
+func newBloomFilter(n int, p float64) *bloomFilter {
+	if p <= 0 || p >= 1 {
+		p = 0.01
+	}
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	hashes := int(math.Round(m / float64(n) * math.Ln2))
+	return &bloomFilter{bits: make([]uint64, int(m)/64+1), hashes: max(hashes, 1)}
+}
+
+// positions calls fn with the bits of key, stopping if fn returns false. The bits are picked by double hashing, from
+// the FNV-1a hash of key and a remix of it, as the bits of FNV-1a alone are too alike for similar keys.
+func (f *bloomFilter) positions(key string, fn func(word int, mask uint64) bool) bool {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	h1 := mix64(h.Sum64())
+	h2 := mix64(h1) | 1
+	size := uint64(len(f.bits) * 64)
+	for i := 0; i < f.hashes; i++ {
+		bit := (h1 + uint64(i)*h2) % size
+		if !fn(int(bit/64), 1<<(bit%64)) {
+			return false
+		}
+	}
+	return true
+}
+
+// mix64 is the finalizer of SplitMix64, which spreads every bit of x over the result.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// add adds key to the filter.
+func (f *bloomFilter) add(key string) {
+	f.positions(key, func(word int, mask uint64) bool {
+		f.bits[word] |= mask
+		return true
+	})
+}
+
+// has reports whether key may have been added to the filter.
+func (f *bloomFilter) has(key string) bool {
+	return f.positions(key, func(word int, mask uint64) bool {
+		return f.bits[word]&mask != 0
+	})
+}
+
+// reset empties the filter.
+func (f *bloomFilter) reset() {
+	clear(f.bits)
+}
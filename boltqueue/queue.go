@@ -0,0 +1,372 @@
+// Package boltqueue is a persistent job queue for WorkPool workers, stored in a local bbolt database. It lets a
+// single-node service enqueue work durably: jobs are removed once they have been processed successfully, so any job
+// which was pending or in progress when the process stopped is processed again after a restart.
+//
+// It is a separate module so that the workpool package itself does not depend on bbolt.
+package boltqueue
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/algorand/workpool"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrClosed is returned by Submit once the queue has been closed.
+var ErrClosed = errors.New("boltqueue: queue closed")
+
+// defaultBucket holds the jobs unless Open is given a different bucket.
+const defaultBucket = "jobs"
+
+// Job is a job stored in the queue.
+type Job struct {
+	// ID orders the jobs, it increases with each Submit.
+	ID uint64
+
+	// Payload is the data given to Submit.
+	Payload []byte
+
+	// Running is true while a handler is processing the job.
+	Running bool
+
+	// Attempts is the number of times the job failed since the queue was opened.
+	Attempts int
+}
+
+// Queue is a FIFO job queue in a bbolt bucket. Jobs are taken in the order they were submitted, and a job stays in
+// the database until a handler has processed it successfully.
+type Queue struct {
+	// RetryBackoff is how long a failed job waits before it is tried again, doubling with each failure up to
+	// MaxRetryBackoff, so that a job which keeps failing does not hold up the others. Zero waits 100 milliseconds.
+	RetryBackoff time.Duration
+
+	// MaxRetryBackoff caps the wait of RetryBackoff. Zero caps it at one minute.
+	MaxRetryBackoff time.Duration
+
+	db     *bolt.DB
+	bucket []byte
+	owned  bool
+
+	mu      sync.Mutex
+	running map[uint64]bool
+	retries map[uint64]retry
+	changed chan struct{}
+	closed  bool
+
+	// handling counts the jobs being processed, Close waits for them before closing the database.
+	handling sync.WaitGroup
+}
+
+// Open opens, or creates, the database at path and returns a queue for its jobs. Closing the queue closes the
+// database.
+func Open(path string) (*Queue, error) {
+	db, err := bolt.Open(path, 0o600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("boltqueue: open %s: %w", path, err)
+	}
+	q, err := New(db, defaultBucket)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	q.owned = true
+	return q, nil
+}
+
+// New creates a queue for the jobs in bucket, creating the bucket if it does not exist. The database can be shared
+// with other queues or application data, and stays open when the queue is closed.
+func New(db *bolt.DB, bucket string) (*Queue, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("boltqueue: create bucket %s: %w", bucket, err)
+	}
+	return &Queue{
+		db:      db,
+		bucket:  []byte(bucket),
+		running: make(map[uint64]bool),
+		retries: make(map[uint64]retry),
+		changed: make(chan struct{}),
+	}, nil
+}
+
+// Close stops the handlers from taking more jobs, waits for the jobs being processed, and closes the database if the
+// queue was created by Open. Jobs which have not been processed stay in the database. Close must not be called from a
+// handler.
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	close(q.changed)
+	q.mu.Unlock()
+
+	q.handling.Wait()
+
+	if q.owned {
+		return q.db.Close()
+	}
+	return nil
+}
+
+// Submit stores a job, returning its ID. The job is on disk when Submit returns.
+func (q *Queue) Submit(payload []byte) (uint64, error) {
+	q.mu.Lock()
+	closed := q.closed
+	q.mu.Unlock()
+	if closed {
+		return 0, ErrClosed
+	}
+
+	var id uint64
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(q.bucket)
+		var err error
+		if id, err = b.NextSequence(); err != nil {
+			return err
+		}
+		return b.Put(key(id), payload)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("boltqueue: submit: %w", err)
+	}
+	q.notify()
+	return id, nil
+}
+
+// Len returns the number of stored jobs, including those being processed.
+func (q *Queue) Len() (int, error) {
+	var n int
+	err := q.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(q.bucket).Stats().KeyN
+		return nil
+	})
+	return n, err
+}
+
+// Jobs returns the stored jobs in the order they will be processed, including those being processed.
+func (q *Queue) Jobs() ([]Job, error) {
+	var jobs []Job
+	err := q.db.View(func(tx *bolt.Tx) error {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return tx.Bucket(q.bucket).ForEach(func(k, v []byte) error {
+			id := binary.BigEndian.Uint64(k)
+			jobs = append(jobs, Job{
+				ID:       id,
+				Payload:  append([]byte(nil), v...),
+				Running:  q.running[id],
+				Attempts: q.retries[id].attempts,
+			})
+			return nil
+		})
+	})
+	return jobs, err
+}
+
+// Delete removes a job which is not being processed. It returns false if there is no such job, or it is running.
+func (q *Queue) Delete(id uint64) (bool, error) {
+	var deleted bool
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		b := tx.Bucket(q.bucket)
+		if q.running[id] || b.Get(key(id)) == nil {
+			return nil
+		}
+		deleted = true
+		delete(q.retries, id)
+		return b.Delete(key(id))
+	})
+	return deleted, err
+}
+
+// Purge removes every job which is not being processed, returning how many were removed.
+func (q *Queue) Purge() (int, error) {
+	var n int
+	err := q.db.Update(func(tx *bolt.Tx) error {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		c := tx.Bucket(q.bucket).Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			id := binary.BigEndian.Uint64(k)
+			if q.running[id] {
+				continue
+			}
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			delete(q.retries, id)
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		n = 0
+	}
+	return n, err
+}
+
+// Handler creates a WorkHandler which calls fn for each job. The job is removed from the database if fn returns nil.
+// Otherwise the error is reported to the pool and the job stays queued, to be tried again after RetryBackoff while
+// the workers move on to the other jobs.
+//
+// Workers wait for new jobs when the queue is empty, and finish once the queue is closed. The context given to fn is
+// cancelled when the pool is cancelled.
+func (q *Queue) Handler(fn func(ctx context.Context, job Job) error) workpool.ErrWorkHandler {
+	return func(abort <-chan struct{}) (bool, error) {
+		job, changed, retry, err := q.take()
+		if err != nil {
+			return false, err
+		}
+		if changed != nil {
+			var wait <-chan time.Time
+			if retry > 0 {
+				timer := time.NewTimer(retry)
+				defer timer.Stop()
+				wait = timer.C
+			}
+			select {
+			case <-changed:
+				q.mu.Lock()
+				closed := q.closed
+				q.mu.Unlock()
+				return !closed, nil
+			case <-wait:
+				return true, nil
+			case <-abort:
+				return false, nil
+			}
+		}
+
+		defer q.finish(job)
+		ctx, cancel := workpool.AbortContext(abort)
+		defer cancel()
+		ferr := fn(ctx, job)
+		if ferr == nil {
+			err = q.db.Update(func(tx *bolt.Tx) error {
+				return tx.Bucket(q.bucket).Delete(key(job.ID))
+			})
+			if err != nil {
+				err = fmt.Errorf("boltqueue: remove %d: %w", job.ID, err)
+			}
+		}
+		q.record(job.ID, ferr == nil)
+		if ferr != nil {
+			return true, ferr
+		}
+		return true, err
+	}
+}
+
+// take marks the oldest job which is not being processed or waiting to be retried as running. If there is none it
+// returns a channel which is closed when a job is submitted or the queue is closed, along with how long until the
+// next failed job may be retried, zero if there is none.
+func (q *Queue) take() (Job, <-chan struct{}, time.Duration, error) {
+	var job Job
+	var changed <-chan struct{}
+	var retry time.Duration
+	err := q.db.View(func(tx *bolt.Tx) error {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if q.closed {
+			changed = q.changed
+			return nil
+		}
+		now := time.Now()
+		c := tx.Bucket(q.bucket).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			id := binary.BigEndian.Uint64(k)
+			if q.running[id] {
+				continue
+			}
+			r := q.retries[id]
+			if wait := r.at.Sub(now); wait > 0 {
+				if retry == 0 || wait < retry {
+					retry = wait
+				}
+				continue
+			}
+			q.running[id] = true
+			q.handling.Add(1)
+			job = Job{ID: id, Payload: append([]byte(nil), v...), Running: true, Attempts: r.attempts}
+			return nil
+		}
+		changed = q.changed
+		return nil
+	})
+	if err != nil {
+		return Job{}, nil, 0, fmt.Errorf("boltqueue: take: %w", err)
+	}
+	if changed == nil {
+		retry = 0
+	}
+	return job, changed, retry, nil
+}
+
+// retry is the backoff of a job which failed.
+type retry struct {
+	attempts int
+	at       time.Time
+}
+
+// record counts a failed attempt of a job, delaying its next one, or forgets the attempts of a job which succeeded.
+func (q *Queue) record(id uint64, succeeded bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if succeeded {
+		delete(q.retries, id)
+		return
+	}
+	r := q.retries[id]
+	r.attempts++
+	backoff, limit := q.RetryBackoff, q.MaxRetryBackoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+	if limit <= 0 {
+		limit = time.Minute
+	}
+	for i := 1; i < r.attempts && backoff < limit; i++ {
+		backoff *= 2
+	}
+	r.at = time.Now().Add(min(backoff, limit))
+	q.retries[id] = r
+}
+
+// finish clears the running mark set by take. The job may still be stored, so the handlers waiting on an empty queue
+// are woken to retry it.
+func (q *Queue) finish(job Job) {
+	q.mu.Lock()
+	delete(q.running, job.ID)
+	q.mu.Unlock()
+	q.notify()
+	q.handling.Done()
+}
+
+// notify wakes the handlers waiting for a job.
+func (q *Queue) notify() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// key encodes a job ID so that the bucket is sorted by ID.
+func key(id uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, id)
+	return k
+}
@@ -0,0 +1,196 @@
+package boltqueue
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/algorand/workpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func open(t *testing.T, path string) *Queue {
+	q, err := Open(path)
+	require.NoError(t, err)
+	return q
+}
+
+func submit(t *testing.T, q *Queue, payloads ...string) {
+	for _, payload := range payloads {
+		_, err := q.Submit([]byte(payload))
+		require.NoError(t, err)
+	}
+}
+
+func TestQueue(t *testing.T) {
+	q := open(t, filepath.Join(t.TempDir(), "queue.db"))
+	submit(t, q, "a", "b", "c")
+
+	var mu sync.Mutex
+	var got []string
+	pool := workpool.NewWithError(2, q.Handler(func(ctx context.Context, job Job) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, string(job.Payload))
+		return nil
+	}))
+	pool.Start()
+	assert.Eventually(t, func() bool {
+		n, err := q.Len()
+		return err == nil && n == 0
+	}, time.Second, time.Millisecond)
+
+	// Workers wait for more jobs until the queue is closed.
+	submit(t, q, "d")
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(got) == 4
+	}, time.Second, time.Millisecond)
+	require.NoError(t, q.Close())
+	assert.NoError(t, pool.Wait())
+	assert.ElementsMatch(t, []string{"a", "b", "c", "d"}, got)
+
+	_, err := q.Submit([]byte("e"))
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestQueueResumesAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.db")
+	q := open(t, path)
+	submit(t, q, "a", "b", "c")
+
+	// The first run stops while processing its first job.
+	started := make(chan struct{})
+	pool := workpool.NewWithError(1, q.Handler(func(ctx context.Context, job Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	pool.Start()
+	<-started
+	pool.Cancel()
+	assert.ErrorIs(t, pool.Wait(), context.Canceled)
+	require.NoError(t, q.Close())
+
+	q = open(t, path)
+	defer q.Close()
+	jobs, err := q.Jobs()
+	require.NoError(t, err)
+	require.Len(t, jobs, 3)
+
+	var mu sync.Mutex
+	var got []string
+	pool = workpool.NewWithError(1, q.Handler(func(ctx context.Context, job Job) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, string(job.Payload))
+		return nil
+	}))
+	pool.Start()
+	assert.Eventually(t, func() bool {
+		n, err := q.Len()
+		return err == nil && n == 0
+	}, time.Second, time.Millisecond)
+	pool.Cancel()
+	assert.NoError(t, pool.Wait())
+	assert.Equal(t, []string{"a", "b", "c"}, got)
+}
+
+func TestQueueRetriesFailedJobs(t *testing.T) {
+	q := open(t, filepath.Join(t.TempDir(), "queue.db"))
+	defer q.Close()
+	submit(t, q, "flaky")
+
+	var calls atomic.Int32
+	pool := workpool.NewWithError(1, q.Handler(func(ctx context.Context, job Job) error {
+		if calls.Add(1) == 1 {
+			return errors.New("first attempt")
+		}
+		return nil
+	}))
+	pool.Start()
+	assert.Eventually(t, func() bool {
+		n, err := q.Len()
+		return err == nil && n == 0
+	}, time.Second, time.Millisecond)
+	pool.Cancel()
+	assert.EqualError(t, pool.Wait(), "first attempt")
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestQueuePoisonJob(t *testing.T) {
+	q := open(t, filepath.Join(t.TempDir(), "queue.db"))
+	defer q.Close()
+	q.RetryBackoff = 20 * time.Millisecond
+	submit(t, q, "poison", "a", "b")
+
+	var poisoned atomic.Int32
+	var attempts atomic.Int32
+	pool := workpool.NewWithError(1, q.Handler(func(ctx context.Context, job Job) error {
+		if string(job.Payload) == "poison" {
+			poisoned.Add(1)
+			attempts.Store(int32(job.Attempts))
+			return errors.New("poison")
+		}
+		return nil
+	}))
+	pool.Start()
+
+	// The other jobs are processed while the poison job waits to be retried.
+	assert.Eventually(t, func() bool {
+		n, err := q.Len()
+		return err == nil && n == 1
+	}, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	pool.Cancel()
+	assert.EqualError(t, pool.Wait(), "poison")
+
+	// The backoff doubles, so within 50ms of 20ms, 40ms, ... waits the job is only retried a few times.
+	assert.GreaterOrEqual(t, poisoned.Load(), int32(1))
+	assert.LessOrEqual(t, poisoned.Load(), int32(4))
+	jobs, err := q.Jobs()
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, int(poisoned.Load()), jobs[0].Attempts)
+	assert.Equal(t, poisoned.Load()-1, attempts.Load(), "the job is given its previous attempts")
+}
+
+func TestQueueInspectAndPurge(t *testing.T) {
+	q := open(t, filepath.Join(t.TempDir(), "queue.db"))
+	defer q.Close()
+	submit(t, q, "a", "b", "c", "d")
+
+	running, changed, _, err := q.take()
+	require.NoError(t, err)
+	require.Nil(t, changed)
+	defer q.finish(running)
+
+	jobs, err := q.Jobs()
+	require.NoError(t, err)
+	require.Len(t, jobs, 4)
+	assert.Equal(t, Job{ID: running.ID, Payload: []byte("a"), Running: true}, jobs[0])
+	assert.Equal(t, Job{ID: jobs[1].ID, Payload: []byte("b")}, jobs[1])
+
+	deleted, err := q.Delete(running.ID)
+	require.NoError(t, err)
+	assert.False(t, deleted, "running jobs are not deleted")
+	deleted, err = q.Delete(jobs[1].ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	deleted, err = q.Delete(jobs[1].ID)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	n, err := q.Purge()
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	jobs, err = q.Jobs()
+	require.NoError(t, err)
+	assert.Equal(t, []Job{running}, jobs)
+}
@@ -0,0 +1,37 @@
+package workpool
+
+import (
+	"context"
+)
+
+// SubmitWithCallback is like Submit, but instead of sending the result of the item to Results it calls callback with
+// it. Callbacks run on Callbacks, or on their own goroutine, never on the worker. In Ordered mode callbacks are
+// started in the order the items were submitted. The callback is not called if the pool is cancelled before the item
+// is processed, or the item is dropped as a duplicate because of Key.
+func (p *TypedPool[In, Out]) SubmitWithCallback(item In, callback func(result Out, err error)) error {
+	p.init()
+	if p.ctx.Err() != nil || p.isFinishing() {
+		return ErrPoolClosed
+	}
+	if !p.claim(item) {
+		return nil
+	}
+	if !p.queue.pushEntry(entry[In]{item: item, callback: callback}, p.QueueSize, p.ctx.Done()) {
+		p.unclaim(item)
+		return ErrPoolClosed
+	}
+	p.addTotal(1)
+	p.wake()
+	return nil
+}
+
+// runCallback calls a callback outside of the worker.
+func (p *TypedPool[In, Out]) runCallback(callback func(Out, error), result Result[Out]) {
+	run := func() {
+		callback(result.Value, result.Err)
+	}
+	if p.Callbacks != nil && p.Callbacks.submit(task{run: func(context.Context) { run() }, fail: func(error) {}}) {
+		return
+	}
+	go run()
+}
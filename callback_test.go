@@ -0,0 +1,84 @@
+package workpool
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// callbackResults collects the results passed to callbacks.
+type callbackResults struct {
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	results []Result[int]
+}
+
+func (c *callbackResults) callback() func(int, error) {
+	c.wg.Add(1)
+	return func(value int, err error) {
+		defer c.wg.Done()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.results = append(c.results, Result[int]{Value: value, Err: err})
+	}
+}
+
+func TestSubmitWithCallback(t *testing.T) {
+	pool := NewTypedPool(2, func(abort <-chan struct{}, item int) (int, error) {
+		if item < 0 {
+			return 0, errors.New("negative")
+		}
+		return item * 2, nil
+	})
+	pool.Start()
+
+	var got callbackResults
+	for _, item := range []int{1, 2, -1} {
+		assert.NoError(t, pool.SubmitWithCallback(item, got.callback()))
+	}
+	assert.NoError(t, pool.Submit(10))
+
+	// Only the item submitted without a callback is sent to Results.
+	result := <-pool.Results()
+	assert.Equal(t, Result[int]{Value: 20}, result)
+	pool.Finish()
+	for range pool.Results() {
+		t.Error("unexpected result")
+	}
+	assert.EqualError(t, pool.Wait(), "negative")
+	got.wg.Wait()
+	assert.ElementsMatch(t, []Result[int]{{Value: 2}, {Value: 4}, {Err: errors.New("negative")}}, got.results)
+
+	assert.ErrorIs(t, pool.SubmitWithCallback(3, func(int, error) {}), ErrPoolClosed)
+}
+
+func TestSubmitWithCallbackOnExecutor(t *testing.T) {
+	callbacks := NewExecutor(1)
+	callbacks.Start()
+	pool := NewTypedPool(3, func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	})
+	pool.Ordered = true
+	pool.Callbacks = callbacks
+	pool.Start()
+
+	// A single callback worker runs the callbacks one at a time, in submission order.
+	var got callbackResults
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, pool.SubmitWithCallback(i, got.callback()))
+	}
+	pool.Finish()
+	for range pool.Results() {
+	}
+	assert.NoError(t, pool.Wait())
+	got.wg.Wait()
+	callbacks.Finish()
+	assert.NoError(t, callbacks.Wait())
+
+	for i, result := range got.results {
+		assert.Equal(t, i, result.Value)
+	}
+	assert.Len(t, got.results, 20)
+}
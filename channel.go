@@ -0,0 +1,144 @@
+package workpool
+
+import (
+	"reflect"
+	"sync"
+)
+
+// FromChannel creates a handler which calls fn for each item received from ch, until ch is closed. While waiting for
+// an item it also watches the abort signal, so a cancelled pool is never stuck on an idle channel. Errors returned by
+// fn are reported to the pool and the worker keeps going.
+func FromChannel[T any](ch <-chan T, fn func(item T) error) ErrWorkHandler {
+	return func(abort <-chan struct{}) (bool, error) {
+		item, ok := receive(abort, ch)
+		if !ok {
+			return false, nil
+		}
+		return true, fn(item)
+	}
+}
+
+// receive reads an item from in. False is returned if in is closed or abort is closed first.
+func receive[T any](abort <-chan struct{}, in <-chan T) (T, bool) {
+	select {
+	case item, ok := <-in:
+		return item, ok
+	case <-abort:
+		var zero T
+		return zero, false
+	}
+}
+
+// WeightedChannel is one of the channels read by FromChannels.
+type WeightedChannel[T any] struct {
+	// Items is the channel the items are received from.
+	Items <-chan T
+
+	// Weight is how many items are taken from this channel in a row when its turn comes, before moving on to the next
+	// channel. Zero or less means one.
+	Weight int
+}
+
+// FromChannels is like FromChannel, but receives items from several channels, so that one pool can serve several
+// queues. The workers take items from the channels in turn, following their weights, skipping channels without
+// an item ready. The handler reports that there is no more work once every channel is closed.
+func FromChannels[T any](channels []WeightedChannel[T], fn func(item T) error) ErrWorkHandler {
+	r := &roundRobin[T]{sources: make([]roundRobinSource[T], len(channels))}
+	for i, ch := range channels {
+		r.sources[i] = roundRobinSource[T]{items: ch.Items, weight: max(ch.Weight, 1)}
+	}
+	return func(abort <-chan struct{}) (bool, error) {
+		item, ok := r.receive(abort)
+		if !ok {
+			return false, nil
+		}
+		return true, fn(item)
+	}
+}
+
+// roundRobin is the rotation between the channels of FromChannels, shared by all workers.
+type roundRobin[T any] struct {
+	mu      sync.Mutex
+	sources []roundRobinSource[T]
+
+	// next is the source whose turn it is, and taken the number of items taken from it during the turn.
+	next  int
+	taken int
+}
+
+type roundRobinSource[T any] struct {
+	items  <-chan T
+	weight int
+	closed bool
+}
+
+// receive takes the next item. False is returned once every source is closed, or if abort is closed first.
+func (r *roundRobin[T]) receive(abort <-chan struct{}) (T, bool) {
+	var zero T
+	for {
+		r.mu.Lock()
+		// Try the sources in turn, starting with the one whose turn it is.
+		open := 0
+		for n := 0; n < len(r.sources); n++ {
+			i := (r.next + n) % len(r.sources)
+			source := &r.sources[i]
+			if source.closed {
+				continue
+			}
+			open++
+			select {
+			case item, ok := <-source.items:
+				if !ok {
+					source.closed = true
+					open--
+					continue
+				}
+				r.took(i)
+				r.mu.Unlock()
+				return item, true
+			default:
+			}
+		}
+		if open == 0 {
+			r.mu.Unlock()
+			return zero, false
+		}
+
+		// Nothing is ready, wait for any of the open sources.
+		cases := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(abort)}}
+		indexes := []int{-1}
+		for i, source := range r.sources {
+			if !source.closed {
+				cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(source.items)})
+				indexes = append(indexes, i)
+			}
+		}
+		r.mu.Unlock()
+
+		chosen, value, ok := reflect.Select(cases)
+		if chosen == 0 {
+			return zero, false
+		}
+		r.mu.Lock()
+		if !ok {
+			r.sources[indexes[chosen]].closed = true
+			r.mu.Unlock()
+			continue
+		}
+		r.took(indexes[chosen])
+		r.mu.Unlock()
+		return value.Interface().(T), true
+	}
+}
+
+// took moves the rotation on after an item was taken from source i. The caller must hold r.mu.
+func (r *roundRobin[T]) took(i int) {
+	if i != r.next {
+		// The source whose turn it was had nothing, so the turn passes to the source which did.
+		r.next, r.taken = i, 0
+	}
+	r.taken++
+	if r.taken >= r.sources[i].weight {
+		r.next, r.taken = (i+1)%len(r.sources), 0
+	}
+}
@@ -0,0 +1,92 @@
+package workpool
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromChannel(t *testing.T) {
+	var sum int64
+	handler := FromChannel(gen(1, 2, 3, 4), func(item int) error {
+		atomic.AddInt64(&sum, int64(item))
+		if item == 2 {
+			return errors.New("two")
+		}
+		return nil
+	})
+
+	assert.EqualError(t, NewWithError(2, handler).Run(), "two")
+	assert.Equal(t, int64(10), sum)
+}
+
+func TestFromChannelAbort(t *testing.T) {
+	// Nothing is ever sent, the pool must still stop when cancelled.
+	ch := make(chan int)
+	pool := NewWithError(2, FromChannel(ch, func(item int) error {
+		return nil
+	}))
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		pool.Cancel()
+	}()
+
+	assert.NoError(t, pool.Run())
+}
+
+// buffered returns a closed channel holding items.
+func buffered(items ...string) chan string {
+	ch := make(chan string, len(items))
+	for _, item := range items {
+		ch <- item
+	}
+	close(ch)
+	return ch
+}
+
+func TestFromChannels(t *testing.T) {
+	var order []string
+	handler := FromChannels([]WeightedChannel[string]{
+		{Items: buffered("a1", "a2", "a3", "a4", "a5")},
+		{Items: buffered("b1", "b2", "b3", "b4"), Weight: 2},
+		{Items: buffered("c1")},
+	}, func(item string) error {
+		order = append(order, item)
+		return nil
+	})
+
+	assert.NoError(t, NewWithError(1, handler).Run())
+	assert.Equal(t, []string{"a1", "b1", "b2", "c1", "a2", "b3", "b4", "a3", "a4", "a5"}, order)
+}
+
+func TestFromChannelsWaits(t *testing.T) {
+	a := make(chan string)
+	b := make(chan string)
+	var received []string
+	pool := NewWithError(1, FromChannels([]WeightedChannel[string]{{Items: a}, {Items: b}}, func(item string) error {
+		received = append(received, item)
+		return nil
+	}))
+	pool.Start()
+
+	b <- "b1"
+	a <- "a1"
+	close(b)
+	a <- "a2"
+	close(a)
+	assert.NoError(t, pool.Wait())
+	assert.Equal(t, []string{"b1", "a1", "a2"}, received)
+}
+
+func TestFromChannelsAbort(t *testing.T) {
+	pool := NewWithError(2, FromChannels([]WeightedChannel[int]{{Items: make(chan int)}}, func(item int) error {
+		return nil
+	}))
+	pool.Start()
+	time.Sleep(5 * time.Millisecond)
+	pool.Cancel()
+	assert.NoError(t, pool.Wait())
+}
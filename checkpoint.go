@@ -0,0 +1,226 @@
+package workpool
+
+import (
+	"log/slog"
+	"sort"
+	"sync"
+)
+
+// Checkpointer stores how far a TypedPool has got through a stream of items, such as the offset of a consumer in a log
+// or a partition, so that a consumer restarted after a crash resumes where it left off. See TypedPool.Checkpointer.
+type Checkpointer interface {
+	// Checkpoint records that every item up to and including position has been processed. A failed checkpoint is
+	// tried again at the next flush.
+	Checkpoint(position int64) error
+}
+
+// CheckpointFunc adapts a function to the Checkpointer interface.
+type CheckpointFunc func(position int64) error
+
+// Checkpoint calls f(position).
+func (f CheckpointFunc) Checkpoint(position int64) error {
+	return f(position)
+}
+
+// checkpoints tracks the positions of the items of a TypedPool between Submit and the end of their processing. Items
+// finish out of order, so the checkpoint is the position before the oldest item which has not finished yet.
+type checkpoints struct {
+	mu sync.Mutex
+
+	// pending holds the positions of the items which were submitted and not yet removed from the front, in order. Of a
+	// run of finished items only the last is kept, as the checkpoint cannot stop in the middle of the run. An item
+	// which never finishes, such as one which failed without DeadLetters, holds the checkpoint back for good, and this
+	// keeps the items finished after it from piling up.
+	pending []checkpointPosition
+
+	// position is the checkpoint, valid once advanced is set, and flushed is set once it has been given to the
+	// Checkpointer.
+	position int64
+	advanced bool
+	flushed  bool
+
+	// quit stops the background flushing once it has been started, stopped is closed once it has.
+	quit    chan struct{}
+	stopped chan struct{}
+}
+
+type checkpointPosition struct {
+	position int64
+	done     bool
+}
+
+// add tracks the position of a submitted item.
+func (c *checkpoints) add(position int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Positions normally arrive in order, so this is an append.
+	i := sort.Search(len(c.pending), func(i int) bool { return c.pending[i].position > position })
+	c.pending = append(c.pending, checkpointPosition{})
+	copy(c.pending[i+1:], c.pending[i:])
+	c.pending[i] = checkpointPosition{position: position}
+}
+
+// done marks the item at position as processed, and reports whether the checkpoint moved.
+func (c *checkpoints) done(position int64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if i, ok := c.find(position); ok {
+		c.pending[i].done = true
+		c.merge(i)
+	}
+	return c.advance()
+}
+
+// remove forgets the position of an item which could not be queued, and reports whether the checkpoint moved.
+func (c *checkpoints) remove(position int64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if i, ok := c.find(position); ok {
+		c.pending = append(c.pending[:i], c.pending[i+1:]...)
+		if i < len(c.pending) && c.pending[i].done {
+			c.merge(i)
+		}
+	}
+	return c.advance()
+}
+
+// merge drops the finished items next to the finished item at i, except for the last of the run. The caller must hold
+// c.mu.
+func (c *checkpoints) merge(i int) {
+	if i+1 < len(c.pending) && c.pending[i+1].done {
+		c.pending = append(c.pending[:i], c.pending[i+1:]...)
+	}
+	if i > 0 && c.pending[i-1].done {
+		c.pending = append(c.pending[:i-1], c.pending[i:]...)
+	}
+}
+
+// find returns the index of an unfinished item at position. The caller must hold c.mu.
+func (c *checkpoints) find(position int64) (int, bool) {
+	i := sort.Search(len(c.pending), func(i int) bool { return c.pending[i].position >= position })
+	for ; i < len(c.pending) && c.pending[i].position == position; i++ {
+		if !c.pending[i].done {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// advance moves the checkpoint past the finished items at the front, and reports whether it moved. The caller must
+// hold c.mu.
+func (c *checkpoints) advance() bool {
+	moved := false
+	for len(c.pending) > 0 && c.pending[0].done {
+		c.position, c.advanced, c.flushed = c.pending[0].position, true, false
+		c.pending = c.pending[1:]
+		moved = true
+	}
+	return moved
+}
+
+// unflushed returns the checkpoint if it moved since it was last flushed.
+func (c *checkpoints) unflushed() (int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.position, c.advanced && !c.flushed
+}
+
+// markFlushed records that position was given to the Checkpointer, unless the checkpoint has moved on since.
+func (c *checkpoints) markFlushed(position int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.position == position {
+		c.flushed = true
+	}
+}
+
+// trackPosition adds the position of an item which is about to be queued, for Checkpointer.
+func (p *TypedPool[In, Out]) trackPosition(item In) {
+	if p.Checkpointer == nil || p.Position == nil {
+		return
+	}
+	p.checkpoints.add(p.Position(item))
+	if p.CheckpointInterval > 0 {
+		p.startCheckpointFlush()
+	}
+}
+
+// untrackPosition forgets the position of an item which could not be queued, Submit returned an error for it so it is
+// up to the caller to submit it again.
+func (p *TypedPool[In, Out]) untrackPosition(item In) {
+	if p.Checkpointer == nil || p.Position == nil {
+		return
+	}
+	if p.checkpoints.remove(p.Position(item)) && p.CheckpointInterval <= 0 {
+		p.flushCheckpoint()
+	}
+}
+
+// checkpoint marks an item as processed, flushing the checkpoint straight away when there is no CheckpointInterval.
+func (p *TypedPool[In, Out]) checkpoint(item In) {
+	if p.Checkpointer == nil || p.Position == nil {
+		return
+	}
+	if p.checkpoints.done(p.Position(item)) && p.CheckpointInterval <= 0 {
+		p.flushCheckpoint()
+	}
+}
+
+// flushCheckpoint gives the checkpoint to the Checkpointer if it moved since the last flush.
+func (p *TypedPool[In, Out]) flushCheckpoint() {
+	// Flushes are serialized, so that an older position is never stored after a newer one.
+	p.checkpointMu.Lock()
+	defer p.checkpointMu.Unlock()
+	position, ok := p.checkpoints.unflushed()
+	if !ok {
+		return
+	}
+	if err := p.Checkpointer.Checkpoint(position); err != nil {
+		p.log(slog.LevelWarn, "workpool checkpoint failed", "position", position, "error", err)
+		return
+	}
+	p.checkpoints.markFlushed(position)
+}
+
+// startCheckpointFlush starts flushing the checkpoint every CheckpointInterval, unless it has already been started.
+func (p *TypedPool[In, Out]) startCheckpointFlush() {
+	c := &p.checkpoints
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.quit != nil {
+		return
+	}
+	c.quit = make(chan struct{})
+	c.stopped = make(chan struct{})
+	go func(quit <-chan struct{}) {
+		defer close(c.stopped)
+		clock := p.clock()
+		for {
+			timer := clock.NewTimer(p.CheckpointInterval)
+			select {
+			case <-timer.C():
+				p.flushCheckpoint()
+			case <-quit:
+				timer.Stop()
+				return
+			}
+		}
+	}(c.quit)
+}
+
+// stopCheckpoints stops the background flushing and flushes the final checkpoint. It is called once all workers have
+// exited.
+func (p *TypedPool[In, Out]) stopCheckpoints() {
+	if p.Checkpointer == nil || p.Position == nil {
+		return
+	}
+	c := &p.checkpoints
+	c.mu.Lock()
+	quit, stopped := c.quit, c.stopped
+	c.mu.Unlock()
+	if quit != nil {
+		close(quit)
+		<-stopped
+	}
+	p.flushCheckpoint()
+}
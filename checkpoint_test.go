@@ -0,0 +1,233 @@
+package workpool
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checkpointLog is a Checkpointer which records the positions it is given.
+type checkpointLog struct {
+	mu        sync.Mutex
+	positions []int64
+}
+
+func (c *checkpointLog) Checkpoint(position int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.positions = append(c.positions, position)
+	return nil
+}
+
+func (c *checkpointLog) get() []int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]int64(nil), c.positions...)
+}
+
+func itemPosition(item int) int64 {
+	return int64(item)
+}
+
+func TestCheckpointOutOfOrder(t *testing.T) {
+	release := make(chan struct{})
+	finished := make(chan int, 10)
+	pool := NewTypedPool(3, func(abort <-chan struct{}, item int) (int, error) {
+		if item == 0 {
+			<-release
+		} else {
+			finished <- item
+		}
+		return item, nil
+	})
+	log := &checkpointLog{}
+	pool.Checkpointer = log
+	pool.Position = itemPosition
+	pool.Start()
+	go func() {
+		for range pool.Results() {
+		}
+	}()
+
+	for i := 0; i < 6; i++ {
+		require.NoError(t, pool.Submit(i))
+	}
+	for i := 0; i < 5; i++ {
+		<-finished
+	}
+	// The later items are done, but the first one is not, so there is no checkpoint yet.
+	assert.Empty(t, log.get())
+
+	close(release)
+	pool.Finish()
+	require.NoError(t, pool.Wait())
+	assert.Equal(t, []int64{5}, log.get())
+}
+
+func TestCheckpointFailedItem(t *testing.T) {
+	log := &checkpointLog{}
+	pool := NewTypedPool(1, failOdd)
+	pool.Checkpointer = log
+	pool.Position = itemPosition
+	pool.Start()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, pool.Submit(i))
+	}
+	pool.Finish()
+	for range pool.Results() {
+	}
+	assert.Error(t, pool.Wait())
+	// Item 1 failed, so it is processed again after a restart along with the items after it.
+	assert.Equal(t, []int64{0}, log.get())
+}
+
+func TestCheckpointStalled(t *testing.T) {
+	log := &checkpointLog{}
+	pool := NewTypedPool(2, func(abort <-chan struct{}, item int) (int, error) {
+		if item == 0 {
+			return 0, errors.New("failed")
+		}
+		return item, nil
+	})
+	pool.Checkpointer = log
+	pool.Position = itemPosition
+	pool.Start()
+	go func() {
+		for range pool.Results() {
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, pool.Submit(i))
+	}
+	pool.Finish()
+	assert.Error(t, pool.Wait())
+	assert.Empty(t, log.get(), "item 0 failed without DeadLetters")
+	// The items finished after item 0 are merged instead of piling up behind it.
+	assert.Equal(t, []checkpointPosition{{position: 0}, {position: 999, done: true}}, pool.checkpoints.pending)
+}
+
+func TestCheckpointsMerge(t *testing.T) {
+	var c checkpoints
+	for i := int64(0); i < 6; i++ {
+		c.add(i)
+	}
+	assert.False(t, c.done(1))
+	assert.False(t, c.done(3))
+	assert.False(t, c.done(2))
+	assert.False(t, c.remove(4))
+	assert.False(t, c.done(5))
+	assert.Equal(t, []checkpointPosition{{position: 0}, {position: 5, done: true}}, c.pending)
+	assert.True(t, c.done(0))
+	assert.Equal(t, int64(5), c.position)
+	assert.Empty(t, c.pending)
+}
+
+func TestCheckpointDeadLetters(t *testing.T) {
+	log := &checkpointLog{}
+	pool := NewTypedPool(1, failOdd)
+	pool.Checkpointer = log
+	pool.Position = itemPosition
+	pool.DeadLetters = DeadLetterFunc[int](func(item int, err error) {})
+	pool.Start()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, pool.Submit(i))
+	}
+	pool.Finish()
+	for range pool.Results() {
+	}
+	assert.Error(t, pool.Wait())
+	assert.Equal(t, []int64{0, 1, 2, 3, 4}, log.get())
+}
+
+func TestCheckpointRetry(t *testing.T) {
+	var positions []int64
+	failed := false
+	pool := NewTypedPool(1, square)
+	pool.Checkpointer = CheckpointFunc(func(position int64) error {
+		if !failed {
+			failed = true
+			return errors.New("unavailable")
+		}
+		positions = append(positions, position)
+		return nil
+	})
+	pool.Position = itemPosition
+	pool.Start()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, pool.Submit(i))
+	}
+	pool.Finish()
+	for range pool.Results() {
+	}
+	require.NoError(t, pool.Wait())
+	assert.Equal(t, []int64{1, 2}, positions)
+}
+
+func TestCheckpointInterval(t *testing.T) {
+	clock := &manualClock{now: time.Unix(1000, 0), timers: make(chan *manualTimer, 1)}
+	log := &checkpointLog{}
+	pool := NewTypedPool(1, square)
+	pool.Clock = clock
+	pool.Checkpointer = log
+	pool.Position = itemPosition
+	pool.CheckpointInterval = time.Second
+	pool.Start()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, pool.Submit(i))
+		<-pool.Results()
+	}
+	assert.Empty(t, log.get())
+
+	timer := <-clock.timers
+	assert.Equal(t, time.Second, timer.d)
+	timer.c <- clock.Now()
+	// The next timer is created once the flush is done.
+	<-clock.timers
+	assert.Equal(t, []int64{2}, log.get())
+
+	// The final position is flushed when the pool finishes.
+	require.NoError(t, pool.Submit(3))
+	<-pool.Results()
+	pool.Finish()
+	require.NoError(t, pool.Wait())
+	assert.Equal(t, []int64{2, 3}, log.get())
+}
+
+func TestCheckpointTrySubmitRetried(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		if item == 0 {
+			close(started)
+			<-release
+		}
+		return item, nil
+	})
+	log := &checkpointLog{}
+	pool.Checkpointer = log
+	pool.Position = itemPosition
+	pool.QueueSize = 1
+	pool.Start()
+	go func() {
+		for range pool.Results() {
+		}
+	}()
+
+	require.NoError(t, pool.Submit(0))
+	<-started
+	require.NoError(t, pool.Submit(1))
+	assert.False(t, pool.TrySubmit(2), "the queue is full")
+	close(release)
+	require.NoError(t, pool.Submit(2))
+	require.NoError(t, pool.Submit(3))
+	pool.Finish()
+	require.NoError(t, pool.Wait())
+	positions := log.get()
+	require.NotEmpty(t, positions)
+	assert.Equal(t, int64(3), positions[len(positions)-1], "the failed TrySubmit does not hold the checkpoint back")
+}
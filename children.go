@@ -0,0 +1,55 @@
+package workpool
+
+import "errors"
+
+// AddChild makes child a sub-pool of the pool, like a context derived from another. Cancelling the pool cancels its
+// children with the same cause, while a child can be cancelled, or stop, on its own. Wait on the pool covers the whole
+// tree: it returns once the pool and all of its descendants have finished.
+//
+// Children added before the pool starts are started along with it, children added afterwards must be started by the
+// caller. A child added to a cancelled pool is cancelled straight away.
+func (p *WorkPool) AddChild(child Pool) {
+	p.init()
+	p.childMu.Lock()
+	p.children = append(p.children, child)
+	p.childMu.Unlock()
+	if p.Cancelled() {
+		child.base().CancelWithCause(p.AbortCause())
+	}
+}
+
+// Children returns the pools added with AddChild.
+func (p *WorkPool) Children() []Pool {
+	p.childMu.Lock()
+	defer p.childMu.Unlock()
+	return append([]Pool(nil), p.children...)
+}
+
+// startChildren starts the children added before the pool started.
+func (p *WorkPool) startChildren() {
+	for _, child := range p.Children() {
+		child.Start()
+	}
+}
+
+// cancelChildren cancels the children with the cause of the pool's cancellation.
+func (p *WorkPool) cancelChildren() {
+	cause := p.AbortCause()
+	for _, child := range p.Children() {
+		child.base().CancelWithCause(cause)
+	}
+}
+
+// waitChildren waits for the children once the pool itself has finished with err. The errors of the pool and its
+// children are returned joined, or err alone when the pool has no children.
+func (p *WorkPool) waitChildren(err error) error {
+	children := p.Children()
+	if len(children) == 0 {
+		return err
+	}
+	errs := []error{err}
+	for _, child := range children {
+		errs = append(errs, child.Wait())
+	}
+	return errors.Join(errs...)
+}
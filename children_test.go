@@ -0,0 +1,86 @@
+package workpool
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChildrenCancel(t *testing.T) {
+	blocking := func(abort <-chan struct{}) bool {
+		<-abort
+		return false
+	}
+	parent := New(1, blocking)
+	child := New(1, blocking)
+	grandchild := NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	})
+	parent.AddChild(child)
+	child.AddChild(grandchild)
+	assert.Equal(t, []Pool{child}, parent.Children())
+
+	parent.Start()
+	cause := errors.New("shutting down")
+	parent.CancelWithCause(cause)
+
+	assert.NoError(t, parent.Wait())
+	assert.True(t, child.Cancelled())
+	assert.True(t, grandchild.Cancelled())
+	assert.Equal(t, cause, grandchild.AbortCause())
+	assert.ErrorIs(t, grandchild.Submit(1), ErrPoolClosed)
+}
+
+func TestChildrenWait(t *testing.T) {
+	release := make(chan struct{})
+	parent := New(1, func(abort <-chan struct{}) bool { return false })
+	child := NewWithError(1, func(abort <-chan struct{}) (bool, error) {
+		<-release
+		return false, errors.New("child failed")
+	})
+	parent.AddChild(child)
+	parent.Start()
+
+	waited := make(chan error)
+	go func() {
+		waited <- parent.Wait()
+	}()
+	select {
+	case <-waited:
+		t.Fatal("parent finished before its child")
+	case <-time.After(5 * time.Millisecond):
+	}
+	close(release)
+	assert.EqualError(t, <-waited, "child failed")
+	assert.False(t, parent.Cancelled())
+}
+
+func TestChildCancelDoesNotCascadeUp(t *testing.T) {
+	parent := New(1, func(abort <-chan struct{}) bool {
+		<-abort
+		return false
+	})
+	child := New(1, func(abort <-chan struct{}) bool {
+		<-abort
+		return false
+	})
+	parent.AddChild(child)
+	parent.Start()
+	child.Cancel()
+	require.Eventually(t, func() bool { return child.StopReason() == StopCancelled }, time.Second, time.Millisecond)
+	assert.False(t, parent.Cancelled())
+
+	parent.Cancel()
+	assert.NoError(t, parent.Wait())
+}
+
+func TestAddChildToCancelledPool(t *testing.T) {
+	parent := New(1, func(abort <-chan struct{}) bool { return false })
+	parent.Cancel()
+	child := New(1, func(abort <-chan struct{}) bool { return false })
+	parent.AddChild(child)
+	assert.True(t, child.Cancelled())
+}
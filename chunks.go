@@ -0,0 +1,40 @@
+package workpool
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// Chunks splits the index range [0, length) into n contiguous chunks of nearly equal size and calls fn for each of them
+// on its own worker, returning once they have all finished. It suits CPU-bound processing of large slices, where
+// handing out items one at a time would cost more than the work itself. If n is not positive GOMAXPROCS is used, and
+// no more chunks are made than there are indices.
+func Chunks(n, length int, fn func(start, end int)) {
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	if n > length {
+		n = length
+	}
+	if n == 0 {
+		return
+	}
+
+	next := int64(-1)
+	pool := New(n, func(abort <-chan struct{}) bool {
+		i := int(atomic.AddInt64(&next, 1))
+		if i >= n {
+			return false
+		}
+		// The first length%n chunks take one extra index.
+		size, extra := length/n, length%n
+		start := i*size + min(i, extra)
+		end := start + size
+		if i < extra {
+			end++
+		}
+		fn(start, end)
+		return true
+	})
+	pool.Run()
+}
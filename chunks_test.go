@@ -0,0 +1,43 @@
+package workpool
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunks(t *testing.T) {
+	for _, tc := range []struct {
+		n, length int
+		want      [][2]int
+	}{
+		{n: 3, length: 10, want: [][2]int{{0, 4}, {4, 7}, {7, 10}}},
+		{n: 2, length: 4, want: [][2]int{{0, 2}, {2, 4}}},
+		{n: 5, length: 2, want: [][2]int{{0, 1}, {1, 2}}},
+		{n: 4, length: 0},
+	} {
+		var mu sync.Mutex
+		var got [][2]int
+		Chunks(tc.n, tc.length, func(start, end int) {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, [2]int{start, end})
+		})
+		sort.Slice(got, func(i, j int) bool { return got[i][0] < got[j][0] })
+		assert.Equal(t, tc.want, got, "n=%d length=%d", tc.n, tc.length)
+	}
+}
+
+func TestChunksDefaultWorkers(t *testing.T) {
+	items := make([]int, 1000)
+	Chunks(0, len(items), func(start, end int) {
+		for i := start; i < end; i++ {
+			items[i]++
+		}
+	})
+	for i, v := range items {
+		assert.Equal(t, 1, v, "index %d", i)
+	}
+}
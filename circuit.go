@@ -0,0 +1,237 @@
+package workpool
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every call through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen blocks calls until the cool-down has passed.
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe call through, its outcome decides whether the circuit closes or opens again.
+	CircuitHalfOpen
+)
+
+// String returns the name of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreaker stops workers from calling a handler which keeps failing, for example because a downstream service
+// is unavailable. It trips once the error rate of recent calls reaches Threshold. While it is open the workers wait
+// instead of calling the handler. After CoolDown a single probe call is let through: if it succeeds the circuit closes,
+// otherwise it opens for another cool-down.
+//
+// A CircuitBreaker is added to a pool as middleware, and may be shared by several pools guarding the same dependency:
+//
+//	breaker := &workpool.CircuitBreaker{Threshold: 0.5, CoolDown: 10 * time.Second}
+//	pool := workpool.NewWithError(8, callService)
+//	pool.Use(breaker.Wrap)
+type CircuitBreaker struct {
+	// Threshold is the error rate, between 0 and 1, at which the circuit trips. Zero uses 0.5.
+	Threshold float64
+
+	// Window is the number of recent calls used to calculate the error rate. The circuit does not trip before Window
+	// calls have been made. Zero uses 10.
+	Window int
+
+	// CoolDown is how long the circuit stays open before a probe call is allowed. Zero uses one second.
+	CoolDown time.Duration
+
+	// OnStateChange, when set, is called whenever the state changes. It is called with the breaker locked, so it must
+	// not call State.
+	OnStateChange func(from, to CircuitState)
+
+	mu       sync.Mutex
+	state    CircuitState
+	results  []bool
+	next     int
+	failures int
+	openedAt time.Time
+	probing  bool
+	changed  chan struct{}
+}
+
+// State returns the current state of the circuit.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Wrap returns a handler which calls handler while the circuit allows it. An error returned by handler counts as a
+// failure, and so does a panic, a call which found work and returned no error counts as a success, and a call which
+// found no work or returned ErrNoWork is not counted. Workers waiting for the circuit stop waiting when the pool is cancelled.
+func (b *CircuitBreaker) Wrap(handler ErrWorkHandler) ErrWorkHandler {
+	return func(abort <-chan struct{}) (bool, error) {
+		probe, ok := b.allow(abort)
+		if !ok {
+			return false, nil
+		}
+		recorded := false
+		defer func() {
+			// A panic recovered by the pool must not leave the probe running forever.
+			if !recorded {
+				b.record(probe, true, ErrPanicked)
+			}
+		}()
+		foundWork, err := handler(abort)
+		recorded = true
+		b.record(probe, foundWork, err)
+		return foundWork, err
+	}
+}
+
+// allow blocks until a call is allowed. It returns whether the call is a probe, and false if abort was closed.
+func (b *CircuitBreaker) allow(abort <-chan struct{}) (probe bool, ok bool) {
+	for {
+		b.mu.Lock()
+		if b.changed == nil {
+			b.changed = make(chan struct{})
+		}
+		changed := b.changed
+		var timer *time.Timer
+		var wait <-chan time.Time
+		switch b.state {
+		case CircuitClosed:
+			b.mu.Unlock()
+			return false, true
+		case CircuitOpen:
+			remaining := b.coolDown() - time.Since(b.openedAt)
+			if remaining <= 0 {
+				b.setState(CircuitHalfOpen)
+				b.mu.Unlock()
+				continue
+			}
+			timer = time.NewTimer(remaining)
+			wait = timer.C
+		case CircuitHalfOpen:
+			if !b.probing {
+				b.probing = true
+				b.mu.Unlock()
+				return true, true
+			}
+		}
+		b.mu.Unlock()
+
+		aborted := false
+		select {
+		case <-changed:
+		case <-wait:
+		case <-abort:
+			aborted = true
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if aborted {
+			return false, false
+		}
+	}
+}
+
+// record updates the circuit with the outcome of a call. ErrNoWork is not a failure, the call found no work.
+func (b *CircuitBreaker) record(probe, foundWork bool, err error) {
+	if errors.Is(err, ErrNoWork) {
+		foundWork, err = false, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+		switch {
+		case err != nil:
+			b.open()
+		case foundWork:
+			b.reset()
+			b.setState(CircuitClosed)
+		default:
+			// Nothing was learned, let another call probe.
+			b.broadcast()
+		}
+		return
+	}
+	if b.state != CircuitClosed || (!foundWork && err == nil) {
+		return
+	}
+
+	window := b.Window
+	if window <= 0 {
+		window = 10
+	}
+	if len(b.results) < window {
+		b.results = append(b.results, err != nil)
+	} else {
+		if b.results[b.next] {
+			b.failures--
+		}
+		b.results[b.next] = err != nil
+		b.next = (b.next + 1) % window
+	}
+	if err != nil {
+		b.failures++
+	}
+
+	threshold := b.Threshold
+	if threshold <= 0 {
+		threshold = 0.5
+	}
+	if len(b.results) >= window && float64(b.failures)/float64(len(b.results)) >= threshold {
+		b.open()
+	}
+}
+
+// open trips the circuit.
+func (b *CircuitBreaker) open() {
+	b.openedAt = time.Now()
+	b.reset()
+	b.setState(CircuitOpen)
+}
+
+// reset forgets the recorded calls.
+func (b *CircuitBreaker) reset() {
+	b.results = b.results[:0]
+	b.next = 0
+	b.failures = 0
+}
+
+// setState changes the state and wakes up waiting workers.
+func (b *CircuitBreaker) setState(state CircuitState) {
+	from := b.state
+	b.state = state
+	b.broadcast()
+	if from != state && b.OnStateChange != nil {
+		b.OnStateChange(from, state)
+	}
+}
+
+// broadcast wakes up the workers waiting for the state to change.
+func (b *CircuitBreaker) broadcast() {
+	if b.changed != nil {
+		close(b.changed)
+	}
+	b.changed = make(chan struct{})
+}
+
+// coolDown returns the configured cool-down or its default.
+func (b *CircuitBreaker) coolDown() time.Duration {
+	if b.CoolDown <= 0 {
+		return time.Second
+	}
+	return b.CoolDown
+}
@@ -0,0 +1,164 @@
+package workpool
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errDownstream = errors.New("downstream unavailable")
+
+func TestCircuitBreakerTrips(t *testing.T) {
+	var mu sync.Mutex
+	var changes []CircuitState
+	breaker := &CircuitBreaker{
+		Threshold: 0.5,
+		Window:    4,
+		CoolDown:  20 * time.Millisecond,
+		OnStateChange: func(from, to CircuitState) {
+			mu.Lock()
+			defer mu.Unlock()
+			changes = append(changes, to)
+		},
+	}
+	never := make(chan struct{})
+	call := breaker.Wrap(func(abort <-chan struct{}) (bool, error) { return true, errDownstream })
+	succeed := breaker.Wrap(func(abort <-chan struct{}) (bool, error) { return true, nil })
+
+	// Two failures out of four calls trip the circuit.
+	for i := 0; i < 2; i++ {
+		_, err := succeed(never)
+		require.NoError(t, err)
+		_, err = call(never)
+		require.ErrorIs(t, err, errDownstream)
+	}
+	assert.Equal(t, CircuitOpen, breaker.State())
+
+	// The probe fails, so the circuit opens again.
+	start := time.Now()
+	_, err := call(never)
+	assert.ErrorIs(t, err, errDownstream)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.Equal(t, CircuitOpen, breaker.State())
+
+	// The next probe succeeds and closes the circuit.
+	_, err = succeed(never)
+	assert.NoError(t, err)
+	assert.Equal(t, CircuitClosed, breaker.State())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen, CircuitHalfOpen, CircuitClosed}, changes)
+}
+
+func TestCircuitBreakerSingleProbe(t *testing.T) {
+	breaker := &CircuitBreaker{Window: 1, CoolDown: time.Millisecond}
+	never := make(chan struct{})
+	_, err := breaker.Wrap(func(abort <-chan struct{}) (bool, error) { return true, errDownstream })(never)
+	require.Error(t, err)
+	require.Equal(t, CircuitOpen, breaker.State())
+
+	var calls, concurrent, maxConcurrent int32
+	release := make(chan struct{})
+	handler := breaker.Wrap(func(abort <-chan struct{}) (bool, error) {
+		n := atomic.AddInt32(&concurrent, 1)
+		defer atomic.AddInt32(&concurrent, -1)
+		if n > atomic.LoadInt32(&maxConcurrent) {
+			atomic.StoreInt32(&maxConcurrent, n)
+		}
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-release
+		}
+		return true, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handler(never)
+		}()
+	}
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	// Only the probe is running while the circuit is half-open.
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+	close(release)
+	wg.Wait()
+	assert.EqualValues(t, 4, atomic.LoadInt32(&calls))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&maxConcurrent))
+	assert.Equal(t, CircuitClosed, breaker.State())
+}
+
+func TestCircuitBreakerPool(t *testing.T) {
+	breaker := &CircuitBreaker{Window: 2, CoolDown: time.Hour}
+	var calls int32
+	pool := NewWithError(4, breaker.Wrap(func(abort <-chan struct{}) (bool, error) {
+		atomic.AddInt32(&calls, 1)
+		return true, errDownstream
+	}))
+	pool.Start()
+
+	assert.Eventually(t, func() bool { return breaker.State() == CircuitOpen }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	n := atomic.LoadInt32(&calls)
+	time.Sleep(10 * time.Millisecond)
+	// Workers are blocked while the circuit is open.
+	assert.Equal(t, n, atomic.LoadInt32(&calls))
+
+	// Cancelling the pool releases the waiting workers.
+	pool.Cancel()
+	assert.ErrorIs(t, pool.Wait(), errDownstream)
+}
+
+func TestCircuitBreakerProbePanics(t *testing.T) {
+	breaker := &CircuitBreaker{Window: 1, CoolDown: time.Millisecond}
+	var calls int32
+	pool := NewWithError(2, breaker.Wrap(func(abort <-chan struct{}) (bool, error) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			return true, errDownstream
+		case 2:
+			panic("probe")
+		default:
+			return false, nil
+		}
+	}))
+	pool.RecoverPanics = true
+
+	// The panicking probe opens the circuit again, so the next probe is let through and finds no more work.
+	done := make(chan error, 1)
+	go func() { done <- pool.Run() }()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, errDownstream)
+	case <-time.After(5 * time.Second):
+		pool.Cancel()
+		t.Fatal("the workers are stuck behind the panicked probe")
+	}
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&calls), int32(3))
+}
+
+func TestCircuitBreakerNoWork(t *testing.T) {
+	breaker := &CircuitBreaker{Window: 2}
+	never := make(chan struct{})
+	idle := breaker.Wrap(func(abort <-chan struct{}) (bool, error) { return true, ErrNoWork })
+	for i := 0; i < 4; i++ {
+		_, err := idle(never)
+		assert.ErrorIs(t, err, ErrNoWork)
+	}
+	assert.Equal(t, CircuitClosed, breaker.State(), "idle calls are not failures")
+}
+
+func TestCircuitStateString(t *testing.T) {
+	assert.Equal(t, "closed", CircuitClosed.String())
+	assert.Equal(t, "open", CircuitOpen.String())
+	assert.Equal(t, "half-open", CircuitHalfOpen.String())
+	assert.Equal(t, "unknown", CircuitState(9).String())
+}
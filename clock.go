@@ -0,0 +1,59 @@
+package workpool
+
+import (
+	"time"
+)
+
+// Clock is the source of time of a pool. It is used for the timing of handler calls, SubmitAfter, the Scheduler,
+// Supervisor backoffs, Deadline and MaxRuntime, the grace period of CancelAndWait, the MaxWait of a BatchPool, progress
+// reports and health reports. Tests can replace it to control time, the workpooltest package has an implementation for
+// that. The RateLimiter, the CircuitBreaker and the Pipeline stages use the time package, as they are not tied to a
+// single pool.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTimer creates a timer which sends the time on its channel once d has elapsed.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer created by a Clock, like a time.Timer.
+type Timer interface {
+	// C returns the channel on which the time is sent when the timer fires.
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing. It returns false if the timer has already fired or been stopped.
+	Stop() bool
+}
+
+// systemClock is the Clock backed by the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) NewTimer(d time.Duration) Timer { return systemTimer{time.NewTimer(d)} }
+
+// systemTimer is a time.Timer.
+type systemTimer struct {
+	*time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time { return t.Timer.C }
+
+// clock returns the Clock of the pool.
+func (p *WorkPool) clock() Clock {
+	if p.Clock != nil {
+		return p.Clock
+	}
+	return systemClock{}
+}
+
+// since returns the time elapsed since t.
+func (p *WorkPool) since(t time.Time) time.Duration {
+	return p.clock().Now().Sub(t)
+}
+
+// until returns the duration until t.
+func (p *WorkPool) until(t time.Time) time.Duration {
+	return t.Sub(p.clock().Now())
+}
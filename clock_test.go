@@ -0,0 +1,107 @@
+package workpool
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// manualClock is a Clock whose timers fire when the test says so.
+type manualClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers chan *manualTimer
+}
+
+type manualTimer struct {
+	c chan time.Time
+	d time.Duration
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) NewTimer(d time.Duration) Timer {
+	t := &manualTimer{c: make(chan time.Time, 1), d: d}
+	c.timers <- t
+	return t
+}
+
+func (t *manualTimer) C() <-chan time.Time { return t.c }
+
+func (t *manualTimer) Stop() bool { return true }
+
+func TestClockMaxRuntime(t *testing.T) {
+	clock := &manualClock{now: time.Unix(1000, 0), timers: make(chan *manualTimer, 1)}
+	pool := New(1, func(abort <-chan struct{}) bool {
+		<-abort
+		return false
+	})
+	pool.Clock = clock
+	pool.MaxRuntime = time.Hour
+	pool.Start()
+
+	timer := <-clock.timers
+	assert.Equal(t, time.Hour, timer.d)
+	clock.mu.Lock()
+	clock.now = clock.now.Add(time.Hour)
+	clock.mu.Unlock()
+	timer.c <- clock.Now()
+
+	require.NoError(t, pool.Wait())
+	assert.True(t, errors.Is(pool.AbortCause(), ErrDeadlineExceeded))
+	assert.Equal(t, time.Hour, pool.Stats().Duration)
+}
+
+func TestClockCancelAndWait(t *testing.T) {
+	clock := &manualClock{now: time.Unix(1000, 0), timers: make(chan *manualTimer, 1)}
+	stuck := make(chan struct{})
+	defer close(stuck)
+	started := make(chan struct{})
+	pool := New(1, func(abort <-chan struct{}) bool {
+		close(started)
+		// Ignores the abort signal.
+		<-stuck
+		return false
+	})
+	pool.Clock = clock
+	pool.Start()
+	<-started
+
+	reports := make(chan CancelReport)
+	go func() { reports <- pool.CancelAndWait(time.Hour) }()
+	timer := <-clock.timers
+	assert.Equal(t, time.Hour, timer.d)
+	timer.c <- clock.Now()
+	assert.Equal(t, CancelReport{Abandoned: 1}, <-reports)
+}
+
+func TestClockBatchPoolMaxWait(t *testing.T) {
+	clock := &manualClock{now: time.Unix(1000, 0), timers: make(chan *manualTimer, 1)}
+	var mu sync.Mutex
+	var batches [][]int
+	pool := NewBatchPool(1, 100, time.Hour, batchRecorder(&mu, &batches))
+	pool.Clock = clock
+	pool.Start()
+
+	require.NoError(t, pool.Submit(1))
+	timer := <-clock.timers
+	assert.Equal(t, time.Hour, timer.d)
+	timer.c <- clock.Now()
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(batches) == 1
+	}, time.Second, time.Millisecond)
+
+	pool.Finish()
+	assert.NoError(t, pool.Wait())
+	assert.Equal(t, [][]int{{1}}, batches)
+}
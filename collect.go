@@ -0,0 +1,32 @@
+package workpool
+
+// Collect starts pool if it has not been started, and gathers the items sent to out by its workers. It returns once out
+// is closed, or once the pool has finished and everything already buffered in out has been read, so it is correct both
+// for pools whose Close function closes out and for pools which never close it. The pool's error is also returned.
+func Collect[T any](pool *WorkPool, out <-chan T) ([]T, error) {
+	pool.Start()
+
+	var results []T
+	for {
+		select {
+		case item, ok := <-out:
+			if !ok {
+				return results, pool.Wait()
+			}
+			results = append(results, item)
+		case <-pool.done:
+			// No more items will be sent, take whatever is left.
+			for {
+				select {
+				case item, ok := <-out:
+					if !ok {
+						return results, pool.Err()
+					}
+					results = append(results, item)
+				default:
+					return results, pool.Err()
+				}
+			}
+		}
+	}
+}
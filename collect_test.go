@@ -0,0 +1,67 @@
+package workpool
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectClosedOutput(t *testing.T) {
+	inputs := gen(1, 2, 3, 4)
+	outputs := make(chan int)
+	pool := NewWithClose(2, sq(inputs, outputs), func() {
+		close(outputs)
+	})
+
+	results, err := Collect(pool, outputs)
+	assert.NoError(t, err)
+	sort.Ints(results)
+	assert.Equal(t, []int{1, 4, 9, 16}, results)
+}
+
+func TestCollectError(t *testing.T) {
+	outputs := make(chan int, 1)
+	worker := func(abort <-chan struct{}) (bool, error) {
+		outputs <- 1
+		return false, errors.New("failed")
+	}
+
+	results, err := Collect(NewWithError(1, worker), outputs)
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, []int{1}, results)
+}
+
+func TestCollectOpenOutput(t *testing.T) {
+	// The output channel is never closed, Collect must stop when the pool finishes.
+	inputs := gen(1, 2, 3)
+	outputs := make(chan int, 3)
+	pool := New(1, sq(inputs, outputs))
+
+	results, err := Collect(pool, outputs)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 4, 9}, results)
+}
+
+func TestCollectCancelled(t *testing.T) {
+	outputs := make(chan int)
+	worker := func(abort <-chan struct{}) (bool, error) {
+		select {
+		case outputs <- 1:
+			return true, nil
+		case <-abort:
+			return false, nil
+		}
+	}
+	pool := NewWithError(2, worker)
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		pool.Cancel()
+	}()
+
+	results, err := Collect(pool, outputs)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, results)
+}
@@ -0,0 +1,75 @@
+package workpool
+
+import (
+	"context"
+)
+
+// workerIDKey is the context key of the worker ID.
+type workerIDKey struct{}
+
+// stateKey is the context key of the worker state.
+type stateKey struct{}
+
+// WorkerID returns the ID of the worker calling a ContextWorkHandler. False is returned if ctx does not come from a
+// WorkPool.
+func WorkerID(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(workerIDKey{}).(int)
+	return id, ok
+}
+
+// State returns the state created by WorkerState for the worker calling a ContextWorkHandler. Nil is returned if ctx
+// does not come from a WorkPool, or the pool has no WorkerState.
+func State(ctx context.Context) any {
+	return ctx.Value(stateKey{})
+}
+
+// AbortContext returns a context which is cancelled when abort is closed, for handlers which are given an abort signal
+// and call APIs taking a context. The context must be cancelled once it is not needed.
+func AbortContext(abort <-chan struct{}) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-abort:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// callContext returns the context given to a ContextWorkHandler by the worker.
+func (p *WorkPool) callContext(w *worker) context.Context {
+	return callContext{Context: w.ctx, parent: p.parent, workerID: w.id, state: w.state}
+}
+
+// handlerContext returns the context of the current call of the worker: the one passed on by the innermost
+// ContextMiddleware, or the one created by callContext.
+func (p *WorkPool) handlerContext(w *worker) context.Context {
+	if w.call != nil {
+		return w.call
+	}
+	return p.callContext(w)
+}
+
+// callContext is cancelled with the handler call, and looks up values in the context given to RunContext.
+type callContext struct {
+	context.Context
+	parent   context.Context
+	workerID int
+	state    any
+}
+
+func (c callContext) Value(key any) any {
+	if _, ok := key.(workerIDKey); ok {
+		return c.workerID
+	}
+	if _, ok := key.(stateKey); ok {
+		return c.state
+	}
+	if c.parent != nil {
+		if v := c.parent.Value(key); v != nil {
+			return v
+		}
+	}
+	return c.Context.Value(key)
+}
@@ -0,0 +1,76 @@
+package workpool
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type requestIDKey struct{}
+
+func TestContextHandler(t *testing.T) {
+	var mu sync.Mutex
+	ids := make(map[int]bool)
+	var values []any
+	pool := NewWithContext(2, func(ctx context.Context) bool {
+		id, ok := WorkerID(ctx)
+		assert.True(t, ok)
+		mu.Lock()
+		ids[id] = true
+		values = append(values, ctx.Value(requestIDKey{}))
+		mu.Unlock()
+
+		<-ctx.Done()
+		return false
+	})
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), requestIDKey{}, "abc"))
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		cancel()
+	}()
+	assert.NoError(t, pool.RunContext(ctx))
+	assert.Equal(t, map[int]bool{0: true, 1: true}, ids)
+	assert.Equal(t, []any{"abc", "abc"}, values)
+}
+
+func TestContextHandlerTaskTimeout(t *testing.T) {
+	calls := 0
+	pool := NewWithContext(1, func(ctx context.Context) bool {
+		calls++
+		if calls > 2 {
+			return false
+		}
+		_, ok := ctx.Deadline()
+		assert.True(t, ok)
+		<-ctx.Done()
+		assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+		return false
+	})
+	pool.TaskTimeout = time.Millisecond
+	assert.NoError(t, pool.Run())
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, int64(2), pool.Stats().Timeouts)
+}
+
+func TestWorkerIDNotPool(t *testing.T) {
+	_, ok := WorkerID(context.Background())
+	assert.False(t, ok)
+}
+
+func TestAbortContext(t *testing.T) {
+	abort := make(chan struct{})
+	ctx, cancel := AbortContext(abort)
+	defer cancel()
+	assert.NoError(t, ctx.Err())
+	close(abort)
+	<-ctx.Done()
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+
+	ctx, cancel = AbortContext(make(chan struct{}))
+	cancel()
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+}
@@ -0,0 +1,151 @@
+package workpool
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed cron expression. Each field is a bit set of the values it matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// domStar and dowStar record an unrestricted day field. When both day fields are restricted a day matching either
+	// one matches, as in cron.
+	domStar, dowStar bool
+}
+
+// cronDescriptors are the supported shorthands for common expressions.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// parseCron parses a standard five field cron expression: minute, hour, day of month, month and day of week. Fields
+// may be a "*", a value, a range such as "1-5", a step such as "*/15" or "10-40/10", or a comma separated list of
+// these. Sunday is both 0 and 7 in the day of week field. The descriptors @yearly, @monthly, @weekly, @daily and
+// @hourly are also accepted.
+func parseCron(spec string) (*cronSchedule, error) {
+	expr := strings.TrimSpace(spec)
+	if d, ok := cronDescriptors[expr]; ok {
+		expr = d
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("workpool: cron %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	var s cronSchedule
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("workpool: cron %q: minute: %w", spec, err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("workpool: cron %q: hour: %w", spec, err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("workpool: cron %q: day of month: %w", spec, err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("workpool: cron %q: month: %w", spec, err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("workpool: cron %q: day of week: %w", spec, err)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = strings.HasPrefix(fields[2], "*")
+	s.dowStar = strings.HasPrefix(fields[4], "*")
+	return &s, nil
+}
+
+// parseCronField parses one field into a bit set of the values between min and max which it matches.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		lo, hi, step := min, max, 1
+		rng := part
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+			rng = part[:i]
+		}
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			i := strings.IndexByte(rng, '-')
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(rng[:i])
+			hi, err2 = strconv.Atoi(rng[i+1:])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		default:
+			n, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", rng)
+			}
+			lo = n
+			hi = n
+			if step > 1 {
+				// "5/15" means from 5 to the end in steps of 15.
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is outside of %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// next returns the first matching time after t, in t's location. The zero time is returned if nothing matches within
+// five years, which happens for impossible dates such as "0 0 30 2 *".
+func (s *cronSchedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches checks the day of month and day of week fields.
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
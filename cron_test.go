@@ -0,0 +1,61 @@
+package workpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronNext(t *testing.T) {
+	// A Wednesday.
+	start := time.Date(2024, time.January, 10, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2024, time.January, 10, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, time.January, 10, 10, 15, 0, 0, time.UTC)},
+		{"5 * * * *", time.Date(2024, time.January, 10, 11, 5, 0, 0, time.UTC)},
+		{"0 9-17 * * 1-5", time.Date(2024, time.January, 10, 11, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2024, time.January, 11, 2, 30, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2024, time.January, 14, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, time.January, 14, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"10-40/10 10 * * *", time.Date(2024, time.January, 10, 10, 10, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, time.January, 11, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted.
+		{"0 0 13 * 5", time.Date(2024, time.January, 12, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			s, err := parseCron(test.spec)
+			require.NoError(t, err)
+			assert.Equal(t, test.next, s.next(start))
+		})
+	}
+}
+
+func TestCronInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@often",
+	} {
+		_, err := parseCron(spec)
+		assert.Error(t, err, spec)
+	}
+}
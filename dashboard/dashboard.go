@@ -0,0 +1,124 @@
+// Package dashboard serves a minimal web dashboard for the pools of a workpool.Manager. The page polls a JSON endpoint
+// and plots the throughput, queue depth and error rate of every pool, along with their most recent failures.
+//
+//	d := dashboard.New(manager)
+//	pool.Use(d.RecordFailures("images"))
+//	http.Handle("/workpool/", http.StripPrefix("/workpool", d))
+//
+// The routes are GET / for the page and GET /api/pools for the data, whose pools are admin.Status values with their
+// recent failures. Like the admin endpoint, the dashboard should only be reachable by operators.
+package dashboard
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/algorand/workpool"
+	"github.com/algorand/workpool/admin"
+)
+
+//go:embed index.html
+var page []byte
+
+// Failure is an error returned by a handler call.
+type Failure struct {
+	Time  time.Time `json:"time"`
+	Error string    `json:"error"`
+}
+
+// Pool is the JSON representation of a pool on the dashboard.
+type Pool struct {
+	admin.Status
+	Failures []Failure `json:"failures"`
+}
+
+// Snapshot is the JSON document served by /api/pools.
+type Snapshot struct {
+	Time  time.Time `json:"time"`
+	Pools []Pool    `json:"pools"`
+}
+
+// Dashboard is the http.Handler of the dashboard.
+type Dashboard struct {
+	// MaxFailures is the number of recent failures kept for each pool. Zero keeps 20.
+	MaxFailures int
+
+	manager *workpool.Manager
+
+	mu       sync.Mutex
+	failures map[string][]Failure
+}
+
+// New creates a Dashboard for the pools of manager.
+func New(manager *workpool.Manager) *Dashboard {
+	return &Dashboard{manager: manager, failures: make(map[string][]Failure)}
+}
+
+// RecordFailures returns a Middleware which records the errors of the handler calls of the pool added to the manager
+// as name, so that the dashboard lists them.
+func (d *Dashboard) RecordFailures(name string) workpool.Middleware {
+	return func(next workpool.ErrWorkHandler) workpool.ErrWorkHandler {
+		return func(abort <-chan struct{}) (bool, error) {
+			foundWork, err := next(abort)
+			if err != nil {
+				d.record(name, err)
+			}
+			return foundWork, err
+		}
+	}
+}
+
+// record keeps a failure of a pool, dropping the oldest one when there are too many.
+func (d *Dashboard) record(name string, err error) {
+	limit := d.MaxFailures
+	if limit <= 0 {
+		limit = 20
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	failures := append(d.failures[name], Failure{Time: time.Now(), Error: err.Error()})
+	if len(failures) > limit {
+		failures = append([]Failure(nil), failures[len(failures)-limit:]...)
+	}
+	d.failures[name] = failures
+}
+
+// Snapshot returns the current state of the pools, the most recent failure of each pool first.
+func (d *Dashboard) Snapshot() Snapshot {
+	snapshot := Snapshot{Time: time.Now(), Pools: []Pool{}}
+	for _, name := range d.manager.Names() {
+		pool, _ := d.manager.Pool(name)
+		d.mu.Lock()
+		recorded := d.failures[name]
+		failures := make([]Failure, len(recorded))
+		for i, failure := range recorded {
+			failures[len(recorded)-1-i] = failure
+		}
+		d.mu.Unlock()
+		snapshot.Pools = append(snapshot.Pools, Pool{Status: admin.PoolStatus(name, pool), Failures: failures})
+	}
+	return snapshot
+}
+
+// ServeHTTP implements http.Handler.
+func (d *Dashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	switch r.URL.Path {
+	case "/", "":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(page)
+	case "/api/pools":
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(d.Snapshot())
+	default:
+		http.NotFound(w, r)
+	}
+}
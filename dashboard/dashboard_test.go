@@ -0,0 +1,104 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/algorand/workpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboard(t *testing.T) {
+	typed := workpool.NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		if item%2 == 1 {
+			return 0, fmt.Errorf("item %d failed", item)
+		}
+		return item, nil
+	})
+	go func() {
+		for range typed.Results() {
+		}
+	}()
+	m := workpool.NewManager()
+	require.NoError(t, m.Add("typed", typed))
+	d := New(m)
+	d.MaxFailures = 2
+	typed.Use(d.RecordFailures("typed"))
+	m.Start()
+	for i := 0; i < 6; i++ {
+		require.NoError(t, typed.Submit(i))
+	}
+	typed.Finish()
+	require.Error(t, typed.Wait())
+
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pools", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var snapshot Snapshot
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &snapshot))
+	require.Len(t, snapshot.Pools, 1)
+	pool := snapshot.Pools[0]
+	assert.Equal(t, "typed", pool.Name)
+	assert.Equal(t, 3, pool.Errors)
+	assert.Equal(t, int64(6), pool.Done)
+	require.Len(t, pool.Failures, 2)
+	assert.Contains(t, pool.Failures[0].Error, "item 5 failed")
+	assert.Contains(t, pool.Failures[1].Error, "item 3 failed")
+}
+
+func TestDashboardPage(t *testing.T) {
+	d := New(workpool.NewManager())
+
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "api/pools")
+
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/pools", nil))
+	assert.JSONEq(t, `[]`, string(mustField(t, rec.Body.Bytes(), "pools")))
+
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/pools", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestRecordFailuresPlainPool(t *testing.T) {
+	calls := 0
+	pool := workpool.NewWithError(1, func(abort <-chan struct{}) (bool, error) {
+		calls++
+		if calls > 1 {
+			return false, nil
+		}
+		return true, errors.New("boom")
+	})
+	m := workpool.NewManager()
+	require.NoError(t, m.Add("plain", pool))
+	d := New(m)
+	pool.Use(d.RecordFailures("plain"))
+	m.Start()
+	_ = m.Wait()
+
+	failures := d.Snapshot().Pools[0].Failures
+	require.Len(t, failures, 1)
+	assert.Equal(t, "boom", failures[0].Error)
+	assert.False(t, failures[0].Time.IsZero())
+}
+
+// mustField returns the raw JSON of a field of a JSON object.
+func mustField(t *testing.T, data []byte, name string) json.RawMessage {
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &fields))
+	return fields[name]
+}
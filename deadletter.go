@@ -0,0 +1,51 @@
+package workpool
+
+// DeadLetter is an item which failed to be processed, along with the error returned by the handler.
+type DeadLetter[In any] struct {
+	Item In
+	Err  error
+}
+
+// DeadLetterSink receives the items of a TypedPool which failed to be processed. The pool does not retry items, so any
+// item for which the handler returns an error is final and is given to the sink. Implementations can forward items to
+// a channel, a log, or persistent storage for later inspection.
+type DeadLetterSink[In any] interface {
+	DeadLetter(item In, err error)
+}
+
+// DeadLetterFunc adapts a function to the DeadLetterSink interface.
+type DeadLetterFunc[In any] func(item In, err error)
+
+// DeadLetter calls f(item, err).
+func (f DeadLetterFunc[In]) DeadLetter(item In, err error) {
+	f(item, err)
+}
+
+// DeadLetterChan is a DeadLetterSink which sends items to a channel. Sending blocks the worker, so the channel should
+// be buffered or read concurrently. A TypedPool stops waiting for room in the channel once it is cancelled, and the
+// item is dropped.
+type DeadLetterChan[In any] chan<- DeadLetter[In]
+
+// DeadLetter sends the item and error to the channel.
+func (c DeadLetterChan[In]) DeadLetter(item In, err error) {
+	c <- DeadLetter[In]{Item: item, Err: err}
+}
+
+// deadLetterUntil sends the item and error to the channel unless cancelled is closed first.
+func (c DeadLetterChan[In]) deadLetterUntil(item In, err error, cancelled <-chan struct{}) {
+	select {
+	case c <- DeadLetter[In]{Item: item, Err: err}:
+	case <-cancelled:
+	}
+}
+
+// deadLetter gives an item to DeadLetters, giving up on a DeadLetterChan once the pool is cancelled.
+func (p *TypedPool[In, Out]) deadLetter(item In, err error) {
+	if sink, ok := p.DeadLetters.(interface {
+		deadLetterUntil(item In, err error, cancelled <-chan struct{})
+	}); ok {
+		sink.deadLetterUntil(item, err, p.ctx.Done())
+		return
+	}
+	p.DeadLetters.DeadLetter(item, err)
+}
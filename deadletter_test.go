@@ -0,0 +1,85 @@
+package workpool
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failOdd is a TypedHandler which fails for odd numbers.
+func failOdd(abort <-chan struct{}, item int) (int, error) {
+	if item%2 == 1 {
+		return 0, errors.New("odd")
+	}
+	return item, nil
+}
+
+func TestDeadLetterChan(t *testing.T) {
+	dead := make(chan DeadLetter[int], 10)
+	pool := NewTypedPool(2, failOdd)
+	pool.DeadLetters = DeadLetterChan[int](dead)
+	pool.Start()
+
+	for i := 0; i < 6; i++ {
+		require.NoError(t, pool.Submit(i))
+	}
+	pool.Finish()
+	failed := 0
+	for result := range pool.Results() {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	assert.Error(t, pool.Wait())
+	close(dead)
+
+	sum := 0
+	for letter := range dead {
+		assert.EqualError(t, letter.Err, "odd")
+		sum += letter.Item
+	}
+	assert.Equal(t, 3, failed)
+	assert.Equal(t, 1+3+5, sum)
+}
+
+func TestDeadLetterFunc(t *testing.T) {
+	var items []int
+	pool := NewTypedPool(1, failOdd)
+	pool.DeadLetters = DeadLetterFunc[int](func(item int, err error) {
+		items = append(items, item)
+	})
+	pool.Start()
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, pool.Submit(i))
+	}
+	pool.Finish()
+	for range pool.Results() {
+	}
+	pool.Wait()
+
+	assert.Equal(t, []int{1, 3}, items)
+}
+
+func TestDeadLetterChanCancel(t *testing.T) {
+	dead := make(chan DeadLetter[int])
+	pool := NewTypedPool(1, failOdd)
+	pool.DeadLetters = DeadLetterChan[int](dead)
+	pool.Start()
+	require.NoError(t, pool.Submit(1))
+
+	// Nobody reads the channel, cancelling the pool releases the worker.
+	time.Sleep(10 * time.Millisecond)
+	pool.Cancel()
+	done := make(chan error, 1)
+	go func() { done <- pool.Wait() }()
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the worker is stuck sending to the dead-letter channel")
+	}
+}
@@ -0,0 +1,36 @@
+package workpool
+
+import (
+	"context"
+	"fmt"
+)
+
+// ErrDeadlineExceeded is the cause of a pool cancelled because of Deadline or MaxRuntime. It matches
+// context.DeadlineExceeded with errors.Is.
+var ErrDeadlineExceeded = fmt.Errorf("workpool: deadline exceeded: %w", context.DeadlineExceeded)
+
+// startDeadline starts the timer for Deadline and MaxRuntime. It returns a function which stops the timer. The caller
+// must hold p.mu.
+func (p *WorkPool) startDeadline() (stop func()) {
+	deadline := p.Deadline
+	if p.MaxRuntime > 0 {
+		if limit := p.started.Add(p.MaxRuntime); deadline.IsZero() || limit.Before(deadline) {
+			deadline = limit
+		}
+	}
+	if deadline.IsZero() {
+		return func() {}
+	}
+
+	timer := p.clock().NewTimer(p.until(deadline))
+	quit := make(chan struct{})
+	go func() {
+		select {
+		case <-timer.C():
+			p.CancelWithCause(ErrDeadlineExceeded)
+		case <-quit:
+			timer.Stop()
+		}
+	}()
+	return func() { close(quit) }
+}
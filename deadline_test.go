@@ -0,0 +1,55 @@
+package workpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tick returns a handler which keeps finding work until the pool is cancelled.
+func tick(abort <-chan struct{}) bool {
+	select {
+	case <-abort:
+		return false
+	case <-time.After(time.Millisecond):
+		return true
+	}
+}
+
+func TestMaxRuntime(t *testing.T) {
+	pool := New(2, tick)
+	pool.MaxRuntime = 20 * time.Millisecond
+	start := time.Now()
+	assert.NoError(t, pool.Run())
+
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.ErrorIs(t, pool.AbortCause(), ErrDeadlineExceeded)
+	assert.ErrorIs(t, pool.AbortCause(), context.DeadlineExceeded)
+	stats := pool.Stats()
+	assert.True(t, stats.Cancelled)
+	assert.Greater(t, stats.Invocations, int64(2))
+}
+
+func TestDeadline(t *testing.T) {
+	pool := New(1, tick)
+	pool.MaxRuntime = time.Hour
+	pool.Deadline = time.Now().Add(10 * time.Millisecond)
+	assert.NoError(t, pool.Run())
+	assert.ErrorIs(t, pool.AbortCause(), ErrDeadlineExceeded)
+
+	// A deadline in the past cancels the pool right away.
+	pool = New(1, tick)
+	pool.Deadline = time.Now().Add(-time.Second)
+	assert.NoError(t, pool.Run())
+	assert.ErrorIs(t, pool.AbortCause(), ErrDeadlineExceeded)
+}
+
+func TestDeadlineNotReached(t *testing.T) {
+	pool := New(1, func(abort <-chan struct{}) bool { return false })
+	pool.MaxRuntime = 10 * time.Millisecond
+	assert.NoError(t, pool.Run())
+	time.Sleep(20 * time.Millisecond)
+	assert.False(t, pool.Cancelled())
+}
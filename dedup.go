@@ -0,0 +1,191 @@
+package workpool
+
+import (
+	"container/list"
+	"time"
+)
+
+// claim records the key and the position of an item which is about to be queued. False is returned, after calling
+// OnDuplicate, if an item with the same key is already queued or running.
+func (p *TypedPool[In, Out]) claim(item In) bool {
+	if p.Key != nil && !p.reserveKey(item) {
+		return false
+	}
+	p.trackPosition(item)
+	return true
+}
+
+// reserveKey records the key of an item, see claim.
+func (p *TypedPool[In, Out]) reserveKey(item In) bool {
+	key := p.Key(item)
+
+	p.keysMu.Lock()
+	_, dup := p.keys[key]
+	if !dup {
+		if p.keys == nil {
+			p.keys = make(map[string]struct{})
+		}
+		p.keys[key] = struct{}{}
+	}
+	p.keysMu.Unlock()
+
+	if dup && p.OnDuplicate != nil {
+		p.OnDuplicate(item)
+	}
+	return !dup
+}
+
+// unclaim forgets the key and the position of an item which could not be queued.
+func (p *TypedPool[In, Out]) unclaim(item In) {
+	p.release(item)
+	p.untrackPosition(item)
+}
+
+// release forgets the key of an item once it has been processed, or could not be queued.
+func (p *TypedPool[In, Out]) release(item In) {
+	if p.Key == nil {
+		return
+	}
+	key := p.Key(item)
+
+	p.keysMu.Lock()
+	delete(p.keys, key)
+	p.keysMu.Unlock()
+}
+
+// DedupWindow configures a Dedup stage.
+type DedupWindow struct {
+	// Window is how long a key is remembered after it was first seen. Items with the same key are dropped during that
+	// time. Zero or less remembers keys until they are evicted.
+	Window time.Duration
+
+	// MaxKeys, when positive, bounds the number of keys remembered. Once it is reached, the oldest keys are forgotten
+	// first, so their next items are let through again. Without a Window it defaults to 100000, so that memory stays
+	// bounded.
+	MaxKeys int
+
+	// BloomKeys, when positive, remembers the keys with Bloom filters sized for that many keys per Window, instead of
+	// keeping the keys themselves. Memory is fixed whatever the keys, but a small share of unique items, set by
+	// BloomFalsePositives, are taken for duplicates and dropped. Keys are remembered for between one and two Windows.
+	// Without a Window the filters are rotated every BloomKeys keys instead, so a key is remembered until between
+	// BloomKeys and twice as many other keys were seen. MaxKeys is ignored.
+	BloomKeys int
+
+	// BloomFalsePositives is the share of unique items wrongly dropped when the filters hold BloomKeys keys. Zero is
+	// one percent.
+	BloomFalsePositives float64
+}
+
+// Dedup adds a stage which passes on the items read from in, dropping those whose key, returned by key, was already
+// seen within the window, for streams of noisy events. Items keep their order.
+func Dedup[T any](p *Pipeline, in <-chan T, key func(T) string, window DedupWindow) <-chan T {
+	out := make(chan T)
+	seen := newSeenKeys(window)
+	p.add(&WorkPool{
+		Workers: 1,
+		Handler: func(abort <-chan struct{}) bool {
+			item, ok := receive(abort, in)
+			if !ok {
+				return false
+			}
+			if seen.check(key(item), time.Now()) {
+				return true
+			}
+			select {
+			case out <- item:
+				return true
+			case <-abort:
+				return false
+			}
+		},
+		Close: func() {
+			close(out)
+		},
+	})
+	return out
+}
+
+// defaultDedupKeys is MaxKeys when there is no Window.
+const defaultDedupKeys = 100000
+
+// seenKeys remembers the keys seen by a Dedup stage.
+type seenKeys struct {
+	window DedupWindow
+
+	// keys holds the exact keys with the time they were first seen, order the keys from oldest to newest.
+	keys  map[string]time.Time
+	order *list.List
+
+	// current and previous are the Bloom filters of the current and previous windows, rotated is when the current
+	// window started and added the number of keys added to it.
+	current  *bloomFilter
+	previous *bloomFilter
+	rotated  time.Time
+	added    int
+}
+
+func newSeenKeys(window DedupWindow) *seenKeys {
+	s := &seenKeys{window: window}
+	if window.BloomKeys > 0 {
+		s.current = newBloomFilter(window.BloomKeys, window.BloomFalsePositives)
+		s.previous = newBloomFilter(window.BloomKeys, window.BloomFalsePositives)
+		s.rotated = time.Now()
+	} else {
+		if window.Window <= 0 && window.MaxKeys <= 0 {
+			s.window.MaxKeys = defaultDedupKeys
+		}
+		s.keys = make(map[string]time.Time)
+		s.order = list.New()
+	}
+	return s
+}
+
+// check reports whether key was seen within the window, and remembers it if not.
+func (s *seenKeys) check(key string, now time.Time) bool {
+	if s.current != nil {
+		elapsed := now.Sub(s.rotated)
+		switch {
+		case s.window.Window > 0 && elapsed >= 2*s.window.Window:
+			// Both windows are over, after a gap without items.
+			s.rotate(now)
+			s.previous.reset()
+		case s.window.Window > 0 && elapsed >= s.window.Window, s.window.Window <= 0 && s.added >= s.window.BloomKeys:
+			s.rotate(now)
+		}
+		if s.current.has(key) || s.previous.has(key) {
+			return true
+		}
+		s.current.add(key)
+		s.added++
+		return false
+	}
+
+	// Forget the keys which have expired, the oldest ones are at the front.
+	for s.window.Window > 0 && s.order.Len() > 0 {
+		oldest := s.order.Front()
+		if now.Sub(s.keys[oldest.Value.(string)]) < s.window.Window {
+			break
+		}
+		delete(s.keys, oldest.Value.(string))
+		s.order.Remove(oldest)
+	}
+	if _, ok := s.keys[key]; ok {
+		return true
+	}
+	s.keys[key] = now
+	s.order.PushBack(key)
+	if s.window.MaxKeys > 0 && s.order.Len() > s.window.MaxKeys {
+		oldest := s.order.Front()
+		delete(s.keys, oldest.Value.(string))
+		s.order.Remove(oldest)
+	}
+	return false
+}
+
+// rotate starts a new window of the Bloom filters, the current filter becomes the previous one.
+func (s *seenKeys) rotate(now time.Time) {
+	s.previous, s.current = s.current, s.previous
+	s.current.reset()
+	s.rotated = now
+	s.added = 0
+}
@@ -0,0 +1,157 @@
+package workpool
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedup(t *testing.T) {
+	release := make(chan struct{})
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		<-release
+		return item, nil
+	})
+	pool.Key = func(item int) string { return strconv.Itoa(item % 10) }
+	var mu sync.Mutex
+	var dropped []int
+	pool.OnDuplicate = func(item int) {
+		mu.Lock()
+		defer mu.Unlock()
+		dropped = append(dropped, item)
+	}
+	pool.Start()
+
+	// 1 is running or queued, 11 and 21 are duplicates of it.
+	require.NoError(t, pool.Submit(1))
+	require.NoError(t, pool.Submit(2))
+	require.NoError(t, pool.Submit(11))
+	assert.True(t, pool.TrySubmit(21))
+	require.NoError(t, pool.SubmitAfter(time.Millisecond, 12))
+
+	close(release)
+	var values []int
+	for i := 0; i < 2; i++ {
+		values = append(values, (<-pool.Results()).Value)
+	}
+	assert.Equal(t, []int{1, 2}, values)
+
+	// Once processed the key may be submitted again.
+	require.NoError(t, pool.Submit(31))
+	assert.Equal(t, 31, (<-pool.Results()).Value)
+	pool.Finish()
+	for range pool.Results() {
+	}
+	require.NoError(t, pool.Wait())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []int{11, 21, 12}, dropped)
+}
+
+func TestDedupClosed(t *testing.T) {
+	pool := NewTypedPool(1, square)
+	pool.Key = func(item int) string { return strconv.Itoa(item) }
+	pool.Start()
+	pool.Finish()
+	require.NoError(t, pool.Wait())
+
+	assert.ErrorIs(t, pool.Submit(1), ErrPoolClosed)
+	assert.Empty(t, pool.keys)
+}
+
+func TestDedupStage(t *testing.T) {
+	p := NewPipeline()
+	events := Source(p, "a", "b", "a", "c", "b", "a")
+	var got []string
+	deduped := Dedup(p, events, func(s string) string { return s }, DedupWindow{Window: time.Hour})
+	Sink(p, 1, deduped, func(abort <-chan struct{}, item string) error {
+		got = append(got, item)
+		return nil
+	})
+
+	assert.NoError(t, p.Run())
+	assert.Equal(t, []string{"a", "b", "c"}, got)
+}
+
+func TestSeenKeysWindow(t *testing.T) {
+	start := time.Unix(1000, 0)
+	s := newSeenKeys(DedupWindow{Window: time.Minute})
+	assert.False(t, s.check("a", start))
+	assert.True(t, s.check("a", start.Add(30*time.Second)))
+	assert.False(t, s.check("b", start.Add(45*time.Second)))
+	// The window starts when a key is first seen, duplicates do not extend it.
+	assert.False(t, s.check("a", start.Add(time.Minute)))
+	assert.True(t, s.check("b", start.Add(time.Minute)))
+	assert.Equal(t, 2, s.order.Len())
+}
+
+func TestSeenKeysMaxKeys(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := newSeenKeys(DedupWindow{MaxKeys: 2})
+	assert.False(t, s.check("a", now))
+	assert.False(t, s.check("b", now))
+	assert.False(t, s.check("c", now))
+	// a was the oldest key, so it was forgotten to make room for c.
+	assert.False(t, s.check("a", now))
+	assert.True(t, s.check("c", now))
+	assert.Len(t, s.keys, 2)
+}
+
+func TestSeenKeysBloom(t *testing.T) {
+	start := time.Unix(1000, 0)
+	s := newSeenKeys(DedupWindow{Window: time.Minute, BloomKeys: 1000})
+	s.rotated = start
+	for i := 0; i < 1000; i++ {
+		s.check(strconv.Itoa(i), start)
+	}
+	for i := 0; i < 1000; i++ {
+		require.True(t, s.check(strconv.Itoa(i), start), "key %d", i)
+	}
+	// Unique keys are looked up without adding them, so that the filter holds the number of keys it was sized for.
+	falsePositives := 0
+	for i := 1000; i < 11000; i++ {
+		if s.current.has(strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+	assert.Less(t, falsePositives, 200)
+
+	// The keys are still remembered during the next window, and forgotten after it.
+	assert.True(t, s.check("1", start.Add(time.Minute)))
+	assert.False(t, s.check("1", start.Add(2*time.Minute)))
+}
+
+func TestSeenKeysBloomGap(t *testing.T) {
+	start := time.Unix(1000, 0)
+	s := newSeenKeys(DedupWindow{Window: time.Minute, BloomKeys: 100})
+	s.rotated = start
+	assert.False(t, s.check("a", start))
+	// Both windows ended during the gap, so a is forgotten.
+	assert.False(t, s.check("a", start.Add(3*time.Minute)))
+	assert.True(t, s.check("a", start.Add(3*time.Minute)))
+}
+
+func TestSeenKeysBloomNoWindow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := newSeenKeys(DedupWindow{BloomKeys: 10})
+	assert.False(t, s.check("a", now))
+	for i := 0; i < 9; i++ {
+		s.check(strconv.Itoa(i), now)
+	}
+	assert.True(t, s.check("a", now), "a is in the previous filter")
+	for i := 10; i < 20; i++ {
+		s.check(strconv.Itoa(i), now)
+	}
+	// The filters were rotated twice, so neither of them fills up.
+	assert.False(t, s.check("a", now))
+}
+
+func TestSeenKeysDefaultMaxKeys(t *testing.T) {
+	s := newSeenKeys(DedupWindow{})
+	assert.Equal(t, defaultDedupKeys, s.window.MaxKeys)
+}
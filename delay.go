@@ -0,0 +1,56 @@
+package workpool
+
+import (
+	"time"
+)
+
+// SubmitAfter adds an item to the pool once d has elapsed. It returns straight away; ErrPoolClosed is returned if
+// Finish or Cancel have already been called. If the pool is cancelled before the item is due it is dropped. Finish
+// waits for delayed items to be queued before letting the pool exit.
+func (p *TypedPool[In, Out]) SubmitAfter(d time.Duration, item In) error {
+	p.init()
+	p.delayMu.Lock()
+	if p.ctx.Err() != nil || p.finishing {
+		p.delayMu.Unlock()
+		return ErrPoolClosed
+	}
+	p.delayed++
+	p.delayMu.Unlock()
+	if !p.claim(item) {
+		p.delayDone()
+		return nil
+	}
+	p.addTotal(1)
+
+	go func() {
+		defer p.delayDone()
+		timer := p.clock().NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C():
+			if p.queue.push(item, 0, p.QueueSize, p.ctx.Done()) {
+				p.wake()
+				return
+			}
+		case <-p.ctx.Done():
+		}
+		// The position is kept, the item was accepted so it holds the checkpoint back like the items left queued.
+		p.release(item)
+	}()
+	return nil
+}
+
+// SubmitAt adds an item to the pool at t. It is like SubmitAfter, a time in the past queues the item right away.
+func (p *TypedPool[In, Out]) SubmitAt(t time.Time, item In) error {
+	return p.SubmitAfter(p.until(t), item)
+}
+
+// delayDone is called once a delayed item has been queued or dropped.
+func (p *TypedPool[In, Out]) delayDone() {
+	p.delayMu.Lock()
+	defer p.delayMu.Unlock()
+	p.delayed--
+	if p.finishing && p.delayed == 0 {
+		p.close()
+	}
+}
@@ -0,0 +1,72 @@
+package workpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmitAfter(t *testing.T) {
+	pool := NewTypedPool(2, square)
+	pool.Start()
+
+	start := time.Now()
+	require.NoError(t, pool.SubmitAfter(30*time.Millisecond, 3))
+	require.NoError(t, pool.SubmitAt(start.Add(10*time.Millisecond), 2))
+	require.NoError(t, pool.Submit(1))
+	// Finish waits for the delayed items.
+	pool.Finish()
+
+	var values []int
+	for result := range pool.Results() {
+		require.NoError(t, result.Err)
+		values = append(values, result.Value)
+	}
+	assert.Equal(t, []int{1, 4, 9}, values)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+	assert.NoError(t, pool.Wait())
+}
+
+func TestSubmitAtPast(t *testing.T) {
+	pool := NewTypedPool(1, square)
+	pool.Start()
+	require.NoError(t, pool.SubmitAt(time.Now().Add(-time.Hour), 5))
+	pool.Finish()
+
+	result := <-pool.Results()
+	assert.Equal(t, 25, result.Value)
+	assert.NoError(t, pool.Wait())
+}
+
+func TestSubmitAfterClosed(t *testing.T) {
+	pool := NewTypedPool(1, square)
+	pool.Start()
+	pool.Finish()
+	assert.ErrorIs(t, pool.SubmitAfter(time.Millisecond, 1), ErrPoolClosed)
+	assert.ErrorIs(t, pool.Submit(1), ErrPoolClosed)
+	assert.NoError(t, pool.Wait())
+}
+
+func TestSubmitAfterCancel(t *testing.T) {
+	pool := NewTypedPool(1, square)
+	pool.Start()
+	require.NoError(t, pool.SubmitAfter(time.Hour, 1))
+	pool.Finish()
+
+	// The pending item does not keep the pool alive once cancelled.
+	done := make(chan struct{})
+	go func() {
+		for range pool.Results() {
+		}
+		close(done)
+	}()
+	pool.Cancel()
+	assert.NoError(t, pool.Wait())
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("results were not closed")
+	}
+}
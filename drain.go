@@ -0,0 +1,24 @@
+package workpool
+
+// Drain stops the pool gracefully: new submissions are rejected with ErrPoolClosed, while the queued items, including
+// delayed ones, and the items being processed are allowed to finish. It blocks until the pool has shut down and
+// returns the same error as Run. Results must still be read until the channel is closed.
+//
+// Unlike Cancel, which aborts work immediately, Drain never closes the abort signal. The pool is started if it was not
+// already, so that queued items are processed.
+func (p *TypedPool[In, Out]) Drain() error {
+	p.Finish()
+	p.Start()
+	return p.Wait()
+}
+
+// Drain stops the pool gracefully: new submissions are rejected with ErrPoolClosed, while queued items are flushed to
+// the handler. It blocks until the pool has shut down and returns the same error as Run.
+//
+// Unlike Cancel, which drops queued items, Drain never closes the abort signal. The pool is started if it was not
+// already.
+func (p *BatchPool[T]) Drain() error {
+	p.Finish()
+	p.Start()
+	return p.Wait()
+}
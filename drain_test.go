@@ -0,0 +1,59 @@
+package workpool
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedPoolDrain(t *testing.T) {
+	release := make(chan struct{})
+	pool := NewTypedPool(2, func(abort <-chan struct{}, item int) (int, error) {
+		select {
+		case <-release:
+		case <-abort:
+			t.Error("drained work was aborted")
+		}
+		return item, nil
+	})
+	for i := 0; i < 5; i++ {
+		require.NoError(t, pool.Submit(i))
+	}
+
+	var values []int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for result := range pool.Results() {
+			values = append(values, result.Value)
+		}
+	}()
+
+	drained := make(chan error)
+	go func() { drained <- pool.Drain() }()
+	assert.Eventually(t, func() bool { return pool.Submit(9) == ErrPoolClosed }, time.Second, time.Millisecond)
+	close(release)
+
+	assert.NoError(t, <-drained)
+	wg.Wait()
+	assert.ElementsMatch(t, []int{0, 1, 2, 3, 4}, values)
+	assert.False(t, pool.Stats().Cancelled)
+}
+
+func TestBatchPoolDrain(t *testing.T) {
+	var batches [][]int
+	pool := NewBatchPool(1, 2, time.Hour, func(abort <-chan struct{}, batch []int) bool {
+		batches = append(batches, batch)
+		return true
+	})
+	for i := 0; i < 3; i++ {
+		require.NoError(t, pool.Submit(i))
+	}
+	assert.NoError(t, pool.Drain())
+	assert.Equal(t, [][]int{{0, 1}, {2}}, batches)
+	assert.ErrorIs(t, pool.Submit(3), ErrPoolClosed)
+}
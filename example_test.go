@@ -1,6 +1,11 @@
 package workpool
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
 
 func ExampleWorkPool_struct() {
 	numWorkers := 2
@@ -77,3 +82,129 @@ func ExampleNewWithClose() {
 	// 1
 	// 1
 }
+
+func ExampleWorkPool_RunContext() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// This worker waits for an abort signal, which is sent when the context expires.
+	worker := func(abort <-chan struct{}) bool {
+		<-abort
+		fmt.Println("aborted")
+		return false
+	}
+
+	pool := New(1, worker)
+	pool.RunContext(ctx)
+	// Output: aborted
+}
+
+func ExampleNewWithError() {
+	worker := func(abort <-chan struct{}) (bool, error) {
+		return false, errors.New("something went wrong")
+	}
+
+	pool := NewWithError(2, worker)
+	if err := pool.Run(); err != nil {
+		fmt.Println(err)
+	}
+	// Output: something went wrong
+}
+
+func ExampleTypedPool() {
+	square := func(abort <-chan struct{}, item int) (int, error) {
+		return item * item, nil
+	}
+
+	pool := NewTypedPool(1, square)
+	pool.Start()
+
+	go func() {
+		for _, i := range []int{2, 3, 10} {
+			pool.Submit(i)
+		}
+		pool.Finish()
+	}()
+
+	for result := range pool.Results() {
+		fmt.Println(result.Value)
+	}
+	// Output: 4
+	// 9
+	// 100
+}
+
+func ExamplePipeline() {
+	square := func(abort <-chan struct{}, item int) (int, error) {
+		return item * item, nil
+	}
+	print := func(abort <-chan struct{}, item int) error {
+		fmt.Println(item)
+		return nil
+	}
+
+	// A single worker per stage keeps the output in order.
+	p := NewPipeline()
+	numbers := Source(p, 2, 3, 10)
+	squares := Stage(p, 1, numbers, square)
+	Sink(p, 1, squares, print)
+
+	if err := p.Run(); err != nil {
+		fmt.Println(err)
+	}
+	// Output: 4
+	// 9
+	// 100
+}
+
+func ExampleWorkPool_Start() {
+	outputs := make(chan int, 3)
+	worker := func(abort <-chan struct{}) bool {
+		outputs <- 1
+		return false
+	}
+
+	pool := New(3, worker)
+	pool.Start()
+
+	// Do something else while the pool runs, then wait for it.
+	pool.Wait()
+	close(outputs)
+
+	sum := 0
+	for out := range outputs {
+		sum += out
+	}
+	fmt.Println(sum)
+	// Output: 3
+}
+
+func ExampleMap() {
+	square := func(ctx context.Context, item int) (int, error) {
+		return item * item, nil
+	}
+
+	results, err := Map(context.Background(), 4, []int{2, 3, 10}, square)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	// Results are in the order they were produced.
+	sum := 0
+	for _, result := range results {
+		sum += result
+	}
+	fmt.Println(sum)
+	// Output: 113
+}
+
+func ExampleChunks() {
+	squares := make([]int, 10)
+	Chunks(3, len(squares), func(start, end int) {
+		for i := start; i < end; i++ {
+			squares[i] = i * i
+		}
+	})
+	fmt.Println(squares)
+	// Output: [0 1 4 9 16 25 36 49 64 81]
+}
@@ -0,0 +1,53 @@
+package workpool
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+)
+
+// expvarMu makes checking and publishing a name atomic, expvar.Publish panics when a name is taken.
+var expvarMu sync.Mutex
+
+// PublishExpvar publishes the statistics of the pool with the expvar package, so they appear under name in
+// /debug/vars. The value is a map of the Stats and Progress counters along with the errors recorded and whether the
+// pool is paused, computed afresh whenever it is read. The queue counters are only included for pools which queue
+// their work. An error is returned if the name is already taken, as each expvar name can only be published once.
+func (p *WorkPool) PublishExpvar(name string) error {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("workpool: expvar %s is already published", name)
+	}
+	expvar.Publish(name, expvar.Func(p.expvars))
+	return nil
+}
+
+// expvars returns the values published by PublishExpvar.
+func (p *WorkPool) expvars() any {
+	stats := p.Stats()
+	progress := p.Progress()
+	vars := map[string]any{
+		"workers":          stats.ActiveWorkers,
+		"retiring":         stats.RetiringWorkers,
+		"invocations":      stats.Invocations,
+		"finished":         stats.Finished,
+		"timeouts":         stats.Timeouts,
+		"errors":           p.ErrorCount(),
+		"done":             progress.Done,
+		"total":            progress.Total,
+		"throughput":       progress.Throughput,
+		"duration_seconds": stats.Duration.Seconds(),
+		"paused":           p.Paused(),
+		"cancelled":        stats.Cancelled,
+		"running":          stats.Running,
+	}
+	if p.queueStats != nil {
+		vars["queued"] = stats.Queued
+		vars["max_queued"] = stats.MaxQueued
+		vars["enqueued"] = stats.Enqueued
+		vars["dequeued"] = stats.Dequeued
+		vars["submit_blocked_seconds"] = stats.SubmitBlocked.Seconds()
+	}
+	return vars
+}
@@ -0,0 +1,42 @@
+package workpool
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	var calls int
+	pool := NewWithError(1, func(abort <-chan struct{}) (bool, error) {
+		calls++
+		if calls == 2 {
+			return true, assert.AnError
+		}
+		return calls < 3, nil
+	})
+	require.NoError(t, pool.PublishExpvar("workpool_test_pool"))
+	assert.EqualError(t, pool.PublishExpvar("workpool_test_pool"), "workpool: expvar workpool_test_pool is already published")
+	assert.Error(t, pool.Run())
+
+	var vars map[string]any
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("workpool_test_pool").String()), &vars))
+	assert.Equal(t, map[string]any{
+		"workers":          0.0,
+		"retiring":         0.0,
+		"invocations":      3.0,
+		"finished":         1.0,
+		"timeouts":         0.0,
+		"errors":           1.0,
+		"done":             2.0,
+		"total":            0.0,
+		"throughput":       0.0,
+		"duration_seconds": vars["duration_seconds"],
+		"paused":           false,
+		"cancelled":        false,
+		"running":          false,
+	}, vars)
+}
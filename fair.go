@@ -0,0 +1,62 @@
+package workpool
+
+// SubmitTenant is like Submit, but the item belongs to tenant. Workers share out the queued items of the same
+// priority between the tenants in proportion to their TenantWeights, so a tenant submitting many items at once
+// cannot hold up the others. With PriorityAging the share still applies between items of the same priority, and the
+// priorities are taken in the order of the aged priority of the item the share picks next from each of them. Items
+// submitted without a tenant belong to the tenant "".
+func (p *TypedPool[In, Out]) SubmitTenant(tenant string, item In) error {
+	p.init()
+	if p.ctx.Err() != nil || p.isFinishing() {
+		return ErrPoolClosed
+	}
+	if !p.claim(item) {
+		return nil
+	}
+	if !p.queue.pushEntry(entry[In]{item: item, tenant: tenant}, p.QueueSize, p.ctx.Done()) {
+		p.unclaim(item)
+		return ErrPoolClosed
+	}
+	p.addTotal(1)
+	p.wake()
+	return nil
+}
+
+// fairShare orders the items of a queue between tenants with weighted fair queueing. Each item is tagged with the
+// virtual time at which it would finish if every tenant with queued items was served at the rate of its weight, and
+// items are taken in the order of their tags.
+type fairShare struct {
+	// weight returns the weight of a tenant, which is more than zero.
+	weight func(tenant string) float64
+
+	// virtual is the tag of the last item taken, and finish the tag of the last item queued by each tenant with
+	// queued items.
+	virtual float64
+	finish  map[string]float64
+}
+
+func newFairShare(weight func(tenant string) float64) *fairShare {
+	return &fairShare{weight: weight, finish: make(map[string]float64)}
+}
+
+// tag returns the tag of an item queued by tenant.
+func (f *fairShare) tag(tenant string) float64 {
+	start := f.virtual
+	if last, ok := f.finish[tenant]; ok && last > start {
+		start = last
+	}
+	tag := start + 1/f.weight(tenant)
+	f.finish[tenant] = tag
+	return tag
+}
+
+// take moves the virtual time on to an item being taken from the queue.
+func (f *fairShare) take(tenant string, tag float64) {
+	if tag > f.virtual {
+		f.virtual = tag
+	}
+	if f.finish[tenant] <= tag {
+		// The tenant has no more queued items.
+		delete(f.finish, tenant)
+	}
+}
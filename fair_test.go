@@ -0,0 +1,134 @@
+package workpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmitTenant(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		weights map[string]int
+		order   []string
+	}{
+		{
+			name:  "equal weights",
+			order: []string{"noisy", "quiet", "noisy", "quiet", "noisy", "noisy", "noisy", "noisy"},
+		},
+		{
+			name:    "weighted",
+			weights: map[string]int{"quiet": 2},
+			order:   []string{"quiet", "noisy", "quiet", "noisy", "noisy", "noisy", "noisy", "noisy"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pool := NewTypedPool(1, func(abort <-chan struct{}, tenant string) (string, error) {
+				return tenant, nil
+			})
+			pool.TenantWeights = tc.weights
+
+			// Queue everything before starting so that the order only depends on the fair share.
+			for i := 0; i < 6; i++ {
+				require.NoError(t, pool.SubmitTenant("noisy", "noisy"))
+			}
+			for i := 0; i < 2; i++ {
+				require.NoError(t, pool.SubmitTenant("quiet", "quiet"))
+			}
+			pool.Finish()
+			pool.Start()
+
+			var order []string
+			for result := range pool.Results() {
+				order = append(order, result.Value)
+			}
+			assert.Equal(t, tc.order, order)
+		})
+	}
+}
+
+func TestSubmitTenantPriorityAging(t *testing.T) {
+	pool := NewTypedPool(1, func(abort <-chan struct{}, tenant string) (string, error) {
+		return tenant, nil
+	})
+	clock := &manualClock{now: time.Unix(1000, 0)}
+	pool.Clock = clock
+	pool.PriorityAging = time.Second
+
+	// Every item gets its own aged rank, but the fair share still applies between items of the same priority.
+	for i := 0; i < 4; i++ {
+		require.NoError(t, pool.SubmitTenant("noisy", "noisy"))
+		clock.now = clock.now.Add(time.Millisecond)
+	}
+	require.NoError(t, pool.SubmitTenant("quiet", "quiet"))
+	clock.now = clock.now.Add(2 * time.Second)
+	// An item with a higher priority is still overtaken by the items which have waited long enough.
+	require.NoError(t, pool.SubmitWithPriority("urgent", 1))
+	pool.Finish()
+	pool.Start()
+
+	var order []string
+	for result := range pool.Results() {
+		order = append(order, result.Value)
+	}
+	assert.Equal(t, []string{"noisy", "quiet", "noisy", "noisy", "noisy", "urgent"}, order)
+}
+
+func TestSubmitTenantPriorityAgingOrder(t *testing.T) {
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item string) (string, error) {
+		return item, nil
+	})
+	clock := &manualClock{now: time.Unix(1000, 0)}
+	pool.Clock = clock
+	pool.PriorityAging = time.Second
+
+	require.NoError(t, pool.SubmitTenant("a", "a1"))
+	require.NoError(t, pool.SubmitTenant("a", "a2"))
+	clock.now = clock.now.Add(1500 * time.Millisecond)
+	require.NoError(t, pool.SubmitWithPriority("urgent", 1))
+	clock.now = clock.now.Add(100 * time.Millisecond)
+	require.NoError(t, pool.SubmitTenant("b", "b1"))
+	pool.Finish()
+	pool.Start()
+
+	var order []string
+	for result := range pool.Results() {
+		order = append(order, result.Value)
+	}
+	// The share puts b1 before a2 within priority 0, and urgent has waited longer than b1.
+	assert.Equal(t, []string{"a1", "urgent", "b1", "a2"}, order)
+}
+
+func TestSubmitTenantPriority(t *testing.T) {
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	})
+	for i := 0; i < 3; i++ {
+		require.NoError(t, pool.SubmitTenant("a", i))
+	}
+	// Priorities apply before the fair share, and items without a tenant belong to "".
+	require.NoError(t, pool.SubmitWithPriority(10, 1))
+	require.NoError(t, pool.Submit(11))
+	pool.Finish()
+	pool.Start()
+
+	var order []int
+	for result := range pool.Results() {
+		order = append(order, result.Value)
+	}
+	assert.Equal(t, []int{10, 0, 1, 11, 2}, order, "item 10 used up the first share of \"\"")
+}
+
+func TestFairShareForgetsTenants(t *testing.T) {
+	f := newFairShare(func(string) float64 { return 1 })
+	a1, a2 := f.tag("a"), f.tag("a")
+	b1 := f.tag("b")
+	f.take("a", a1)
+	f.take("b", b1)
+	assert.Equal(t, map[string]float64{"a": a2}, f.finish)
+	f.take("a", a2)
+	assert.Empty(t, f.finish)
+	assert.Equal(t, a2+1, f.tag("c"), "a new tenant starts at the virtual time")
+}
@@ -0,0 +1,78 @@
+package workpool
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// ForEach calls fn for every item using numWorkers workers. The first error returned by fn cancels the context given to
+// the other calls, stops the remaining items from being processed, and is returned. If ctx is done before all items are
+// processed its error is returned.
+func ForEach[T any](ctx context.Context, numWorkers int, items []T, fn func(ctx context.Context, item T) error) error {
+	_, err := Map(ctx, numWorkers, items, func(ctx context.Context, item T) (struct{}, error) {
+		return struct{}{}, fn(ctx, item)
+	})
+	return err
+}
+
+// Map calls fn for every item using numWorkers workers, and returns the results in the order they were produced. Errors
+// are handled like ForEach, the results produced before an error are returned along with it.
+func Map[T, R any](ctx context.Context, numWorkers int, items []T, fn func(ctx context.Context, item T) (R, error)) ([]R, error) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	results := make([]R, 0, len(items))
+	next := int64(-1)
+	pool := NewWithError(numWorkers, func(abort <-chan struct{}) (bool, error) {
+		i := atomic.AddInt64(&next, 1)
+		if i >= int64(len(items)) {
+			return false, nil
+		}
+		result, err := fn(runCtx, items[i])
+		if err != nil {
+			cancel()
+			return false, err
+		}
+		mu.Lock()
+		results = append(results, result)
+		mu.Unlock()
+		return true, nil
+	})
+
+	if err := pool.RunContext(runCtx); err != nil {
+		return results, err
+	}
+	return results, ctx.Err()
+}
+
+// MapOrdered is like Map, but the results are returned in the order of the items: the result of items[i] is at index
+// i, whichever worker finished first. When an error is returned the results of the items which were not processed are
+// left as the zero value.
+func MapOrdered[T, R any](ctx context.Context, numWorkers int, items []T, fn func(ctx context.Context, item T) (R, error)) ([]R, error) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]R, len(items))
+	next := int64(-1)
+	pool := NewWithError(numWorkers, func(abort <-chan struct{}) (bool, error) {
+		i := atomic.AddInt64(&next, 1)
+		if i >= int64(len(items)) {
+			return false, nil
+		}
+		result, err := fn(runCtx, items[i])
+		if err != nil {
+			cancel()
+			return false, err
+		}
+		// Each index is written by exactly one worker, so no lock is needed.
+		results[i] = result
+		return true, nil
+	})
+
+	if err := pool.RunContext(runCtx); err != nil {
+		return results, err
+	}
+	return results, ctx.Err()
+}
@@ -0,0 +1,95 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForEach(t *testing.T) {
+	var sum int64
+	err := ForEach(context.Background(), 3, []int64{1, 2, 3, 4}, func(ctx context.Context, item int64) error {
+		atomic.AddInt64(&sum, item)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), sum)
+}
+
+func TestForEachError(t *testing.T) {
+	items := make([]int, 1000)
+	for i := range items {
+		items[i] = i
+	}
+
+	var calls int64
+	err := ForEach(context.Background(), 2, items, func(ctx context.Context, item int) error {
+		atomic.AddInt64(&calls, 1)
+		if item == 10 {
+			return errors.New("ten")
+		}
+		return ctx.Err()
+	})
+	assert.EqualError(t, err, "ten")
+	assert.Less(t, atomic.LoadInt64(&calls), int64(len(items)))
+}
+
+func TestForEachContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := ForEach(ctx, 2, []int{1, 2, 3}, func(ctx context.Context, item int) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestMap(t *testing.T) {
+	results, err := Map(context.Background(), 4, []int{1, 2, 3, 4, 5}, func(ctx context.Context, item int) (string, error) {
+		return string(rune('a' + item - 1)), nil
+	})
+	assert.NoError(t, err)
+	sort.Strings(results)
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, results)
+}
+
+func TestMapEmpty(t *testing.T) {
+	results, err := Map(context.Background(), 4, nil, func(ctx context.Context, item int) (int, error) {
+		return item, nil
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestMapOrdered(t *testing.T) {
+	items := make([]int, 100)
+	for i := range items {
+		items[i] = i
+	}
+	results, err := MapOrdered(context.Background(), 4, items, func(ctx context.Context, item int) (string, error) {
+		// Later items finish first.
+		time.Sleep(time.Duration(len(items)-item) * 10 * time.Microsecond)
+		return strconv.Itoa(item), nil
+	})
+	assert.NoError(t, err)
+	for i, result := range results {
+		assert.Equal(t, strconv.Itoa(i), result)
+	}
+}
+
+func TestMapOrderedError(t *testing.T) {
+	results, err := MapOrdered(context.Background(), 1, []int{1, 2, 3}, func(ctx context.Context, item int) (int, error) {
+		if item == 2 {
+			return 0, errors.New("two")
+		}
+		return item * 10, nil
+	})
+	assert.EqualError(t, err, "two")
+	assert.Equal(t, []int{10, 0, 0}, results)
+}
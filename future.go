@@ -0,0 +1,125 @@
+package workpool
+
+import (
+	"context"
+)
+
+// Executor runs functions submitted with SubmitFunc on a pool of workers, each call is independent of the others.
+// The embedded WorkPool may be configured before calling Start, but its handler and Close fields are managed by the
+// Executor.
+type Executor struct {
+	*WorkPool
+
+	// QueueSize limits the number of functions waiting for a worker, SubmitFunc blocks while the queue is full. Zero
+	// means that the queue is unbounded.
+	QueueSize int
+
+	queue *queue[task]
+}
+
+// task is a function queued on an Executor. fail completes its future when it cannot be run.
+type task struct {
+	run  func(ctx context.Context)
+	fail func(err error)
+}
+
+// NewExecutor creates an Executor with numWorkers workers.
+func NewExecutor(numWorkers int) *Executor {
+	e := &Executor{queue: newQueue[task]()}
+	e.WorkPool = &WorkPool{
+		Workers: numWorkers,
+		ContextHandler: func(ctx context.Context) bool {
+			t, ok := e.queue.pop(ctx.Done())
+			if !ok {
+				return false
+			}
+			t.run(ctx)
+			return true
+		},
+		Close: e.drop,
+	}
+	trackQueue(e.WorkPool, e.queue)
+	return e
+}
+
+// Finish signals that no more functions will be submitted. The executor exits once the queued functions have run.
+func (e *Executor) Finish() {
+	e.queue.close()
+}
+
+// drop fails the functions left in the queue once the workers have exited.
+func (e *Executor) drop() {
+	e.queue.close()
+	cause := e.AbortCause()
+	if cause == nil {
+		cause = ErrPoolClosed
+	}
+	closed := make(chan struct{})
+	close(closed)
+	for {
+		t, ok := e.queue.pop(closed)
+		if !ok {
+			return
+		}
+		t.fail(cause)
+	}
+}
+
+// Future is the result of a function submitted with SubmitFunc, which becomes available once the function returns.
+type Future[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// SubmitFunc queues fn on the executor and returns its future. The context given to fn is cancelled when the executor
+// is cancelled, or TaskTimeout expires. If the executor is closed, or cancelled before fn runs, the future fails with
+// ErrPoolClosed or the cause of the cancellation.
+func SubmitFunc[T any](e *Executor, fn func(ctx context.Context) (T, error)) *Future[T] {
+	f := &Future[T]{done: make(chan struct{})}
+	t := task{
+		run: func(ctx context.Context) {
+			// A panic recovered by the pool fails the future instead of leaving it pending.
+			defer func() {
+				if r := recover(); r != nil {
+					f.err = ErrPanicked
+					close(f.done)
+					panic(r)
+				}
+			}()
+			f.value, f.err = fn(ctx)
+			close(f.done)
+		},
+		fail: func(err error) {
+			f.err = err
+			close(f.done)
+		},
+	}
+	if !e.submit(t) {
+		t.fail(ErrPoolClosed)
+	}
+	return f
+}
+
+// submit queues a task. False is returned if the executor is closed.
+func (e *Executor) submit(t task) bool {
+	e.init()
+	return e.ctx.Err() == nil && e.queue.push(t, 0, e.QueueSize, e.ctx.Done())
+}
+
+// Get blocks until the function has returned and returns its result. If ctx is done first its error is returned, the
+// function keeps running.
+func (f *Future[T]) Get(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Done returns a channel which is closed once the result is available.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
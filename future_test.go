@@ -0,0 +1,93 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubmitFunc(t *testing.T) {
+	e := NewExecutor(3)
+	e.Start()
+
+	futures := make([]*Future[int], 10)
+	for i := range futures {
+		i := i
+		futures[i] = SubmitFunc(e, func(ctx context.Context) (int, error) {
+			return i * i, nil
+		})
+	}
+	failed := SubmitFunc(e, func(ctx context.Context) (string, error) {
+		return "", errors.New("unreachable")
+	})
+
+	for i, f := range futures {
+		value, err := f.Get(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, i*i, value)
+	}
+	_, err := failed.Get(context.Background())
+	assert.EqualError(t, err, "unreachable")
+
+	e.Finish()
+	assert.NoError(t, e.Wait(), "errors belong to the futures, not the executor")
+	_, err = SubmitFunc(e, func(ctx context.Context) (int, error) { return 1, nil }).Get(context.Background())
+	assert.ErrorIs(t, err, ErrPoolClosed)
+}
+
+func TestFutureGetContext(t *testing.T) {
+	e := NewExecutor(1)
+	e.Start()
+	defer e.Cancel()
+
+	release := make(chan struct{})
+	f := SubmitFunc(e, func(ctx context.Context) (int, error) {
+		<-release
+		return 1, nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err := f.Get(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	<-f.Done()
+	value, err := f.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, value)
+}
+
+func TestExecutorCancelFailsQueued(t *testing.T) {
+	e := NewExecutor(1)
+	e.Start()
+	started := make(chan struct{})
+	running := SubmitFunc(e, func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	<-started
+	queued := SubmitFunc(e, func(ctx context.Context) (int, error) { return 1, nil })
+
+	cause := errors.New("shutting down")
+	e.CancelWithCause(cause)
+	_, err := running.Get(context.Background())
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = queued.Get(context.Background())
+	assert.Equal(t, cause, err)
+	assert.NoError(t, e.Wait())
+}
+
+func TestFuturePanic(t *testing.T) {
+	e := NewExecutor(1)
+	e.RecoverPanics = true
+	e.Start()
+	f := SubmitFunc(e, func(ctx context.Context) (int, error) { panic("boom") })
+	_, err := f.Get(context.Background())
+	assert.ErrorIs(t, err, ErrPanicked)
+	e.Finish()
+	assert.NoError(t, e.Wait())
+}
@@ -0,0 +1,213 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrCycle is returned by Graph.Run when the dependencies of the tasks form a cycle.
+var ErrCycle = errors.New("workpool: task dependencies form a cycle")
+
+// ErrSkipped is the error recorded for a task which was not run because a task it depends on failed.
+var ErrSkipped = errors.New("workpool: dependency failed")
+
+// FailurePolicy decides what a Graph does when a task fails.
+type FailurePolicy int
+
+const (
+	// FailFast cancels the tasks which are running, runs no more, and returns the error straight away.
+	FailFast FailurePolicy = iota
+
+	// ContinueOnError skips the tasks which depend on a failed task, runs every other task, and returns all the
+	// errors once they are done.
+	ContinueOnError
+)
+
+// TaskError is returned by Graph.Run for a task which failed or was skipped.
+type TaskError struct {
+	// Task is the name of the task.
+	Task string
+
+	// Err is the error returned by the task, or ErrSkipped.
+	Err error
+}
+
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("task %s: %v", e.Task, e.Err)
+}
+
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// Graph is a set of tasks with dependencies between them. Run executes every task once all of its dependencies have
+// succeeded, running as many tasks in parallel as the dependencies and the number of workers allow.
+type Graph struct {
+	// Policy decides what happens when a task fails, the default is FailFast.
+	Policy FailurePolicy
+
+	tasks []*graphTask
+}
+
+// graphTask is a task added to a Graph.
+type graphTask struct {
+	name string
+	fn   func(ctx context.Context) error
+	deps []string
+}
+
+// NewGraph creates an empty Graph.
+func NewGraph() *Graph {
+	return &Graph{}
+}
+
+// Add adds a task which runs fn after the tasks named in deps have succeeded. Tasks may be added in any order, the
+// dependencies are checked by Run.
+func (g *Graph) Add(name string, fn func(ctx context.Context) error, deps ...string) {
+	g.tasks = append(g.tasks, &graphTask{name: name, fn: fn, deps: deps})
+}
+
+// graphRun is the state of a single Graph.Run.
+type graphRun struct {
+	policy FailurePolicy
+	ready  *queue[*graphTask]
+
+	// mu guards the bookkeeping below.
+	mu         sync.Mutex
+	waiting    map[*graphTask]int
+	dependents map[*graphTask][]*graphTask
+	remaining  int
+	errs       map[*graphTask]error
+}
+
+// Run executes the tasks with numWorkers workers and blocks until they are done. With FailFast the first failure is
+// returned as a *TaskError, with ContinueOnError every failed and skipped task is returned, joined in the order the
+// tasks were added. If ctx is done first its error is returned. An error is also returned, before any task runs, if
+// a task depends on one which does not exist, a name is used twice, or the dependencies form a cycle.
+func (g *Graph) Run(ctx context.Context, numWorkers int) error {
+	r, err := g.plan()
+	if err != nil {
+		return err
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pool := NewWithError(numWorkers, func(abort <-chan struct{}) (bool, error) {
+		task, ok := r.ready.pop(abort)
+		if !ok {
+			return false, nil
+		}
+		err := task.fn(runCtx)
+		r.complete(task, err)
+		if err != nil && r.policy == FailFast {
+			cancel()
+			return false, &TaskError{Task: task.name, Err: err}
+		}
+		return true, nil
+	})
+	if err := pool.RunContext(runCtx); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, task := range g.tasks {
+		if err := r.errs[task]; err != nil {
+			errs = append(errs, &TaskError{Task: task.name, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// plan checks the tasks and queues the ones without dependencies.
+func (g *Graph) plan() (*graphRun, error) {
+	r := &graphRun{
+		policy:     g.Policy,
+		ready:      newQueue[*graphTask](),
+		waiting:    make(map[*graphTask]int),
+		dependents: make(map[*graphTask][]*graphTask),
+		remaining:  len(g.tasks),
+		errs:       make(map[*graphTask]error),
+	}
+	byName := make(map[string]*graphTask, len(g.tasks))
+	for _, task := range g.tasks {
+		if _, ok := byName[task.name]; ok {
+			return nil, fmt.Errorf("workpool: task %s added twice", task.name)
+		}
+		byName[task.name] = task
+	}
+	for _, task := range g.tasks {
+		for _, name := range task.deps {
+			dep, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("workpool: task %s depends on unknown task %s", task.name, name)
+			}
+			r.waiting[task]++
+			r.dependents[dep] = append(r.dependents[dep], task)
+		}
+	}
+
+	// Kahn's algorithm, every task is reached unless there is a cycle.
+	waiting := make(map[*graphTask]int, len(r.waiting))
+	var reachable []*graphTask
+	for _, task := range g.tasks {
+		waiting[task] = r.waiting[task]
+		if waiting[task] == 0 {
+			reachable = append(reachable, task)
+		}
+	}
+	for i := 0; i < len(reachable); i++ {
+		for _, next := range r.dependents[reachable[i]] {
+			if waiting[next]--; waiting[next] == 0 {
+				reachable = append(reachable, next)
+			}
+		}
+	}
+	if len(reachable) < len(g.tasks) {
+		return nil, ErrCycle
+	}
+
+	for _, task := range g.tasks {
+		if r.waiting[task] == 0 {
+			r.ready.push(task, 0, 0, nil)
+		}
+	}
+	if r.remaining == 0 {
+		r.ready.close()
+	}
+	return r, nil
+}
+
+// complete records the result of a task, queues the tasks which were only waiting for it, or skips them if it failed.
+func (r *graphRun) complete(task *graphTask, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finish(task, err)
+	if r.remaining == 0 {
+		r.ready.close()
+	}
+}
+
+// finish marks a task as done. The caller must hold r.mu.
+func (r *graphRun) finish(task *graphTask, err error) {
+	r.remaining--
+	if err != nil {
+		r.errs[task] = err
+	}
+	for _, next := range r.dependents[task] {
+		if _, skipped := r.errs[next]; skipped {
+			continue
+		}
+		if err != nil {
+			r.finish(next, ErrSkipped)
+			continue
+		}
+		if r.waiting[next]--; r.waiting[next] == 0 {
+			r.ready.push(next, 0, 0, nil)
+		}
+	}
+}
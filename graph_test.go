@@ -0,0 +1,130 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder records the order tasks ran in.
+type recorder struct {
+	mu    sync.Mutex
+	order []string
+}
+
+func (r *recorder) task(name string, err error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.order = append(r.order, name)
+		return err
+	}
+}
+
+func (r *recorder) index(name string) int {
+	for i, ran := range r.order {
+		if ran == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestGraph(t *testing.T) {
+	var r recorder
+	g := NewGraph()
+	g.Add("link", r.task("link", nil), "compile-a", "compile-b")
+	g.Add("compile-a", r.task("compile-a", nil), "generate")
+	g.Add("compile-b", r.task("compile-b", nil))
+	g.Add("generate", r.task("generate", nil))
+	g.Add("test", r.task("test", nil), "link")
+
+	require.NoError(t, g.Run(context.Background(), 3))
+	assert.Len(t, r.order, 5)
+	assert.Less(t, r.index("generate"), r.index("compile-a"))
+	assert.Less(t, r.index("compile-a"), r.index("link"))
+	assert.Less(t, r.index("compile-b"), r.index("link"))
+	assert.Less(t, r.index("link"), r.index("test"))
+}
+
+func TestGraphParallel(t *testing.T) {
+	// Independent tasks run at the same time: each waits until all of them have started.
+	var started sync.WaitGroup
+	started.Add(3)
+	g := NewGraph()
+	for _, name := range []string{"a", "b", "c"} {
+		g.Add(name, func(ctx context.Context) error {
+			started.Done()
+			started.Wait()
+			return nil
+		})
+	}
+	assert.NoError(t, g.Run(context.Background(), 3))
+}
+
+func TestGraphFailFast(t *testing.T) {
+	var r recorder
+	g := NewGraph()
+	g.Add("fetch", r.task("fetch", errors.New("offline")))
+	g.Add("parse", r.task("parse", nil), "fetch")
+
+	err := g.Run(context.Background(), 2)
+	var taskErr *TaskError
+	require.ErrorAs(t, err, &taskErr)
+	assert.Equal(t, "fetch", taskErr.Task)
+	assert.EqualError(t, err, "task fetch: offline")
+	assert.Equal(t, []string{"fetch"}, r.order)
+}
+
+func TestGraphContinueOnError(t *testing.T) {
+	var r recorder
+	g := NewGraph()
+	g.Policy = ContinueOnError
+	g.Add("a", r.task("a", errors.New("broken")))
+	g.Add("b", r.task("b", nil), "a")
+	g.Add("c", r.task("c", nil), "b")
+	g.Add("d", r.task("d", nil))
+	g.Add("e", r.task("e", nil), "d")
+
+	err := g.Run(context.Background(), 2)
+	assert.EqualError(t, err, "task a: broken\ntask b: workpool: dependency failed\ntask c: workpool: dependency failed")
+	assert.ErrorIs(t, err, ErrSkipped)
+	assert.ElementsMatch(t, []string{"a", "d", "e"}, r.order)
+}
+
+func TestGraphInvalid(t *testing.T) {
+	noop := func(ctx context.Context) error { return nil }
+
+	g := NewGraph()
+	g.Add("a", noop, "b")
+	g.Add("b", noop, "c")
+	g.Add("c", noop, "a")
+	g.Add("d", noop)
+	assert.ErrorIs(t, g.Run(context.Background(), 1), ErrCycle)
+
+	g = NewGraph()
+	g.Add("a", noop, "missing")
+	assert.EqualError(t, g.Run(context.Background(), 1), "workpool: task a depends on unknown task missing")
+
+	g = NewGraph()
+	g.Add("a", noop)
+	g.Add("a", noop)
+	assert.EqualError(t, g.Run(context.Background(), 1), "workpool: task a added twice")
+
+	assert.NoError(t, NewGraph().Run(context.Background(), 1))
+}
+
+func TestGraphCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g := NewGraph()
+	g.Add("a", func(ctx context.Context) error {
+		cancel()
+		return nil
+	})
+	g.Add("b", func(ctx context.Context) error { return nil }, "a")
+	assert.ErrorIs(t, g.Run(ctx, 1), context.Canceled)
+}
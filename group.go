@@ -0,0 +1,79 @@
+package workpool
+
+import (
+	"context"
+	"sync"
+)
+
+// GoGroup is implemented by errgroup.Group, and other groups which run functions until the first error.
+type GoGroup interface {
+	Go(fn func() error)
+}
+
+// RunIn runs the pool as a member of g, so that an error from the pool fails the group. ctx should be the group's
+// context, as returned by errgroup.WithContext, so that the pool is cancelled when another member fails.
+func (p *WorkPool) RunIn(ctx context.Context, g GoGroup) {
+	g.Go(func() error {
+		return p.RunContext(ctx)
+	})
+}
+
+// Group runs functions on a pool of workers with an errgroup style API. The first error cancels the group's context
+// and is returned by Wait.
+//
+// Unlike errgroup.Group, Go waits for a free worker, and once the group has been cancelled further functions are
+// discarded instead of run.
+type Group struct {
+	pool   *WorkPool
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	funcs  chan func() error
+	done   chan error
+	once   sync.Once
+	err    error
+}
+
+// NewGroup creates a Group with numWorkers workers, and a context derived from ctx which is cancelled when a function
+// fails or Wait returns.
+func NewGroup(ctx context.Context, numWorkers int) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	g := &Group{
+		ctx:    ctx,
+		cancel: cancel,
+		funcs:  make(chan func() error),
+		done:   make(chan error, 1),
+	}
+	g.pool = NewWithError(numWorkers, func(abort <-chan struct{}) (bool, error) {
+		fn, ok := receive(abort, g.funcs)
+		if !ok {
+			return false, nil
+		}
+		if err := fn(); err != nil {
+			cancel(err)
+			return false, err
+		}
+		return true, nil
+	})
+	go func() {
+		g.done <- g.pool.RunContext(ctx)
+	}()
+	return g, ctx
+}
+
+// Go runs fn on the next free worker, blocking until one is available. It must not be called after Wait.
+func (g *Group) Go(fn func() error) {
+	select {
+	case g.funcs <- fn:
+	case <-g.ctx.Done():
+	}
+}
+
+// Wait waits for the functions passed to Go to return, and returns the first error.
+func (g *Group) Wait() error {
+	g.once.Do(func() {
+		close(g.funcs)
+		g.err = <-g.done
+		g.cancel(g.err)
+	})
+	return g.err
+}
@@ -0,0 +1,103 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testGroup is a minimal errgroup.Group.
+type testGroup struct {
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+	cancel context.CancelFunc
+}
+
+func newTestGroup(ctx context.Context) (*testGroup, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &testGroup{cancel: cancel}, ctx
+}
+
+func (g *testGroup) Go(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := fn(); err != nil {
+			g.once.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}()
+}
+
+func (g *testGroup) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+func TestRunInCancelledByGroup(t *testing.T) {
+	g, ctx := newTestGroup(context.Background())
+	pool := New(2, func(abort <-chan struct{}) bool {
+		<-abort
+		return false
+	})
+	pool.RunIn(ctx, g)
+	g.Go(func() error { return errors.New("other member") })
+
+	assert.EqualError(t, g.Wait(), "other member")
+	assert.True(t, pool.Cancelled())
+}
+
+func TestRunInFailsGroup(t *testing.T) {
+	g, ctx := newTestGroup(context.Background())
+	pool := NewWithError(1, func(abort <-chan struct{}) (bool, error) {
+		return false, errors.New("pool failed")
+	})
+	pool.RunIn(ctx, g)
+	g.Go(func() error {
+		<-ctx.Done()
+		return nil
+	})
+	assert.EqualError(t, g.Wait(), "pool failed")
+}
+
+func TestGroup(t *testing.T) {
+	g, ctx := NewGroup(context.Background(), 3)
+	var sum int64
+	for i := 1; i <= 100; i++ {
+		i := i
+		g.Go(func() error {
+			atomic.AddInt64(&sum, int64(i))
+			return nil
+		})
+	}
+	assert.NoError(t, g.Wait())
+	assert.Equal(t, int64(5050), sum)
+	assert.Error(t, ctx.Err(), "the context is cancelled once Wait returns")
+	assert.NoError(t, g.Wait())
+}
+
+func TestGroupError(t *testing.T) {
+	g, ctx := NewGroup(context.Background(), 2)
+	var calls int64
+	for i := 0; i < 1000; i++ {
+		i := i
+		g.Go(func() error {
+			atomic.AddInt64(&calls, 1)
+			if i == 10 {
+				return errors.New("ten")
+			}
+			return nil
+		})
+	}
+	assert.EqualError(t, g.Wait(), "ten")
+	assert.EqualError(t, context.Cause(ctx), "ten")
+	assert.Less(t, atomic.LoadInt64(&calls), int64(1000))
+}
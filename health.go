@@ -0,0 +1,122 @@
+package workpool
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// ErrUnhealthy is returned by Healthy when a worker is stuck.
+var ErrUnhealthy = errors.New("workpool: unhealthy")
+
+// HealthReport describes whether the workers of a pool are making progress, see WorkPool.HealthReport.
+type HealthReport struct {
+	// Running is true while the pool is running.
+	Running bool
+
+	// Workers is the number of running workers.
+	Workers int
+
+	// Stuck lists the workers whose current handler call has been running for longer than HealthInterval.
+	Stuck []StuckWorker
+
+	// LastReturn is when a handler call last returned, or the zero time if none has.
+	LastReturn time.Time
+}
+
+// StuckWorker is a worker in a HealthReport whose handler call is taking too long.
+type StuckWorker struct {
+	// ID is the worker ID.
+	ID int
+
+	// Running is how long the current handler call has been running.
+	Running time.Duration
+}
+
+// WorkerStatus is the state of a single worker, see WorkPool.WorkerStatuses.
+type WorkerStatus struct {
+	// ID is the worker ID.
+	ID int
+
+	// Busy is true while the worker is in a handler call, and Running is how long the call has been running.
+	Busy    bool
+	Running time.Duration
+
+	// Retiring is true once the worker has been asked to stop and is finishing its current handler call.
+	Retiring bool
+}
+
+// WorkerStatuses returns the state of every running worker, including retiring ones, ordered by ID.
+func (p *WorkPool) WorkerStatuses() []WorkerStatus {
+	now := p.clock().Now()
+	p.mu.Lock()
+	statuses := make([]WorkerStatus, 0, len(p.workers)+len(p.retiring))
+	add := func(w *worker, retiring bool) {
+		status := WorkerStatus{ID: w.id, Retiring: retiring}
+		if started := w.calling.Load(); started != 0 {
+			status.Busy = true
+			status.Running = now.Sub(time.Unix(0, started))
+		}
+		statuses = append(statuses, status)
+	}
+	for _, w := range p.workers {
+		add(w, false)
+	}
+	for w := range p.retiring {
+		add(w, true)
+	}
+	p.mu.Unlock()
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].ID < statuses[j].ID
+	})
+	return statuses
+}
+
+// HealthReport returns a snapshot of the progress made by the workers.
+func (p *WorkPool) HealthReport() HealthReport {
+	p.init()
+	interval := p.HealthInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	now := p.clock().Now()
+
+	p.mu.Lock()
+	report := HealthReport{
+		Running: p.running,
+		Workers: len(p.workers),
+	}
+	for _, w := range p.workers {
+		if started := w.calling.Load(); started != 0 {
+			if running := now.Sub(time.Unix(0, started)); running > interval {
+				report.Stuck = append(report.Stuck, StuckWorker{ID: w.id, Running: running})
+			}
+		}
+	}
+	p.mu.Unlock()
+
+	if last := atomic.LoadInt64(&p.counters.lastReturn); last != 0 {
+		report.LastReturn = time.Unix(0, last)
+	}
+	return report
+}
+
+// Healthy returns an error wrapping ErrUnhealthy if any worker has been stuck in a handler call for longer than
+// HealthInterval, and nil otherwise. It is cheap enough to call from a liveness probe:
+//
+//	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+//		if err := pool.Healthy(); err != nil {
+//			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+//		}
+//	})
+func (p *WorkPool) Healthy() error {
+	report := p.HealthReport()
+	if len(report.Stuck) == 0 {
+		return nil
+	}
+	stuck := report.Stuck[0]
+	return fmt.Errorf("%w: %d of %d workers stuck, worker %d for %s", ErrUnhealthy, len(report.Stuck), report.Workers,
+		stuck.ID, stuck.Running.Round(time.Millisecond))
+}
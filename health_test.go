@@ -0,0 +1,95 @@
+package workpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthy(t *testing.T) {
+	release := make(chan struct{})
+	calls := make(chan struct{}, 100)
+	pool := NewIndexed(2, func(workerID int, abort <-chan struct{}) bool {
+		calls <- struct{}{}
+		if workerID == 1 {
+			<-release
+			return false
+		}
+		select {
+		case <-abort:
+			return false
+		case <-time.After(time.Millisecond):
+			return true
+		}
+	})
+	pool.HealthInterval = 20 * time.Millisecond
+
+	report := pool.HealthReport()
+	assert.False(t, report.Running)
+	assert.True(t, report.LastReturn.IsZero())
+	assert.NoError(t, pool.Healthy())
+
+	pool.Start()
+	<-calls
+	<-calls
+	assert.NoError(t, pool.Healthy())
+
+	// Worker 1 is stuck in its handler call.
+	assert.Eventually(t, func() bool { return pool.Healthy() != nil }, time.Second, time.Millisecond)
+	err := pool.Healthy()
+	assert.ErrorIs(t, err, ErrUnhealthy)
+	assert.Contains(t, err.Error(), "1 of 2 workers stuck, worker 1")
+
+	report = pool.HealthReport()
+	assert.True(t, report.Running)
+	assert.Equal(t, 2, report.Workers)
+	require.Len(t, report.Stuck, 1)
+	assert.Equal(t, 1, report.Stuck[0].ID)
+	assert.Greater(t, report.Stuck[0].Running, 20*time.Millisecond)
+	assert.WithinDuration(t, time.Now(), report.LastReturn, time.Second)
+
+	close(release)
+	pool.Cancel()
+	assert.NoError(t, pool.Wait())
+	assert.NoError(t, pool.Healthy())
+}
+
+func TestWorkerStatuses(t *testing.T) {
+	release := make(chan struct{})
+	pool := NewIndexed(3, func(workerID int, abort <-chan struct{}) bool {
+		if workerID != 0 {
+			<-release
+			return false
+		}
+		<-abort
+		return false
+	})
+	assert.Empty(t, pool.WorkerStatuses())
+	pool.Start()
+	require.Eventually(t, func() bool {
+		for _, status := range pool.WorkerStatuses() {
+			if !status.Busy {
+				return false
+			}
+		}
+		return true
+	}, time.Second, time.Millisecond)
+
+	pool.Resize(2)
+	statuses := pool.WorkerStatuses()
+	require.Len(t, statuses, 3)
+	for i, status := range statuses {
+		assert.Equal(t, i, status.ID)
+		assert.True(t, status.Busy)
+		assert.Equal(t, i == 2, status.Retiring)
+	}
+	assert.True(t, pool.Stats().Running)
+
+	close(release)
+	pool.Cancel()
+	require.NoError(t, pool.Wait())
+	assert.Empty(t, pool.WorkerStatuses())
+	assert.False(t, pool.Stats().Running)
+}
@@ -0,0 +1,206 @@
+package workpool
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerHooks(t *testing.T) {
+	var mu sync.Mutex
+	var started, stopped []int
+
+	worker := func(abort <-chan struct{}) bool {
+		return false
+	}
+	pool := New(3, worker)
+	pool.OnWorkerStart = func(workerID int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		started = append(started, workerID)
+		return nil
+	}
+	pool.OnWorkerStop = func(workerID int) {
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = append(stopped, workerID)
+	}
+
+	assert.NoError(t, pool.Run())
+	sort.Ints(started)
+	sort.Ints(stopped)
+	assert.Equal(t, []int{0, 1, 2}, started)
+	assert.Equal(t, []int{0, 1, 2}, stopped)
+}
+
+func TestWorkerStartError(t *testing.T) {
+	calls := 0
+	stops := 0
+	worker := func(abort <-chan struct{}) bool {
+		calls++
+		return false
+	}
+	pool := New(1, worker)
+	pool.OnWorkerStart = func(workerID int) error {
+		return errors.New("no connection")
+	}
+	pool.OnWorkerStop = func(workerID int) {
+		stops++
+	}
+
+	assert.EqualError(t, pool.Run(), "no connection")
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, 0, stops)
+}
+
+func TestWorkerIDsReused(t *testing.T) {
+	pool := &WorkPool{}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	assert.Equal(t, 0, pool.allocID())
+	assert.Equal(t, 1, pool.allocID())
+	assert.Equal(t, 2, pool.allocID())
+	pool.ids[1] = false
+	assert.Equal(t, 1, pool.allocID())
+	assert.Equal(t, 3, pool.allocID())
+}
+
+func TestIndexedHandler(t *testing.T) {
+	numWorkers := 4
+	counts := make([]int, numWorkers)
+	worker := func(workerID int, abort <-chan struct{}) bool {
+		// Each worker owns its own slot, no locking required.
+		counts[workerID]++
+		return counts[workerID] < 3
+	}
+
+	pool := NewIndexed(numWorkers, worker)
+	assert.NoError(t, pool.Run())
+	assert.Equal(t, []int{3, 3, 3, 3}, counts)
+}
+
+func TestLifecycleHooks(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+
+	pool := New(1, func(abort <-chan struct{}) bool {
+		record("handler")
+		return false
+	})
+	pool.OnStart = func() { record("start") }
+	pool.OnStop = func(err error) { record(fmt.Sprint("stop ", err)) }
+	pool.OnCancel = func() { record("cancel") }
+	pool.OnWorkerExit = func(workerID int, reason ExitReason) { record(fmt.Sprint("exit ", workerID, " ", reason)) }
+	pool.Close = func() { record("close") }
+
+	assert.NoError(t, pool.Run())
+	assert.Equal(t, []string{"start", "handler", "exit 0 finished", "close", "stop <nil>"}, events)
+}
+
+func TestLifecycleCancel(t *testing.T) {
+	var mu sync.Mutex
+	reasons := make(map[ExitReason]int)
+	cancels := 0
+	var stopErr error
+
+	errFailed := errors.New("failed")
+	var running sync.WaitGroup
+	running.Add(2)
+	pool := NewWithError(2, func(abort <-chan struct{}) (bool, error) {
+		running.Done()
+		<-abort
+		return false, errFailed
+	})
+	pool.OnCancel = func() { cancels++ }
+	pool.OnStop = func(err error) { stopErr = err }
+	pool.OnWorkerExit = func(workerID int, reason ExitReason) {
+		mu.Lock()
+		defer mu.Unlock()
+		reasons[reason]++
+	}
+	pool.Start()
+	running.Wait()
+	pool.Cancel()
+	pool.Cancel()
+	assert.ErrorIs(t, pool.Wait(), errFailed)
+
+	assert.Equal(t, 1, cancels)
+	assert.Equal(t, errFailed, stopErr)
+	assert.Equal(t, map[ExitReason]int{ExitCancelled: 2}, reasons)
+}
+
+func TestExitReasons(t *testing.T) {
+	var mu sync.Mutex
+	var reasons []ExitReason
+	release := make(chan struct{})
+	pool := New(3, func(abort <-chan struct{}) bool {
+		select {
+		case <-release:
+			return false
+		case <-abort:
+			return false
+		}
+	})
+	pool.OnWorkerStart = func(workerID int) error {
+		if workerID == 2 {
+			return errors.New("no connection")
+		}
+		return nil
+	}
+	pool.OnWorkerExit = func(workerID int, reason ExitReason) {
+		mu.Lock()
+		defer mu.Unlock()
+		reasons = append(reasons, reason)
+	}
+	pool.Start()
+
+	// Shrinking the pool retires a worker once its current call returns.
+	assert.Eventually(t, func() bool { return pool.ActiveWorkers() == 2 }, time.Second, time.Millisecond)
+	pool.Resize(1)
+	release <- struct{}{}
+	close(release)
+	assert.EqualError(t, pool.Wait(), "no connection")
+
+	sort.Slice(reasons, func(i, j int) bool { return reasons[i] < reasons[j] })
+	assert.Equal(t, []ExitReason{ExitFinished, ExitRetired, ExitStartFailed}, reasons)
+}
+
+func TestExitReasonString(t *testing.T) {
+	assert.Equal(t, "finished", ExitFinished.String())
+	assert.Equal(t, "cancelled", ExitCancelled.String())
+	assert.Equal(t, "retired", ExitRetired.String())
+	assert.Equal(t, "start failed", ExitStartFailed.String())
+	assert.Equal(t, "unknown", ExitReason(-1).String())
+}
+
+func TestOnStartCallsPool(t *testing.T) {
+	pool := New(2, func(abort <-chan struct{}) bool {
+		return false
+	})
+	var stats Stats
+	pool.OnStart = func() {
+		// The hook runs without the lock, so it may use the pool.
+		stats = pool.Stats()
+	}
+
+	done := make(chan error)
+	go func() { done <- pool.Run() }()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnStart deadlocked")
+	}
+	assert.Equal(t, 0, stats.ActiveWorkers, "no worker has started yet")
+}
@@ -0,0 +1,65 @@
+package workpool
+
+import (
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// ErrIdleTimeout is the cause of a pool which shut itself down because of IdleTimeout. Run and Wait return it when no
+// handler returned an error.
+var ErrIdleTimeout = errors.New("workpool: idle timeout")
+
+// startIdleTimeout starts checking that the pool keeps finding work, shutting it down once it has gone IdleTimeout
+// without. It returns a function which stops the checks. The caller must hold p.mu.
+func (p *WorkPool) startIdleTimeout() (stop func()) {
+	if p.IdleTimeout <= 0 {
+		return func() {}
+	}
+	quit := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		clock := p.clock()
+		wait := p.IdleTimeout
+		for {
+			timer := clock.NewTimer(wait)
+			select {
+			case <-timer.C():
+				var idle bool
+				if idle, wait = p.checkIdle(clock.Now()); idle {
+					p.log(slog.LevelInfo, "workpool idle, shutting down", "timeout", p.IdleTimeout)
+					p.CancelWithCause(ErrIdleTimeout)
+					return
+				}
+			case <-quit:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+	return func() {
+		close(quit)
+		<-stopped
+	}
+}
+
+// checkIdle reports whether the pool has gone IdleTimeout without finding work, and otherwise how long to wait before
+// checking again. A paused pool is never idle, nor is a pool which owns its queue while a worker is processing an
+// item.
+func (p *WorkPool) checkIdle(now time.Time) (bool, time.Duration) {
+	p.mu.Lock()
+	since := p.started
+	p.mu.Unlock()
+	if last := atomic.LoadInt64(&p.counters.lastWork); last != 0 {
+		since = time.Unix(0, last)
+	}
+	if remaining := p.IdleTimeout - now.Sub(since); remaining > 0 {
+		return false, remaining
+	}
+	if p.Paused() || (p.idle != nil && !p.idle()) {
+		return false, p.IdleTimeout / 4
+	}
+	return true, 0
+}
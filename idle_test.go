@@ -0,0 +1,102 @@
+package workpool
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdleTimeout(t *testing.T) {
+	pool := NewTypedPool(2, func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	})
+	pool.IdleTimeout = 20 * time.Millisecond
+	pool.Start()
+	go func() {
+		for i := 0; i < 5; i++ {
+			assert.NoError(t, pool.Submit(i))
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+	count := 0
+	for range pool.Results() {
+		count++
+	}
+
+	assert.Equal(t, 5, count)
+	assert.ErrorIs(t, pool.Wait(), ErrIdleTimeout)
+	assert.ErrorIs(t, pool.AbortCause(), ErrIdleTimeout)
+	assert.ErrorIs(t, pool.Submit(5), ErrPoolClosed)
+}
+
+func TestIdleTimeoutBusy(t *testing.T) {
+	// A single item takes longer than the timeout, the pool is not idle while it is processed.
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		time.Sleep(30 * time.Millisecond)
+		return item, nil
+	})
+	pool.IdleTimeout = 10 * time.Millisecond
+	pool.Start()
+	require.NoError(t, pool.Submit(1))
+	result := <-pool.Results()
+	assert.Equal(t, 1, result.Value)
+	for range pool.Results() {
+	}
+	assert.ErrorIs(t, pool.Wait(), ErrIdleTimeout)
+}
+
+func TestIdleTimeoutPlainPool(t *testing.T) {
+	var calls int64
+	items := make(chan int)
+	pool := NewWithError(1, FromChannel(items, func(item int) error {
+		atomic.AddInt64(&calls, 1)
+		return nil
+	}))
+	pool.IdleTimeout = 10 * time.Millisecond
+	closed := make(chan struct{})
+	pool.Close = func() { close(closed) }
+	pool.Start()
+	items <- 1
+	items <- 2
+
+	assert.ErrorIs(t, pool.Wait(), ErrIdleTimeout)
+	<-closed
+	assert.Equal(t, int64(2), atomic.LoadInt64(&calls))
+}
+
+func TestIdleTimeoutError(t *testing.T) {
+	calls := 0
+	pool := NewWithError(1, func(abort <-chan struct{}) (bool, error) {
+		calls++
+		if calls == 1 {
+			return true, assert.AnError
+		}
+		<-abort
+		return false, nil
+	})
+	pool.IdleTimeout = 10 * time.Millisecond
+
+	assert.ErrorIs(t, pool.Run(), assert.AnError)
+	assert.ErrorIs(t, pool.AbortCause(), ErrIdleTimeout)
+}
+
+func TestIdleTimeoutNoWork(t *testing.T) {
+	// An Autoscale style handler keeps polling, reporting ErrNoWork, which does not keep the pool busy.
+	pool := NewWithError(1, func(abort <-chan struct{}) (bool, error) {
+		time.Sleep(time.Millisecond)
+		return true, ErrNoWork
+	})
+	pool.IdleTimeout = 20 * time.Millisecond
+	done := make(chan error, 1)
+	go func() { done <- pool.Run() }()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, ErrIdleTimeout)
+	case <-time.After(5 * time.Second):
+		pool.Cancel()
+		t.Fatal("the pool never went idle")
+	}
+}
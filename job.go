@@ -0,0 +1,237 @@
+package workpool
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrJobCancelled is the result error of a job which was cancelled before a worker started it.
+var ErrJobCancelled = errors.New("workpool: job cancelled")
+
+// JobState is the state of a job submitted with SubmitJob.
+type JobState int32
+
+const (
+	// JobQueued is the state of a job waiting for a worker.
+	JobQueued JobState = iota
+
+	// JobRunning is the state of a job being processed by a worker.
+	JobRunning
+
+	// JobDone is the state of a job whose handler returned no error.
+	JobDone
+
+	// JobFailed is the state of a job whose handler returned an error.
+	JobFailed
+
+	// JobCancelled is the state of a job cancelled with JobHandle.Cancel, or dropped as a duplicate because of Key.
+	JobCancelled
+)
+
+// String returns the name of the state.
+func (s JobState) String() string {
+	switch s {
+	case JobQueued:
+		return "queued"
+	case JobRunning:
+		return "running"
+	case JobDone:
+		return "done"
+	case JobFailed:
+		return "failed"
+	case JobCancelled:
+		return "cancelled"
+	default:
+		return "unknown"
+	}
+}
+
+// JobHandle is a handle to an item submitted with SubmitJob, which can be used to follow and cancel that item alone.
+type JobHandle struct {
+	id         uint64
+	state      atomic.Int32
+	cancelled  chan struct{}
+	cancelOnce sync.Once
+
+	// err is the error of a failed job, and group the JobGroup the job was submitted to.
+	err   error
+	group interface{ done(job *JobHandle) }
+}
+
+// ID returns the ID of the job, which is unique within its pool.
+func (j *JobHandle) ID() uint64 {
+	return j.id
+}
+
+// State returns the current state of the job.
+func (j *JobHandle) State() JobState {
+	return JobState(j.state.Load())
+}
+
+// Cancel cancels the job. A queued job is never given to the handler, its result has ErrJobCancelled as the error. A
+// running job has its abort signal closed, and the error returned by the handler is not reported to the pool. False
+// is returned if the job had already finished.
+func (j *JobHandle) Cancel() bool {
+	for {
+		switch state := j.State(); state {
+		case JobQueued:
+			if !j.state.CompareAndSwap(int32(JobQueued), int32(JobCancelled)) {
+				continue
+			}
+		case JobRunning:
+		default:
+			return false
+		}
+		j.cancelOnce.Do(func() { close(j.cancelled) })
+		return true
+	}
+}
+
+// start moves a queued job to running. False is returned if it was cancelled.
+func (j *JobHandle) start() bool {
+	return j.state.CompareAndSwap(int32(JobQueued), int32(JobRunning))
+}
+
+// isCancelled reports whether Cancel was called.
+func (j *JobHandle) isCancelled() bool {
+	select {
+	case <-j.cancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// finish records the outcome of the handler call.
+func (j *JobHandle) finish(err error) {
+	state := JobDone
+	switch {
+	case j.isCancelled():
+		state = JobCancelled
+	case err != nil:
+		state = JobFailed
+		j.err = err
+	}
+	j.state.Store(int32(state))
+}
+
+// settle tells the group of the job, if any, that the job will not run any more.
+func (j *JobHandle) settle() {
+	if j.group != nil {
+		j.group.done(j)
+	}
+}
+
+// abort returns a signal which is closed when either the pool's abort signal or the job is cancelled. The returned
+// function must be called once the handler returns.
+func (j *JobHandle) abort(pool <-chan struct{}) (<-chan struct{}, func()) {
+	merged := make(chan struct{})
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-pool:
+		case <-j.cancelled:
+		case <-stop:
+			return
+		}
+		close(merged)
+	}()
+	return merged, func() { close(stop) }
+}
+
+// SubmitJob is like Submit, but returns a handle to follow and cancel the item. If the item is dropped as a duplicate
+// because of Key, the job is already cancelled.
+func (p *TypedPool[In, Out]) SubmitJob(item In) (*JobHandle, error) {
+	return p.submitJob(item, nil)
+}
+
+// submitJob is SubmitJob for a job of group, if not nil. The group is told about the job before it is queued.
+func (p *TypedPool[In, Out]) submitJob(item In, group *JobGroup[In, Out]) (*JobHandle, error) {
+	p.init()
+	if p.ctx.Err() != nil || p.isFinishing() {
+		return nil, ErrPoolClosed
+	}
+	job := &JobHandle{id: p.jobIDs.Add(1), cancelled: make(chan struct{})}
+	if !p.claim(item) {
+		job.state.Store(int32(JobCancelled))
+		return job, nil
+	}
+	if group != nil {
+		job.group = group
+		group.add(job)
+	}
+
+	p.jobsMu.Lock()
+	if p.jobs == nil {
+		p.jobs = make(map[uint64]*JobHandle)
+	}
+	p.jobs[job.id] = job
+	p.jobsMu.Unlock()
+	if !p.queue.pushEntry(entry[In]{item: item, job: job}, p.QueueSize, p.ctx.Done()) {
+		p.forgetJob(job)
+		p.unclaim(item)
+		job.settle()
+		return nil, ErrPoolClosed
+	}
+	p.addTotal(1)
+	p.wake()
+	return job, nil
+}
+
+// LookupJob returns the handle of a queued or running job. False is returned once the job has finished.
+func (p *TypedPool[In, Out]) LookupJob(id uint64) (*JobHandle, bool) {
+	p.jobsMu.Lock()
+	defer p.jobsMu.Unlock()
+	job, ok := p.jobs[id]
+	return job, ok
+}
+
+// Jobs returns the handles of the queued and running jobs, in no particular order.
+func (p *TypedPool[In, Out]) Jobs() []*JobHandle {
+	p.jobsMu.Lock()
+	defer p.jobsMu.Unlock()
+	jobs := make([]*JobHandle, 0, len(p.jobs))
+	for _, job := range p.jobs {
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+// dropCancelled removes the cancelled jobs from the queue, so that they are settled without waiting for a worker to
+// take them. Their ErrJobCancelled results are delivered in the background, Close waits for them.
+func (p *TypedPool[In, Out]) dropCancelled() {
+	removed := p.queue.remove(func(e entry[In]) bool {
+		return e.job != nil && e.job.State() == JobCancelled
+	})
+	for _, e := range removed {
+		p.release(e.item)
+		p.forgetJob(e.job)
+		p.completeTask()
+		p.checkpoint(e.item)
+		item, _ := any(e.item).(Acker)
+		ack := acker{acker: item}
+		if err := ack.ack(false, false); err != nil {
+			p.setErr(err)
+		}
+		e.job.settle()
+	}
+	if len(removed) == 0 {
+		return
+	}
+	p.dropping.Add(1)
+	go func() {
+		defer p.dropping.Done()
+		for _, e := range removed {
+			callback, _ := e.callback.(func(Out, error))
+			p.deliver(e.order, Result[Out]{Err: ErrJobCancelled}, callback)
+		}
+	}()
+}
+
+// forgetJob removes a finished job from the pool's bookkeeping.
+func (p *TypedPool[In, Out]) forgetJob(job *JobHandle) {
+	p.jobsMu.Lock()
+	defer p.jobsMu.Unlock()
+	delete(p.jobs, job.id)
+}
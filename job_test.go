@@ -0,0 +1,131 @@
+package workpool
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmitJob(t *testing.T) {
+	release := make(chan struct{})
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item string) (string, error) {
+		if item == "slow" {
+			<-release
+		}
+		if item == "bad" {
+			return "", errors.New("bad item")
+		}
+		return item, nil
+	})
+	slow, err := pool.SubmitJob("slow")
+	require.NoError(t, err)
+	bad, err := pool.SubmitJob("bad")
+	require.NoError(t, err)
+	assert.NotEqual(t, slow.ID(), bad.ID())
+	assert.Equal(t, JobQueued, slow.State())
+	assert.Len(t, pool.Jobs(), 2)
+
+	pool.Start()
+	assert.Eventually(t, func() bool { return slow.State() == JobRunning }, time.Second, time.Millisecond)
+	found, ok := pool.LookupJob(slow.ID())
+	assert.True(t, ok)
+	assert.Same(t, slow, found)
+
+	close(release)
+	pool.Finish()
+	var values []string
+	for result := range pool.Results() {
+		values = append(values, result.Value)
+	}
+	assert.EqualError(t, pool.Wait(), "bad item")
+	assert.Equal(t, []string{"slow", ""}, values)
+	assert.Equal(t, JobDone, slow.State())
+	assert.Equal(t, JobFailed, bad.State())
+	assert.Empty(t, pool.Jobs())
+	_, ok = pool.LookupJob(slow.ID())
+	assert.False(t, ok)
+	assert.False(t, slow.Cancel(), "finished jobs cannot be cancelled")
+}
+
+func TestJobCancelQueued(t *testing.T) {
+	var handled []int
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		handled = append(handled, item)
+		return item, nil
+	})
+	first, err := pool.SubmitJob(1)
+	require.NoError(t, err)
+	second, err := pool.SubmitJob(2)
+	require.NoError(t, err)
+	assert.True(t, first.Cancel())
+	assert.Equal(t, JobCancelled, first.State())
+
+	pool.Start()
+	pool.Finish()
+	var results []Result[int]
+	for result := range pool.Results() {
+		results = append(results, result)
+	}
+	assert.NoError(t, pool.Wait())
+	assert.Equal(t, []int{2}, handled)
+	assert.Equal(t, []Result[int]{{Err: ErrJobCancelled}, {Value: 2}}, results)
+	assert.Equal(t, JobDone, second.State())
+}
+
+func TestJobCancelRunning(t *testing.T) {
+	started := make(chan struct{})
+	pool := NewTypedPool(2, func(abort <-chan struct{}, item int) (int, error) {
+		if item != 1 {
+			return item, nil
+		}
+		close(started)
+		<-abort
+		return 0, errors.New("aborted")
+	})
+	pool.Start()
+	stuck, err := pool.SubmitJob(1)
+	require.NoError(t, err)
+	<-started
+	assert.True(t, stuck.Cancel())
+	assert.NoError(t, pool.Submit(2))
+
+	pool.Finish()
+	var results []Result[int]
+	for result := range pool.Results() {
+		results = append(results, result)
+	}
+	assert.NoError(t, pool.Wait(), "a cancelled job does not fail the pool")
+	assert.False(t, pool.Cancelled())
+	assert.ElementsMatch(t, []Result[int]{{Err: errors.New("aborted")}, {Value: 2}}, results)
+	assert.Equal(t, JobCancelled, stuck.State())
+}
+
+func TestSubmitJobDuplicate(t *testing.T) {
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	})
+	pool.Key = func(item int) string { return "same" }
+	_, err := pool.SubmitJob(1)
+	require.NoError(t, err)
+	duplicate, err := pool.SubmitJob(1)
+	require.NoError(t, err)
+	assert.Equal(t, JobCancelled, duplicate.State())
+
+	pool.Finish()
+	pool.Start()
+	for range pool.Results() {
+	}
+	assert.NoError(t, pool.Wait())
+
+	_, err = pool.SubmitJob(3)
+	assert.ErrorIs(t, err, ErrPoolClosed)
+}
+
+func TestJobStateString(t *testing.T) {
+	assert.Equal(t, "queued", JobQueued.String())
+	assert.Equal(t, "cancelled", JobCancelled.String())
+	assert.Equal(t, "unknown", JobState(42).String())
+}
@@ -0,0 +1,114 @@
+package workpool
+
+import (
+	"errors"
+	"sync"
+)
+
+// JobGroup tags the jobs submitted through it, so that the requests sharing one TypedPool can each wait for, or
+// cancel, only their own jobs. It is created with TypedPool.Group. The results of its jobs are delivered like those of
+// any other job, on Results or to their callback.
+type JobGroup[In, Out any] struct {
+	pool *TypedPool[In, Out]
+
+	mu        sync.Mutex
+	jobs      map[*JobHandle]struct{}
+	err       error
+	cancelled bool
+
+	// idle is closed while the group has no unfinished jobs, and replaced when a job is added.
+	idle chan struct{}
+}
+
+// Group creates a JobGroup for jobs submitted to the pool.
+func (p *TypedPool[In, Out]) Group() *JobGroup[In, Out] {
+	idle := make(chan struct{})
+	close(idle)
+	return &JobGroup[In, Out]{pool: p, jobs: make(map[*JobHandle]struct{}), idle: idle}
+}
+
+// Submit is like TypedPool.SubmitJob, for a job of the group. ErrJobCancelled is returned once the group has been
+// cancelled.
+func (g *JobGroup[In, Out]) Submit(item In) (*JobHandle, error) {
+	g.mu.Lock()
+	cancelled := g.cancelled
+	g.mu.Unlock()
+	if cancelled {
+		return nil, ErrJobCancelled
+	}
+	return g.pool.submitJob(item, g)
+}
+
+// Wait blocks until every job submitted to the group so far has finished, or was cancelled. The first error returned
+// by the handler for one of them is returned. If the pool stops before running all of them, ErrPoolClosed is returned
+// along with that error.
+func (g *JobGroup[In, Out]) Wait() error {
+	g.pool.init()
+	for {
+		g.mu.Lock()
+		idle, err := g.idle, g.err
+		pending := len(g.jobs)
+		g.mu.Unlock()
+		if pending == 0 {
+			return err
+		}
+		select {
+		case <-idle:
+		case <-g.pool.done:
+			// The jobs may have finished along with the pool.
+			g.mu.Lock()
+			pending, err = len(g.jobs), g.err
+			g.mu.Unlock()
+			if pending == 0 {
+				return err
+			}
+			return errors.Join(err, ErrPoolClosed)
+		}
+	}
+}
+
+// Cancel cancels every unfinished job of the group, like JobHandle.Cancel does, and makes further calls to Submit fail.
+// Queued jobs are removed from the queue without being run, running jobs have their abort signal closed. The other
+// jobs of the pool are not affected.
+func (g *JobGroup[In, Out]) Cancel() {
+	g.mu.Lock()
+	g.cancelled = true
+	jobs := make([]*JobHandle, 0, len(g.jobs))
+	for job := range g.jobs {
+		jobs = append(jobs, job)
+	}
+	g.mu.Unlock()
+	for _, job := range jobs {
+		job.Cancel()
+	}
+	g.pool.dropCancelled()
+}
+
+// add counts a job submitted to the group.
+func (g *JobGroup[In, Out]) add(job *JobHandle) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.jobs) == 0 {
+		g.idle = make(chan struct{})
+	}
+	g.jobs[job] = struct{}{}
+	if g.cancelled {
+		job.Cancel()
+	}
+}
+
+// done records a job which will not run any more.
+func (g *JobGroup[In, Out]) done(job *JobHandle) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.jobs[job]; !ok {
+		return
+	}
+	delete(g.jobs, job)
+	if job.State() == JobFailed && g.err == nil {
+		g.err = job.err
+	}
+	if len(g.jobs) == 0 {
+		close(g.idle)
+	}
+}
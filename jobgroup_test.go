@@ -0,0 +1,145 @@
+package workpool
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobGroup(t *testing.T) {
+	release := make(chan struct{})
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item string) (string, error) {
+		if item == "a-fail" {
+			return "", fmt.Errorf("%s failed", item)
+		}
+		if item == "b1" {
+			select {
+			case <-release:
+			case <-abort:
+				return "", fmt.Errorf("%s aborted", item)
+			}
+		}
+		return item, nil
+	})
+	var mu sync.Mutex
+	var results []string
+	go func() {
+		for r := range pool.Results() {
+			mu.Lock()
+			if r.Err == nil {
+				results = append(results, r.Value)
+			}
+			mu.Unlock()
+		}
+	}()
+	pool.Start()
+
+	// b1 holds the only worker, so the other jobs queue behind it.
+	b := pool.Group()
+	b1, err := b.Submit("b1")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return b1.State() == JobRunning }, time.Second, time.Millisecond)
+	a := pool.Group()
+	for _, item := range []string{"a1", "a-fail", "a2"} {
+		_, err := a.Submit(item)
+		require.NoError(t, err)
+	}
+	b2, err := b.Submit("b2")
+	require.NoError(t, err)
+	other, err := pool.SubmitJob("other")
+	require.NoError(t, err)
+
+	b.Cancel()
+	assert.NoError(t, b.Wait())
+	assert.Equal(t, JobCancelled, b2.State())
+	_, err = b.Submit("b3")
+	assert.ErrorIs(t, err, ErrJobCancelled)
+
+	close(release)
+	assert.EqualError(t, a.Wait(), "a-fail failed")
+	pool.Finish()
+	assert.Error(t, pool.Wait())
+	assert.Equal(t, JobDone, other.State())
+	assert.Equal(t, JobCancelled, b1.State())
+	mu.Lock()
+	assert.Equal(t, []string{"a1", "a2", "other"}, results)
+	mu.Unlock()
+}
+
+func TestJobGroupEmpty(t *testing.T) {
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	})
+	assert.NoError(t, pool.Group().Wait())
+}
+
+func TestJobGroupPoolCancelled(t *testing.T) {
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		<-abort
+		return item, nil
+	})
+	pool.Start()
+	g := pool.Group()
+	for i := 0; i < 3; i++ {
+		_, err := g.Submit(i)
+		require.NoError(t, err)
+	}
+	pool.Cancel()
+	assert.ErrorIs(t, g.Wait(), ErrPoolClosed)
+}
+
+func TestJobGroupCancelRemovesQueuedJobs(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item string) (string, error) {
+		if item == "busy" {
+			close(started)
+			<-release
+		}
+		return item, nil
+	})
+	var cancelled int
+	var results []string
+	read := make(chan struct{})
+	go func() {
+		defer close(read)
+		for r := range pool.Results() {
+			if r.Err == ErrJobCancelled {
+				cancelled++
+			} else {
+				results = append(results, r.Value)
+			}
+		}
+	}()
+	pool.Start()
+
+	_, err := pool.SubmitJob("busy")
+	require.NoError(t, err)
+	<-started
+	g := pool.Group()
+	for _, item := range []string{"g1", "g2"} {
+		_, err := g.Submit(item)
+		require.NoError(t, err)
+	}
+	require.NoError(t, pool.Submit("after"))
+
+	// The only worker is busy, the cancelled jobs are settled without it.
+	g.Cancel()
+	assert.NoError(t, g.Wait())
+	assert.Equal(t, 1, pool.QueueLen())
+	assert.Len(t, pool.Jobs(), 1)
+
+	close(release)
+	pool.Finish()
+	assert.NoError(t, pool.Wait())
+	<-read
+	assert.Equal(t, 2, cancelled)
+	assert.Equal(t, []string{"busy", "after"}, results)
+	progress := pool.Progress()
+	assert.Equal(t, int64(4), progress.Done)
+	assert.Equal(t, int64(4), progress.Total)
+}
@@ -0,0 +1,16 @@
+package workpool
+
+import (
+	"context"
+	"runtime/pprof"
+	"strconv"
+)
+
+// labelled runs f with profiler labels identifying the pool and the worker, so that CPU and goroutine profiles can
+// attribute time to them. Goroutines started by the handler inherit the labels.
+func (p *WorkPool) labelled(w *worker, f func()) {
+	labels := pprof.Labels("workpool", p.Name, "worker", strconv.Itoa(w.id))
+	pprof.Do(context.Background(), labels, func(context.Context) {
+		f()
+	})
+}
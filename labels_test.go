@@ -0,0 +1,34 @@
+package workpool
+
+import (
+	"bytes"
+	"runtime/pprof"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfilerLabels(t *testing.T) {
+	var running sync.WaitGroup
+	running.Add(2)
+	pool := New(2, func(abort <-chan struct{}) bool {
+		running.Done()
+		<-abort
+		return false
+	})
+	pool.Name = "resizer"
+	pool.Start()
+	running.Wait()
+
+	var buf bytes.Buffer
+	require.NoError(t, pprof.Lookup("goroutine").WriteTo(&buf, 1))
+	pool.Cancel()
+	assert.NoError(t, pool.Wait())
+
+	profile := buf.String()
+	assert.Contains(t, profile, `"worker":"0"`)
+	assert.Contains(t, profile, `"worker":"1"`)
+	assert.Contains(t, profile, `"workpool":"resizer"`)
+}
@@ -0,0 +1,52 @@
+package workpool
+
+import (
+	"context"
+)
+
+// initialWorkers returns the number of workers to start with. A lazy pool starts one worker per queued item, up to
+// Workers, and is held open until release is called or the pool is cancelled. The caller must hold p.mu.
+func (p *WorkPool) initialWorkers() int {
+	if p.demand == nil {
+		return p.Workers
+	}
+	lazy, queued, closed := p.demand()
+	if !lazy {
+		return p.Workers
+	}
+	if !closed {
+		p.holding = true
+		context.AfterFunc(p.ctx, p.releaseHold)
+	}
+	if queued < p.Workers {
+		return queued
+	}
+	return p.Workers
+}
+
+// releaseHold lets a lazy pool finish once it has no workers left.
+func (p *WorkPool) releaseHold() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.holding {
+		return
+	}
+	p.holding = false
+	if p.running && p.live == 0 {
+		p.stopRunning()
+	}
+}
+
+// wake starts another worker for a newly queued item if the workers are started lazily and there are more queued
+// items than workers waiting for them.
+func (p *TypedPool[In, Out]) wake() {
+	if p.LazyWorkers && int(p.waiting.Load()) < p.queue.len() {
+		p.scaleUp()
+	}
+}
+
+// close closes the queue, letting the workers finish once it is empty.
+func (p *TypedPool[In, Out]) close() {
+	p.queue.close()
+	p.releaseHold()
+}
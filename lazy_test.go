@@ -0,0 +1,73 @@
+package workpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazyWorkers(t *testing.T) {
+	release := make(chan struct{})
+	pool := NewTypedPool(4, func(abort <-chan struct{}, item int) (int, error) {
+		<-release
+		return item, nil
+	})
+	pool.LazyWorkers = true
+	pool.Start()
+
+	// No workers are started until there is work.
+	time.Sleep(5 * time.Millisecond)
+	assert.Zero(t, pool.ActiveWorkers())
+	assert.True(t, pool.Stats().Duration > 0)
+
+	require.NoError(t, pool.Submit(1))
+	require.NoError(t, pool.Submit(2))
+	assert.Equal(t, 2, pool.ActiveWorkers())
+
+	// Never more than Workers.
+	for i := 3; i <= 10; i++ {
+		require.NoError(t, pool.Submit(i))
+	}
+	assert.Equal(t, 4, pool.ActiveWorkers())
+
+	close(release)
+	pool.Finish()
+	count := 0
+	for range pool.Results() {
+		count++
+	}
+	assert.Equal(t, 10, count)
+	assert.NoError(t, pool.Wait())
+}
+
+func TestLazyWorkersSubmittedBeforeStart(t *testing.T) {
+	pool := NewTypedPool(4, square)
+	pool.LazyWorkers = true
+	require.NoError(t, pool.Submit(3))
+	pool.Start()
+	assert.LessOrEqual(t, pool.ActiveWorkers(), 1)
+
+	assert.Equal(t, 9, (<-pool.Results()).Value)
+	assert.NoError(t, pool.Drain())
+}
+
+func TestLazyWorkersNoWork(t *testing.T) {
+	pool := NewTypedPool(4, square)
+	pool.LazyWorkers = true
+	// A lazy pool which never gets any work finishes, whether it is drained or cancelled.
+	assert.NoError(t, pool.Drain())
+
+	pool = NewTypedPool(4, square)
+	pool.LazyWorkers = true
+	pool.Start()
+	pool.Cancel()
+	assert.NoError(t, pool.Wait())
+
+	pool = NewTypedPool(4, square)
+	pool.LazyWorkers = true
+	pool.Start()
+	pool.Finish()
+	assert.NoError(t, pool.Wait())
+}
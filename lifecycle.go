@@ -0,0 +1,53 @@
+package workpool
+
+// ExitReason is why a worker exited, as reported to OnWorkerExit.
+type ExitReason int
+
+const (
+	// ExitFinished means that the handler reported that there is no more work, or the Limiter failed.
+	ExitFinished ExitReason = iota
+	// ExitCancelled means that the pool was cancelled.
+	ExitCancelled
+	// ExitRetired means that the worker was asked to stop by Resize or Autoscale.
+	ExitRetired
+	// ExitStartFailed means that OnWorkerStart returned an error.
+	ExitStartFailed
+	// ExitFailed means that a supervised worker failed with a fatal error or a panic, see Supervisor.
+	ExitFailed
+	// ExitRecycled means that the worker reached MaxTasksPerWorker and is replaced by a new one.
+	ExitRecycled
+)
+
+// String returns the name of the reason.
+func (r ExitReason) String() string {
+	switch r {
+	case ExitFinished:
+		return "finished"
+	case ExitCancelled:
+		return "cancelled"
+	case ExitRetired:
+		return "retired"
+	case ExitStartFailed:
+		return "start failed"
+	case ExitFailed:
+		return "failed"
+	case ExitRecycled:
+		return "recycled"
+	}
+	return "unknown"
+}
+
+// exitReason works out why a worker is stopping after the handler or one of the waits before it told it to.
+func (p *WorkPool) exitReason(w *worker, abort <-chan struct{}) ExitReason {
+	select {
+	case <-abort:
+		return ExitCancelled
+	default:
+	}
+	select {
+	case <-w.quit:
+		return ExitRetired
+	default:
+	}
+	return ExitFinished
+}
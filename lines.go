@@ -0,0 +1,64 @@
+package workpool
+
+import (
+	"bufio"
+	"context"
+	"io"
+)
+
+// Lines calls fn for every line read from r using numWorkers workers. Lines are split like bufio.ScanLines, without
+// their line endings. Errors are handled like ForEach, and an error reading r is returned once the lines read before
+// it have been processed.
+func Lines(ctx context.Context, numWorkers int, r io.Reader, fn func(ctx context.Context, line string) error) error {
+	return Scan(ctx, numWorkers, r, bufio.ScanLines, func(ctx context.Context, token []byte) error {
+		return fn(ctx, string(token))
+	})
+}
+
+// Scan calls fn for every token read from r with split, using numWorkers workers. Each token is a copy which fn may
+// keep. Tokens are read by a single goroutine while the workers process earlier ones. Errors are handled like Lines.
+//
+// When ctx is cancelled, or fn fails, Scan returns without waiting for a Read which is blocked, the reading goroutine
+// exits once that Read returns. Close r to unblock it sooner.
+func Scan(ctx context.Context, numWorkers int, r io.Reader, split bufio.SplitFunc, fn func(ctx context.Context, token []byte) error) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	tokens := make(chan []byte, numWorkers)
+	var readErr error
+	go func() {
+		defer close(tokens)
+		scanner := bufio.NewScanner(r)
+		scanner.Split(split)
+		for scanner.Scan() {
+			token := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case tokens <- token:
+			case <-runCtx.Done():
+				return
+			}
+		}
+		// Written before tokens is closed, so it is visible once the workers have drained it.
+		readErr = scanner.Err()
+	}()
+
+	pool := NewWithError(numWorkers, func(abort <-chan struct{}) (bool, error) {
+		token, ok := receive(abort, tokens)
+		if !ok {
+			return false, nil
+		}
+		if err := fn(runCtx, token); err != nil {
+			cancel()
+			return false, err
+		}
+		return true, nil
+	})
+
+	if err := pool.RunContext(runCtx); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return readErr
+}
@@ -0,0 +1,100 @@
+package workpool
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLines(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	err := Lines(context.Background(), 3, strings.NewReader("a\nb\r\nc\n\nd"), func(ctx context.Context, line string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, line)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b", "c", "", "d"}, got)
+}
+
+func TestScanWords(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	err := Scan(context.Background(), 2, strings.NewReader("one two  three"), bufio.ScanWords, func(ctx context.Context, token []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, string(token))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"one", "two", "three"}, got)
+}
+
+func TestLinesError(t *testing.T) {
+	input := strings.Repeat("line\n", 1000) + "bad\n" + strings.Repeat("line\n", 1000)
+	err := Lines(context.Background(), 2, strings.NewReader(input), func(ctx context.Context, line string) error {
+		if line == "bad" {
+			return errors.New("bad line")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "bad line")
+}
+
+// failingReader returns its data and then err.
+type failingReader struct {
+	data string
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.data == "" {
+		return 0, r.err
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestLinesReadError(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	reader := &failingReader{data: "a\nb\n", err: errors.New("disk on fire")}
+	err := Lines(context.Background(), 2, reader, func(ctx context.Context, line string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, line)
+		return nil
+	})
+	assert.EqualError(t, err, "disk on fire")
+	assert.ElementsMatch(t, []string{"a", "b"}, got)
+}
+
+func TestLinesCancelWhileReading(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Lines(ctx, 2, r, func(ctx context.Context, line string) error { return nil })
+	}()
+	w.Write([]byte("first\n"))
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("Lines blocked on the reader after cancellation")
+	}
+}
@@ -0,0 +1,37 @@
+package workpool
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// log emits an event to the Logger, if there is one.
+func (p *WorkPool) log(level slog.Level, msg string, args ...any) {
+	if p.Logger != nil {
+		p.Logger.Log(context.Background(), level, msg, args...)
+	}
+}
+
+// logSlow logs a handler call if it took longer than SlowTaskThreshold.
+func (p *WorkPool) logSlow(w *worker, elapsed time.Duration) {
+	if p.Logger == nil || p.SlowTaskThreshold <= 0 {
+		return
+	}
+	if elapsed > p.SlowTaskThreshold {
+		p.log(slog.LevelWarn, "slow handler call", "worker", w.id, "duration", elapsed)
+	}
+}
+
+// logFinished logs the end of a run.
+func (p *WorkPool) logFinished() {
+	if p.Logger == nil {
+		return
+	}
+	stats := p.Stats()
+	args := []any{"duration", stats.Duration, "invocations", stats.Invocations, "cancelled", stats.Cancelled}
+	if err := p.Err(); err != nil {
+		args = append(args, "error", err)
+	}
+	p.log(slog.LevelInfo, "workpool finished", args...)
+}
@@ -0,0 +1,88 @@
+package workpool
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// logBuffer is a goroutine safe buffer for log output.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func newTestLogger(buf *logBuffer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "stack" || a.Key == "duration" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+func TestLogger(t *testing.T) {
+	var buf logBuffer
+	calls := 0
+	pool := New(1, func(abort <-chan struct{}) bool {
+		calls++
+		switch calls {
+		case 1:
+			panic("boom")
+		case 2:
+			time.Sleep(5 * time.Millisecond)
+			return true
+		}
+		return false
+	})
+	pool.Logger = newTestLogger(&buf)
+	pool.RecoverPanics = true
+	pool.SlowTaskThreshold = time.Millisecond
+	assert.NoError(t, pool.Run())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, []string{
+		`level=INFO msg="workpool started" workers=1`,
+		`level=DEBUG msg="worker started" worker=0`,
+		`level=ERROR msg="handler panicked" panic=boom`,
+		`level=WARN msg="slow handler call" worker=0`,
+		`level=DEBUG msg="worker stopped" worker=0`,
+		`level=INFO msg="workpool finished" invocations=3 cancelled=false`,
+	}, lines)
+}
+
+func TestLoggerCancel(t *testing.T) {
+	var buf logBuffer
+	pool := New(2, func(abort <-chan struct{}) bool {
+		<-abort
+		return false
+	})
+	pool.Logger = newTestLogger(&buf)
+	pool.Start()
+	pool.Cancel()
+	pool.Cancel()
+	assert.NoError(t, pool.Wait())
+
+	assert.Equal(t, 1, strings.Count(buf.String(), `msg="workpool cancelled"`))
+	assert.Contains(t, buf.String(), `cancelled=true`)
+}
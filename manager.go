@@ -0,0 +1,201 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Pool is implemented by WorkPool and by the pool types embedding it, such as TypedPool, Executor and Scheduler.
+type Pool interface {
+	Start()
+	Wait() error
+	Cancel()
+	Stats() Stats
+	Progress() Progress
+	ErrorCount() int
+	WorkerStatuses() []WorkerStatus
+	Resize(n int)
+	Pause()
+	Resume()
+	Paused() bool
+	StopReason() StopReason
+
+	base() *WorkPool
+}
+
+// base returns the WorkPool of a Pool.
+func (p *WorkPool) base() *WorkPool {
+	return p
+}
+
+// ShutdownMode is how Manager.Shutdown stops a pool.
+type ShutdownMode int
+
+const (
+	// ShutdownDrain lets a pool finish the work it was given. Pools with a Finish method, such as TypedPool, are told
+	// that no more work is coming, other pools are waited on until their handler runs out of work.
+	ShutdownDrain ShutdownMode = iota
+	// ShutdownCancel cancels a pool and waits for its workers to exit.
+	ShutdownCancel
+)
+
+// ManagerStats is a snapshot of the pools of a Manager.
+type ManagerStats struct {
+	// Pools holds the Stats of each pool by name.
+	Pools map[string]Stats
+
+	// Total adds up the workers, calls and queues of all pools. Its Duration and MaxQueued are the largest ones, and
+	// it is Cancelled or Running if any pool is.
+	Total Stats
+}
+
+// Manager owns a set of named pools, so that a service running several of them can start them together, inspect them
+// in one place and shut them down in a known order.
+type Manager struct {
+	// ShutdownOrder lists the names of the pools in the order Shutdown stops them. Pools which are not listed are
+	// stopped afterwards, in the order they were added, and names which do not match a pool are ignored.
+	ShutdownOrder []string
+
+	mu    sync.Mutex
+	names []string
+	pools map[string]Pool
+}
+
+// NewManager creates a Manager without pools.
+func NewManager() *Manager {
+	return &Manager{pools: make(map[string]Pool)}
+}
+
+// Add gives a pool to the manager under name. Pools added after Start must be started by the caller.
+func (m *Manager) Add(name string, pool Pool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.pools[name]; ok {
+		return fmt.Errorf("workpool: pool %s added twice", name)
+	}
+	m.names = append(m.names, name)
+	m.pools[name] = pool
+	return nil
+}
+
+// Pool returns the pool added under name.
+func (m *Manager) Pool(name string) (Pool, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pool, ok := m.pools[name]
+	return pool, ok
+}
+
+// Names returns the names of the pools in the order they were added.
+func (m *Manager) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.names...)
+}
+
+// Start starts every pool in the order they were added.
+func (m *Manager) Start() {
+	for _, name := range m.Names() {
+		pool, _ := m.Pool(name)
+		pool.Start()
+	}
+}
+
+// Wait blocks until every pool has finished. The errors of the pools are returned joined, each prefixed with the name
+// of its pool.
+func (m *Manager) Wait() error {
+	var errs []error
+	for _, name := range m.Names() {
+		pool, _ := m.Pool(name)
+		if err := pool.Wait(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Stats returns the Stats of every pool along with their total.
+func (m *Manager) Stats() ManagerStats {
+	stats := ManagerStats{Pools: make(map[string]Stats)}
+	for _, name := range m.Names() {
+		pool, _ := m.Pool(name)
+		s := pool.Stats()
+		stats.Pools[name] = s
+		stats.Total.ActiveWorkers += s.ActiveWorkers
+		stats.Total.RetiringWorkers += s.RetiringWorkers
+		stats.Total.Invocations += s.Invocations
+		stats.Total.Finished += s.Finished
+		stats.Total.Timeouts += s.Timeouts
+		stats.Total.Duration = max(stats.Total.Duration, s.Duration)
+		stats.Total.Cancelled = stats.Total.Cancelled || s.Cancelled
+		stats.Total.Running = stats.Total.Running || s.Running
+		stats.Total.Queued += s.Queued
+		stats.Total.MaxQueued = max(stats.Total.MaxQueued, s.MaxQueued)
+		stats.Total.Enqueued += s.Enqueued
+		stats.Total.Dequeued += s.Dequeued
+		stats.Total.SubmitBlocked += s.SubmitBlocked
+	}
+	return stats
+}
+
+// Shutdown stops the pools one at a time in ShutdownOrder, waiting for each one to finish before stopping the next.
+// When ctx is done before the pools have drained, the remaining pools are cancelled instead. The errors of the pools
+// are returned like Wait does, along with the cause of ctx if it cut the shutdown short.
+func (m *Manager) Shutdown(ctx context.Context, mode ShutdownMode) error {
+	var errs []error
+	for _, name := range m.order() {
+		pool, _ := m.Pool(name)
+		if ctx.Err() != nil {
+			mode = ShutdownCancel
+		}
+		if err := stop(ctx, pool, mode); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	if ctx.Err() != nil {
+		errs = append(errs, context.Cause(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// order returns the names of the pools in the order they are shut down.
+func (m *Manager) order() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	order := make([]string, 0, len(m.names))
+	seen := make(map[string]bool)
+	for _, name := range append(append([]string(nil), m.ShutdownOrder...), m.names...) {
+		if _, ok := m.pools[name]; ok && !seen[name] {
+			seen[name] = true
+			order = append(order, name)
+		}
+	}
+	return order
+}
+
+// stop shuts a single pool down, cancelling it if ctx is done before it drains. A pool which was never started is
+// only cancelled.
+func stop(ctx context.Context, pool Pool, mode ShutdownMode) error {
+	p := pool.base()
+	p.init()
+	p.mu.Lock()
+	launched := p.launched
+	p.mu.Unlock()
+	if !launched {
+		pool.Cancel()
+		return nil
+	}
+	if mode == ShutdownCancel {
+		pool.Cancel()
+	} else if finisher, ok := pool.(interface{ Finish() }); ok {
+		finisher.Finish()
+	}
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		pool.Cancel()
+	}
+	return pool.Wait()
+}
@@ -0,0 +1,114 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager(t *testing.T) {
+	var mu sync.Mutex
+	var stopped []string
+	onStop := func(name string) func(error) {
+		return func(error) {
+			mu.Lock()
+			defer mu.Unlock()
+			stopped = append(stopped, name)
+		}
+	}
+
+	typed := NewTypedPool(2, func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	})
+	typed.OnStop = onStop("typed")
+	go func() {
+		for range typed.Results() {
+		}
+	}()
+	executor := NewExecutor(1)
+	executor.OnStop = onStop("executor")
+	scheduler := NewScheduler(1)
+	scheduler.OnStop = onStop("scheduler")
+	plain := NewWithError(1, func(abort <-chan struct{}) (bool, error) {
+		return false, errors.New("plain failed")
+	})
+	plain.OnStop = onStop("plain")
+
+	m := NewManager()
+	require.NoError(t, m.Add("typed", typed))
+	require.NoError(t, m.Add("executor", executor))
+	require.NoError(t, m.Add("scheduler", scheduler))
+	require.NoError(t, m.Add("plain", plain))
+	assert.EqualError(t, m.Add("plain", plain), "workpool: pool plain added twice")
+	assert.Equal(t, []string{"typed", "executor", "scheduler", "plain"}, m.Names())
+	pool, ok := m.Pool("executor")
+	assert.True(t, ok)
+	assert.Same(t, executor, pool)
+
+	m.ShutdownOrder = []string{"plain", "scheduler", "unknown"}
+	m.Start()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, typed.Submit(i))
+	}
+
+	err := m.Shutdown(context.Background(), ShutdownDrain)
+	assert.EqualError(t, err, "plain: plain failed")
+	assert.Equal(t, []string{"plain", "scheduler", "typed", "executor"}, stopped)
+
+	stats := m.Stats()
+	assert.Len(t, stats.Pools, 4)
+	assert.Equal(t, int64(5), stats.Pools["typed"].Invocations-stats.Pools["typed"].Finished)
+	assert.Equal(t, stats.Pools["typed"].Invocations+stats.Pools["executor"].Invocations+
+		stats.Pools["scheduler"].Invocations+stats.Pools["plain"].Invocations, stats.Total.Invocations)
+	assert.Zero(t, stats.Total.ActiveWorkers)
+	assert.False(t, stats.Total.Cancelled)
+	assert.EqualError(t, m.Wait(), "plain: plain failed")
+}
+
+func TestManagerShutdownTimeout(t *testing.T) {
+	stuck := New(1, func(abort <-chan struct{}) bool {
+		<-abort
+		return false
+	})
+	idle := NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	})
+	unstarted := New(1, func(abort <-chan struct{}) bool { return false })
+
+	m := NewManager()
+	require.NoError(t, m.Add("stuck", stuck))
+	require.NoError(t, m.Add("idle", idle))
+	m.Start()
+	require.NoError(t, m.Add("unstarted", unstarted))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := m.Shutdown(ctx, ShutdownDrain)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, stuck.Cancelled(), "the pool which did not drain is cancelled")
+	assert.True(t, idle.Cancelled(), "the pools after it are cancelled")
+	assert.True(t, unstarted.Cancelled())
+	assert.True(t, m.Stats().Total.Cancelled)
+}
+
+func TestManagerShutdownCancel(t *testing.T) {
+	m := NewManager()
+	for _, name := range []string{"a", "b"} {
+		require.NoError(t, m.Add(name, New(2, func(abort <-chan struct{}) bool {
+			<-abort
+			return false
+		})))
+	}
+	m.Start()
+	assert.Equal(t, 4, m.Stats().Total.ActiveWorkers)
+	assert.NoError(t, m.Shutdown(context.Background(), ShutdownCancel))
+	for _, name := range m.Names() {
+		pool, _ := m.Pool(name)
+		assert.True(t, pool.Stats().Cancelled)
+	}
+}
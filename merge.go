@@ -0,0 +1,32 @@
+package workpool
+
+// Merge adds a stage which sends the items read from every channel in ins to the returned channel, so that several
+// stages can feed one. The returned channel is closed once all of ins are closed, or once the pipeline is cancelled.
+// Items from the same input keep their order, items from different inputs are interleaved as they arrive.
+func Merge[T any](p *Pipeline, ins ...<-chan T) <-chan T {
+	out := make(chan T)
+	if len(ins) == 0 {
+		close(out)
+		return out
+	}
+	p.add(&WorkPool{
+		// With a fixed number of workers the IDs range over the inputs.
+		Workers: len(ins),
+		IndexedHandler: func(workerID int, abort <-chan struct{}) bool {
+			item, ok := receive(abort, ins[workerID])
+			if !ok {
+				return false
+			}
+			select {
+			case out <- item:
+				return true
+			case <-abort:
+				return false
+			}
+		},
+		Close: func() {
+			close(out)
+		},
+	})
+	return out
+}
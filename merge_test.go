@@ -0,0 +1,62 @@
+package workpool
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	p := NewPipeline()
+	odds := Source(p, 1, 3, 5)
+	evens := Source(p, 2, 4)
+	var got []int
+	Sink(p, 1, Merge(p, odds, evens), func(abort <-chan struct{}, item int) error {
+		got = append(got, item)
+		return nil
+	})
+
+	assert.NoError(t, p.Run())
+	sort.Ints(got)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, got)
+}
+
+func TestMergeNoInputs(t *testing.T) {
+	p := NewPipeline()
+	count := 0
+	Sink(p, 1, Merge[int](p), func(abort <-chan struct{}, item int) error {
+		count++
+		return nil
+	})
+
+	assert.NoError(t, p.Run())
+	assert.Zero(t, count)
+}
+
+func TestMergeCancel(t *testing.T) {
+	p := NewPipeline()
+	// Neither input is ever closed, only cancellation finishes the stage.
+	idle := make(chan int)
+	busy := make(chan int, 1)
+	busy <- 1
+	merged := Merge(p, (<-chan int)(idle), (<-chan int)(busy))
+	Sink(p, 1, merged, func(abort <-chan struct{}, item int) error {
+		p.Cancel()
+		return nil
+	})
+
+	done := make(chan error)
+	go func() {
+		done <- p.Run()
+	}()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the pipeline did not stop")
+	}
+	_, open := <-merged
+	assert.False(t, open)
+}
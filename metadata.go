@@ -0,0 +1,100 @@
+package workpool
+
+import (
+	"context"
+	"time"
+)
+
+// TypedContextHandler is like TypedHandler, but is given a context instead of an abort signal. The context is done when
+// the pool is cancelled or the deadline of the item passes, and it carries the values of the context the item was
+// submitted with, see SubmitContext. Values which the item's context does not have are looked up in the context given
+// to RunContext.
+type TypedContextHandler[In, Out any] func(ctx context.Context, item In) (Out, error)
+
+// NewTypedPoolContext creates a TypedPool which calls handler for each submitted item using numWorkers goroutines.
+func NewTypedPoolContext[In, Out any](numWorkers int, handler TypedContextHandler[In, Out]) *TypedPool[In, Out] {
+	p := NewTypedPool[In, Out](numWorkers, nil)
+	p.contextHandler = handler
+	return p
+}
+
+// SubmitContext is like Submit, but the item carries the values and the deadline of ctx, such as trace IDs or the
+// tenant of a request, through the queue to the handler. A TypedContextHandler is given a context with those values,
+// and the context, or the abort signal of a TypedHandler, is done once the deadline passes, even if it passed while
+// the item was queued. The cancellation of ctx is not carried over, so an item outlives the request which submitted it.
+func (p *TypedPool[In, Out]) SubmitContext(ctx context.Context, item In) error {
+	p.init()
+	if p.ctx.Err() != nil || p.isFinishing() {
+		return ErrPoolClosed
+	}
+	if !p.claim(item) {
+		return nil
+	}
+	if !p.queue.pushEntry(entry[In]{item: item, ctx: ctx}, p.QueueSize, p.ctx.Done()) {
+		p.unclaim(item)
+		return ErrPoolClosed
+	}
+	p.addTotal(1)
+	p.wake()
+	return nil
+}
+
+// call calls the handler for an item. The context of the item is built only when it was submitted with one, or the
+// handler takes one.
+func (p *TypedPool[In, Out]) call(handler TypedHandler[In, Out], e entry[In], abort <-chan struct{}) (Out, error) {
+	if e.ctx == nil && p.contextHandler == nil {
+		return handler(abort, e.item)
+	}
+
+	var ctx context.Context = itemContext{Context: abortContext{abort}, values: e.ctx, parent: p.parent}
+	if e.ctx != nil {
+		if deadline, ok := e.ctx.Deadline(); ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, deadline)
+			defer cancel()
+		}
+	}
+	if p.contextHandler != nil {
+		return p.contextHandler(ctx, e.item)
+	}
+	return handler(ctx.Done(), e.item)
+}
+
+// abortContext is a context which is done when an abort signal is closed.
+type abortContext struct {
+	abort <-chan struct{}
+}
+
+func (abortContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (c abortContext) Done() <-chan struct{} { return c.abort }
+
+func (c abortContext) Err() error {
+	select {
+	case <-c.abort:
+		return context.Canceled
+	default:
+		return nil
+	}
+}
+
+func (abortContext) Value(any) any { return nil }
+
+// itemContext looks up values in the context an item was submitted with, then in the context given to RunContext.
+type itemContext struct {
+	context.Context
+	values context.Context
+	parent context.Context
+}
+
+func (c itemContext) Value(key any) any {
+	if c.values != nil {
+		if v := c.values.Value(key); v != nil {
+			return v
+		}
+	}
+	if c.parent != nil {
+		return c.parent.Value(key)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package workpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type traceKey struct{}
+
+type tenantKey struct{}
+
+func TestSubmitContext(t *testing.T) {
+	pool := NewTypedPoolContext(2, func(ctx context.Context, item int) (string, error) {
+		trace, _ := ctx.Value(traceKey{}).(string)
+		tenant, _ := ctx.Value(tenantKey{}).(string)
+		return trace + "/" + tenant, ctx.Err()
+	})
+	parent := context.WithValue(context.Background(), tenantKey{}, "default")
+
+	request, cancel := context.WithCancel(context.WithValue(context.Background(), traceKey{}, "abc"))
+	require.NoError(t, pool.SubmitContext(request, 1))
+	// The item outlives the request.
+	cancel()
+	require.NoError(t, pool.SubmitContext(context.WithValue(request, tenantKey{}, "acme"), 2))
+	require.NoError(t, pool.Submit(3))
+	pool.Finish()
+
+	go func() {
+		assert.NoError(t, pool.RunContext(parent))
+	}()
+	var got []string
+	for result := range pool.Results() {
+		require.NoError(t, result.Err)
+		got = append(got, result.Value)
+	}
+	assert.ElementsMatch(t, []string{"abc/default", "abc/acme", "/default"}, got)
+}
+
+func TestSubmitContextDeadline(t *testing.T) {
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		select {
+		case <-abort:
+			return 0, context.DeadlineExceeded
+		case <-time.After(time.Second):
+			return item, nil
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.NoError(t, pool.SubmitContext(ctx, 1))
+	pool.Finish()
+	pool.Start()
+
+	result := <-pool.Results()
+	assert.ErrorIs(t, result.Err, context.DeadlineExceeded)
+	_, ok := <-pool.Results()
+	assert.False(t, ok)
+	assert.False(t, pool.Cancelled())
+}
+
+func TestTypedPoolContextCancelled(t *testing.T) {
+	pool := NewTypedPoolContext(1, func(ctx context.Context, item int) (int, error) {
+		<-ctx.Done()
+		assert.ErrorIs(t, ctx.Err(), context.Canceled)
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+		return item, nil
+	})
+	require.NoError(t, pool.Submit(1))
+	pool.Start()
+	time.Sleep(5 * time.Millisecond)
+	pool.Cancel()
+	require.NoError(t, pool.Wait())
+}
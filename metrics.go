@@ -0,0 +1,51 @@
+package workpool
+
+import (
+	"errors"
+	"time"
+)
+
+// MetricsSink receives the metrics of a pool, for sending them to a system such as StatsD, see the statsd package.
+// The pool calls it from its workers, so it must be safe for concurrent use and should not block.
+//
+// The metrics are the counters "invocations", "finished", "errors" and "timeouts", which count handler calls, those
+// which reported that there was no more work, returned an error other than ErrNoWork, or exceeded TaskTimeout. The
+// timing "duration" is the time taken by each handler call, and the gauge "workers" is the number of running workers.
+//
+// Pools which queue their work, such as TypedPool, also report the counters "enqueued" and "dequeued" for the items
+// added to the queue and taken from it, the gauge "queued" for the depth of the queue, and the timing
+// "submit_blocked" for each submission which had to wait for room in a full queue.
+type MetricsSink interface {
+	// Counter adds delta to a counter.
+	Counter(name string, delta int64)
+
+	// Gauge sets a gauge to value.
+	Gauge(name string, value float64)
+
+	// Timing records a duration.
+	Timing(name string, d time.Duration)
+}
+
+// recordMetrics reports a single handler call to the MetricsSink.
+func (p *WorkPool) recordMetrics(foundWork bool, err error, elapsed time.Duration) {
+	m := p.Metrics
+	if m == nil {
+		return
+	}
+	m.Counter("invocations", 1)
+	if !foundWork {
+		m.Counter("finished", 1)
+	}
+	// ErrNoWork reports an idle call, not a failure.
+	if err != nil && !errors.Is(err, ErrNoWork) {
+		m.Counter("errors", 1)
+	}
+	m.Timing("duration", elapsed)
+}
+
+// gaugeWorkers reports the number of running workers to the MetricsSink. The caller must hold p.mu.
+func (p *WorkPool) gaugeWorkers() {
+	if p.Metrics != nil {
+		p.Metrics.Gauge("workers", float64(p.live))
+	}
+}
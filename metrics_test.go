@@ -0,0 +1,123 @@
+package workpool
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingSink is a MetricsSink which keeps the latest value of every metric.
+type recordingSink struct {
+	mu       sync.Mutex
+	counters map[string]int64
+	gauges   map[string]float64
+	timings  map[string]int
+}
+
+func newRecordingSink() *recordingSink {
+	return &recordingSink{
+		counters: make(map[string]int64),
+		gauges:   make(map[string]float64),
+		timings:  make(map[string]int),
+	}
+}
+
+func (s *recordingSink) Counter(name string, delta int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters[name] += delta
+}
+
+func (s *recordingSink) Gauge(name string, value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gauges[name] = value
+}
+
+func (s *recordingSink) Timing(name string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timings[name]++
+}
+
+func TestMetrics(t *testing.T) {
+	sink := newRecordingSink()
+	var mu sync.Mutex
+	calls := 0
+	pool := NewWithError(2, func(abort <-chan struct{}) (bool, error) {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+		switch {
+		case n == 1:
+			<-abort
+			return true, nil
+		case n == 2:
+			return true, assert.AnError
+		case n < 6:
+			return true, nil
+		}
+		return false, nil
+	})
+	pool.TaskTimeout = 10 * time.Millisecond
+	pool.Metrics = sink
+	assert.Error(t, pool.Run())
+
+	assert.Equal(t, map[string]int64{
+		"invocations": int64(calls),
+		"finished":    2,
+		"errors":      1,
+		"timeouts":    1,
+	}, sink.counters)
+	assert.Equal(t, map[string]int{"duration": calls}, sink.timings)
+	assert.Equal(t, map[string]float64{"workers": 0}, sink.gauges)
+}
+
+func TestMetricsNoWork(t *testing.T) {
+	sink := newRecordingSink()
+	calls := 0
+	pool := NewWithError(1, func(abort <-chan struct{}) (bool, error) {
+		calls++
+		return calls < 3, ErrNoWork
+	})
+	pool.Metrics = sink
+	assert.NoError(t, pool.Run())
+	assert.Equal(t, int64(3), sink.counters["invocations"])
+	assert.Zero(t, sink.counters["errors"], "idle calls are not errors")
+}
+
+func TestQueueMetrics(t *testing.T) {
+	sink := newRecordingSink()
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	})
+	pool.Metrics = sink
+	pool.QueueSize = 1
+	for i := 0; i < 3; i++ {
+		go func(i int) {
+			assert.NoError(t, pool.Submit(i))
+		}(i)
+	}
+	// Let the submissions block on the full queue before starting the workers.
+	assert.Eventually(t, func() bool { return pool.Stats().Enqueued == 1 }, time.Second, time.Millisecond)
+	time.Sleep(time.Millisecond)
+	pool.Start()
+	var results int
+	for range pool.Results() {
+		results++
+		if results == 3 {
+			pool.Finish()
+		}
+	}
+	assert.NoError(t, pool.Wait())
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	assert.Equal(t, int64(3), sink.counters["enqueued"])
+	assert.Equal(t, int64(3), sink.counters["dequeued"])
+	assert.Equal(t, float64(0), sink.gauges["queued"])
+	assert.Equal(t, 2, sink.timings["submit_blocked"])
+}
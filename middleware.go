@@ -0,0 +1,54 @@
+package workpool
+
+import (
+	"context"
+)
+
+// Middleware wraps a handler to add behaviour around every call, such as logging, metrics or retries. It is given the
+// handler for a single worker and returns the handler that worker calls instead.
+type Middleware func(next ErrWorkHandler) ErrWorkHandler
+
+// CallHandler is a handler given the context of the call along with the abort signal, see ContextMiddleware.
+type CallHandler func(ctx context.Context, abort <-chan struct{}) (bool, error)
+
+// ContextMiddleware is like Middleware, but the handler is also given the context a ContextWorkHandler gets. The
+// middleware may call next with a context derived from it, such as one carrying a tracing span, and a
+// ContextWorkHandler is then called with that context.
+type ContextMiddleware func(next CallHandler) CallHandler
+
+// layer is a Middleware or ContextMiddleware, built for a worker.
+type layer func(w *worker, next ErrWorkHandler) ErrWorkHandler
+
+// Use adds middleware around the handler. The first middleware added is the outermost, so it sees every call before
+// the ones added after it. Middleware applies to workers started after Use is called, so it should be added before
+// Start.
+//
+// Recovered panics, task timeouts, pausing and the Limiter are handled by the pool outside of all middleware.
+func (p *WorkPool) Use(mw ...Middleware) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, m := range mw {
+		p.middleware = append(p.middleware, func(w *worker, next ErrWorkHandler) ErrWorkHandler {
+			return m(next)
+		})
+	}
+}
+
+// UseContext is like Use, but adds ContextMiddleware. It is ordered with the middleware added with Use.
+func (p *WorkPool) UseContext(mw ...ContextMiddleware) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, m := range mw {
+		p.middleware = append(p.middleware, func(w *worker, next ErrWorkHandler) ErrWorkHandler {
+			handler := m(func(ctx context.Context, abort <-chan struct{}) (bool, error) {
+				outer := w.call
+				w.call = ctx
+				defer func() { w.call = outer }()
+				return next(abort)
+			})
+			return func(abort <-chan struct{}) (bool, error) {
+				return handler(p.handlerContext(w), abort)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUse(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next ErrWorkHandler) ErrWorkHandler {
+			return func(abort <-chan struct{}) (bool, error) {
+				mu.Lock()
+				calls = append(calls, name+" before")
+				mu.Unlock()
+				foundWork, err := next(abort)
+				mu.Lock()
+				calls = append(calls, name+" after")
+				mu.Unlock()
+				return foundWork, err
+			}
+		}
+	}
+
+	pool := New(1, func(abort <-chan struct{}) bool {
+		mu.Lock()
+		calls = append(calls, "handler")
+		mu.Unlock()
+		return false
+	})
+	pool.Use(record("outer"), record("middle"))
+	pool.Use(record("inner"))
+	assert.NoError(t, pool.Run())
+
+	assert.Equal(t, []string{
+		"outer before", "middle before", "inner before",
+		"handler",
+		"inner after", "middle after", "outer after",
+	}, calls)
+}
+
+func TestUseError(t *testing.T) {
+	errRetried := errors.New("retried")
+
+	// A middleware retrying failed calls once.
+	retry := func(next ErrWorkHandler) ErrWorkHandler {
+		return func(abort <-chan struct{}) (bool, error) {
+			foundWork, err := next(abort)
+			if err != nil {
+				return next(abort)
+			}
+			return foundWork, err
+		}
+	}
+
+	calls := 0
+	pool := NewWithError(1, func(abort <-chan struct{}) (bool, error) {
+		calls++
+		if calls == 1 {
+			return true, errRetried
+		}
+		return false, nil
+	})
+	pool.Use(retry)
+	assert.NoError(t, pool.Run())
+	assert.Equal(t, 2, calls)
+}
+
+func TestUseIndexed(t *testing.T) {
+	var mu sync.Mutex
+	ids := make(map[int]bool)
+	pool := NewIndexed(3, func(workerID int, abort <-chan struct{}) bool {
+		mu.Lock()
+		ids[workerID] = true
+		mu.Unlock()
+		return false
+	})
+	wrapped := 0
+	pool.Use(func(next ErrWorkHandler) ErrWorkHandler {
+		mu.Lock()
+		wrapped++
+		mu.Unlock()
+		return next
+	})
+	assert.NoError(t, pool.Run())
+	// The middleware wraps the handler of each worker.
+	assert.Equal(t, 3, wrapped)
+	assert.Len(t, ids, 3)
+}
+
+func TestUseContext(t *testing.T) {
+	type key string
+	var values []any
+	pool := NewWithContext(1, func(ctx context.Context) bool {
+		id, ok := WorkerID(ctx)
+		assert.True(t, ok)
+		values = append(values, ctx.Value(key("outer")), ctx.Value(key("inner")), ctx.Value(key("run")), id)
+		return false
+	})
+	with := func(name string) ContextMiddleware {
+		return func(next CallHandler) CallHandler {
+			return func(ctx context.Context, abort <-chan struct{}) (bool, error) {
+				return next(context.WithValue(ctx, key(name), name), abort)
+			}
+		}
+	}
+	pool.UseContext(with("outer"))
+	// Middleware added with Use keeps the context passed on by the outer ContextMiddleware.
+	pool.Use(func(next ErrWorkHandler) ErrWorkHandler { return next })
+	pool.UseContext(with("inner"))
+	ctx := context.WithValue(context.Background(), key("run"), "run")
+	assert.NoError(t, pool.RunContext(ctx))
+	assert.Equal(t, []any{"outer", "inner", "run", 0}, values)
+}
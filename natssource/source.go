@@ -0,0 +1,225 @@
+// Package natssource feeds messages from a NATS subscription or JetStream pull consumer to WorkPool workers. JetStream
+// messages are acknowledged when they are processed successfully and negatively acknowledged when processing fails.
+// They are either processed by the workers of a WorkPool, see Handler, or submitted to a TypedPool as Messages, see
+// Feed, which acknowledges them through workpool.Acker.
+//
+// It is a separate module so that the workpool package itself does not depend on the NATS client.
+package natssource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/algorand/workpool"
+	"github.com/nats-io/nats.go"
+)
+
+// Subscription is the part of *nats.Subscription used by Source.
+type Subscription interface {
+	NextMsgWithContext(ctx context.Context) (*nats.Msg, error)
+	Fetch(batch int, opts ...nats.PullOpt) ([]*nats.Msg, error)
+	Unsubscribe() error
+}
+
+// Message is a NATS message which implements workpool.Acker, so that a TypedPool acknowledges it. Core NATS messages
+// have nothing to acknowledge, Ack and Nack do nothing for them.
+type Message struct {
+	*nats.Msg
+}
+
+// Ack acknowledges a JetStream message.
+func (m Message) Ack() error {
+	if !m.jetStream() {
+		return nil
+	}
+	return m.Msg.Ack()
+}
+
+// Nack negatively acknowledges a JetStream message. It is redelivered if requeue is set, otherwise it is terminated so
+// that it is not delivered again.
+func (m Message) Nack(requeue bool) error {
+	switch {
+	case !m.jetStream():
+		return nil
+	case requeue:
+		return m.Msg.Nak()
+	default:
+		return m.Msg.Term()
+	}
+}
+
+// jetStream reports whether the message was delivered by JetStream, and has to be acknowledged.
+func (m Message) jetStream() bool {
+	_, err := m.Metadata()
+	return err == nil
+}
+
+// Source is a subscription whose messages are processed by the handler returned from Handler.
+type Source struct {
+	// MaxWait limits how long a fetch from a pull consumer waits to fill a batch before returning the messages it has.
+	// Zero uses the default wait of the JetStream context.
+	MaxWait time.Duration
+
+	// ErrorBackoff is how long a worker waits after failing to get a message, such as when the client reports a slow
+	// consumer, before trying again. Zero waits one second.
+	ErrorBackoff time.Duration
+
+	sub   Subscription
+	batch int
+
+	mu       sync.Mutex
+	buffered []*nats.Msg
+}
+
+// NewSource reads messages from a synchronous subscription, created with SubscribeSync or QueueSubscribeSync on either
+// a core connection or a JetStream context.
+func NewSource(sub Subscription) *Source {
+	return &Source{sub: sub}
+}
+
+// NewPullSource reads messages from a JetStream pull consumer, created with PullSubscribe, fetching up to batch
+// messages at a time. The fetched messages are shared between the workers.
+func NewPullSource(sub Subscription, batch int) *Source {
+	if batch < 1 {
+		batch = 1
+	}
+	return &Source{sub: sub, batch: batch}
+}
+
+// Handler creates a WorkHandler which calls fn for each message. JetStream messages are acknowledged if fn returns nil
+// and negatively acknowledged otherwise, so that they are redelivered; core NATS messages have nothing to acknowledge.
+// The context given to fn is cancelled when the pool is cancelled. Errors are reported to the pool, waiting for
+// ErrorBackoff after those getting a message, and the worker keeps going until the subscription or the connection is
+// closed or the pool is cancelled.
+func (s *Source) Handler(fn func(ctx context.Context, msg *nats.Msg) error) workpool.ErrWorkHandler {
+	return func(abort <-chan struct{}) (bool, error) {
+		ctx, cancel := workpool.AbortContext(abort)
+		defer cancel()
+
+		message, more, err := s.receive(ctx, abort)
+		if message.Msg == nil {
+			return more, err
+		}
+		if err := fn(ctx, message.Msg); err != nil {
+			if nakErr := message.Nack(true); nakErr != nil {
+				return true, fmt.Errorf("natssource: nak after %v: %w", err, nakErr)
+			}
+			return true, err
+		}
+		if err := message.Ack(); err != nil {
+			return true, fmt.Errorf("natssource: ack: %w", err)
+		}
+		return true, nil
+	}
+}
+
+// Feed creates a WorkHandler which passes each message to submit as a Message, usually the Submit method of a
+// TypedPool which then acknowledges it:
+//
+//	pool := workpool.NewTypedPool(4, process)
+//	pool.RequeueFailed = true
+//	pool.Start()
+//	feeder := workpool.NewWithError(1, source.Feed(pool.Submit))
+//
+// A message which cannot be submitted because the pool was closed is negatively acknowledged so that it is
+// redelivered, and the worker stops. Other errors are reported to the pool like those of Handler.
+func (s *Source) Feed(submit func(Message) error) workpool.ErrWorkHandler {
+	return func(abort <-chan struct{}) (bool, error) {
+		ctx, cancel := workpool.AbortContext(abort)
+		defer cancel()
+
+		message, more, err := s.receive(ctx, abort)
+		if message.Msg == nil {
+			return more, err
+		}
+		if err := submit(message); err != nil {
+			closed := errors.Is(err, workpool.ErrPoolClosed)
+			if nakErr := message.Nack(true); nakErr != nil {
+				return !closed, fmt.Errorf("natssource: nak after %v: %w", err, nakErr)
+			}
+			if closed {
+				return false, nil
+			}
+			return true, err
+		}
+		return true, nil
+	}
+}
+
+// receive waits for a message. Without a message it returns whether the handler should be called again, and the error
+// to report, waiting for ErrorBackoff after errors.
+func (s *Source) receive(ctx context.Context, abort <-chan struct{}) (Message, bool, error) {
+	msg, err := s.next(ctx)
+	switch {
+	case ctx.Err() != nil:
+		return Message{}, false, nil
+	case errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded):
+		// An empty fetch, try again.
+		return Message{}, true, nil
+	case errors.Is(err, nats.ErrBadSubscription) || errors.Is(err, nats.ErrConnectionClosed):
+		return Message{}, false, nil
+	case err != nil:
+		s.backoff(abort)
+		return Message{}, true, fmt.Errorf("natssource: next message: %w", err)
+	}
+	return Message{Msg: msg}, true, nil
+}
+
+// next returns a buffered message, or waits for more.
+func (s *Source) next(ctx context.Context) (*nats.Msg, error) {
+	if s.batch == 0 {
+		return s.sub.NextMsgWithContext(ctx)
+	}
+
+	s.mu.Lock()
+	if len(s.buffered) > 0 {
+		msg := s.buffered[0]
+		s.buffered = s.buffered[1:]
+		s.mu.Unlock()
+		return msg, nil
+	}
+	s.mu.Unlock()
+
+	if s.MaxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.MaxWait)
+		defer cancel()
+	}
+	msgs, err := s.sub.Fetch(s.batch, nats.Context(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, nats.ErrTimeout
+	}
+	s.mu.Lock()
+	s.buffered = append(s.buffered, msgs[1:]...)
+	s.mu.Unlock()
+	return msgs[0], nil
+}
+
+// backoff waits after failing to get a message.
+func (s *Source) backoff(abort <-chan struct{}) {
+	d := s.ErrorBackoff
+	if d <= 0 {
+		d = time.Second
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-abort:
+	}
+}
+
+// Close unsubscribes. Unacknowledged JetStream messages, including fetched messages which were not processed, are
+// redelivered once their ack wait expires. It is meant to be used as the Close function of the pool:
+//
+//	pool := workpool.NewWithError(4, source.Handler(process))
+//	pool.Close = source.Close
+func (s *Source) Close() {
+	s.sub.Unsubscribe()
+}
@@ -0,0 +1,202 @@
+package natssource
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/algorand/workpool"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runServer(t *testing.T) *nats.Conn {
+	s, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+	go s.Start()
+	require.True(t, s.ReadyForConnections(5*time.Second))
+	t.Cleanup(s.Shutdown)
+
+	nc, err := nats.Connect(s.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+	return nc
+}
+
+// received records the message bodies seen by a handler.
+type received struct {
+	mu     sync.Mutex
+	bodies []string
+}
+
+func (r *received) add(body string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bodies = append(r.bodies, body)
+}
+
+func (r *received) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.bodies)
+}
+
+func TestCoreSource(t *testing.T) {
+	nc := runServer(t)
+	sub, err := nc.SubscribeSync("jobs")
+	require.NoError(t, err)
+	source := NewSource(sub)
+
+	var got received
+	pool := workpool.NewWithError(2, source.Handler(func(ctx context.Context, msg *nats.Msg) error {
+		got.add(string(msg.Data))
+		return nil
+	}))
+	pool.Close = source.Close
+	pool.Start()
+
+	for _, body := range []string{"a", "b", "c"} {
+		require.NoError(t, nc.Publish("jobs", []byte(body)))
+	}
+	assert.Eventually(t, func() bool { return got.len() == 3 }, 5*time.Second, time.Millisecond)
+	pool.Cancel()
+	assert.NoError(t, pool.Wait())
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, got.bodies)
+	assert.False(t, sub.IsValid())
+}
+
+func TestPullSource(t *testing.T) {
+	nc := runServer(t)
+	js, err := nc.JetStream()
+	require.NoError(t, err)
+	_, err = js.AddStream(&nats.StreamConfig{Name: "JOBS", Subjects: []string{"jobs"}})
+	require.NoError(t, err)
+	sub, err := js.PullSubscribe("jobs", "workers", nats.AckWait(5*time.Second))
+	require.NoError(t, err)
+	source := NewPullSource(sub, 2)
+	source.MaxWait = 50 * time.Millisecond
+
+	var got received
+	var failed sync.Once
+	pool := workpool.NewWithError(2, source.Handler(func(ctx context.Context, msg *nats.Msg) error {
+		got.add(string(msg.Data))
+		if string(msg.Data) == "retry" {
+			var err error
+			failed.Do(func() { err = errors.New("first attempt") })
+			return err
+		}
+		return nil
+	}))
+	pool.Close = source.Close
+	pool.Start()
+
+	for _, body := range []string{"a", "retry", "b"} {
+		_, err := js.Publish("jobs", []byte(body))
+		require.NoError(t, err)
+	}
+	assert.Eventually(t, func() bool { return got.len() == 4 }, 5*time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool {
+		info, err := js.ConsumerInfo("JOBS", "workers")
+		return err == nil && info.NumAckPending == 0
+	}, 5*time.Second, time.Millisecond)
+	pool.Cancel()
+	assert.EqualError(t, pool.Wait(), "first attempt")
+	assert.ElementsMatch(t, []string{"a", "retry", "retry", "b"}, got.bodies)
+}
+
+// flakySubscription returns the errors and messages it is given, in order, and then reports a closed connection.
+type flakySubscription struct {
+	mu      sync.Mutex
+	results []any
+}
+
+func (s *flakySubscription) NextMsgWithContext(ctx context.Context) (*nats.Msg, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.results) == 0 {
+		return nil, nats.ErrConnectionClosed
+	}
+	result := s.results[0]
+	s.results = s.results[1:]
+	if err, ok := result.(error); ok {
+		return nil, err
+	}
+	return result.(*nats.Msg), nil
+}
+
+func (s *flakySubscription) Fetch(batch int, opts ...nats.PullOpt) ([]*nats.Msg, error) {
+	return nil, nats.ErrBadSubscription
+}
+
+func (s *flakySubscription) Unsubscribe() error { return nil }
+
+func TestSourceKeepsGoingAfterErrors(t *testing.T) {
+	sub := &flakySubscription{results: []any{nats.ErrSlowConsumer, &nats.Msg{Data: []byte("a")}}}
+	source := NewSource(sub)
+	source.ErrorBackoff = time.Millisecond
+
+	var got received
+	pool := workpool.NewWithError(1, source.Handler(func(ctx context.Context, msg *nats.Msg) error {
+		got.add(string(msg.Data))
+		return nil
+	}))
+	err := pool.Run()
+	assert.ErrorIs(t, err, nats.ErrSlowConsumer)
+	assert.Equal(t, []string{"a"}, got.bodies, "the worker keeps going after a slow consumer error")
+}
+
+func TestPullSourceFeed(t *testing.T) {
+	nc := runServer(t)
+	js, err := nc.JetStream()
+	require.NoError(t, err)
+	_, err = js.AddStream(&nats.StreamConfig{Name: "JOBS", Subjects: []string{"jobs"}})
+	require.NoError(t, err)
+	sub, err := js.PullSubscribe("jobs", "workers", nats.AckWait(100*time.Millisecond))
+	require.NoError(t, err)
+	source := NewPullSource(sub, 2)
+	source.MaxWait = 50 * time.Millisecond
+
+	var got received
+	pool := workpool.NewTypedPool(2, func(abort <-chan struct{}, message Message) (string, error) {
+		got.add(string(message.Data))
+		if string(message.Data) == "poison" {
+			return "", errors.New("poison message")
+		}
+		return string(message.Data), nil
+	})
+	pool.Start()
+	go func() {
+		for range pool.Results() {
+		}
+	}()
+	feeder := workpool.NewWithError(1, source.Feed(pool.Submit))
+	feeder.Close = source.Close
+	feeder.Start()
+
+	for _, body := range []string{"a", "poison", "b"} {
+		_, err := js.Publish("jobs", []byte(body))
+		require.NoError(t, err)
+	}
+	assert.Eventually(t, func() bool {
+		info, err := js.ConsumerInfo("JOBS", "workers")
+		return err == nil && info.NumAckPending == 0 && info.Delivered.Consumer == 3
+	}, 5*time.Second, time.Millisecond)
+	// The poison message was terminated by the pool, so it is not redelivered once its ack wait expires.
+	time.Sleep(200 * time.Millisecond)
+	feeder.Cancel()
+	assert.NoError(t, feeder.Wait())
+	pool.Finish()
+	assert.EqualError(t, pool.Wait(), "poison message")
+	assert.ElementsMatch(t, []string{"a", "poison", "b"}, got.bodies)
+}
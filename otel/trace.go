@@ -0,0 +1,67 @@
+// Package otel adds OpenTelemetry tracing to WorkPool handlers. Each handler call is recorded as a span, and the span's
+// context is given to the handler so that it can be propagated to downstream calls.
+//
+// It is a separate module so that the workpool package itself does not depend on OpenTelemetry.
+package otel
+
+import (
+	"context"
+
+	"github.com/algorand/workpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// FoundWorkKey is the span attribute recording the boolean result of a handler call.
+const FoundWorkKey = attribute.Key("workpool.found_work")
+
+// AbortedKey is the span attribute recording whether the pool was cancelled when the handler call returned.
+const AbortedKey = attribute.Key("workpool.aborted")
+
+// ContextHandler is a WorkHandler which also receives the context of the span created for the call.
+type ContextHandler func(ctx context.Context, abort <-chan struct{}) (bool, error)
+
+// Handler creates an ErrWorkHandler which starts a span named name for every call to handler. Spans are children of
+// any span in ctx.
+func Handler(ctx context.Context, tracer trace.Tracer, name string, handler ContextHandler) workpool.ErrWorkHandler {
+	return func(abort <-chan struct{}) (bool, error) {
+		return call(ctx, tracer, name, handler, abort)
+	}
+}
+
+// Instrument adds middleware to pool which records every call of its handler as a span named name, whatever kind of
+// handler the pool has. It must be called before the pool is run. Spans are children of any span in the context given
+// to RunContext, and a ContextWorkHandler is given the context of its span.
+func Instrument(pool *workpool.WorkPool, tracer trace.Tracer, name string) {
+	pool.UseContext(func(next workpool.CallHandler) workpool.CallHandler {
+		return func(ctx context.Context, abort <-chan struct{}) (bool, error) {
+			return call(ctx, tracer, name, ContextHandler(next), abort)
+		}
+	})
+}
+
+// call calls handler within a span named name, which is a child of any span in ctx.
+func call(ctx context.Context, tracer trace.Tracer, name string, handler ContextHandler, abort <-chan struct{}) (bool, error) {
+	spanCtx, span := tracer.Start(ctx, name)
+	defer span.End()
+
+	foundWork, err := handler(spanCtx, abort)
+	record(span, abort, foundWork, err)
+	return foundWork, err
+}
+
+// record sets the outcome of a handler call on span.
+func record(span trace.Span, abort <-chan struct{}, foundWork bool, err error) {
+	aborted := false
+	select {
+	case <-abort:
+		aborted = true
+	default:
+	}
+	span.SetAttributes(FoundWorkKey.Bool(foundWork), AbortedKey.Bool(aborted))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
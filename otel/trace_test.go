@@ -0,0 +1,99 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/algorand/workpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTracer() (trace.Tracer, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return provider.Tracer("test"), recorder
+}
+
+func TestHandler(t *testing.T) {
+	tracer, recorder := newTracer()
+	parentCtx, parent := tracer.Start(context.Background(), "parent")
+
+	calls := 0
+	handler := func(ctx context.Context, abort <-chan struct{}) (bool, error) {
+		calls++
+		assert.Equal(t, parent.SpanContext().TraceID(), trace.SpanContextFromContext(ctx).TraceID())
+		if calls == 1 {
+			return true, errors.New("failed")
+		}
+		return false, nil
+	}
+
+	pool := workpool.NewWithError(1, Handler(parentCtx, tracer, "work", handler))
+	assert.Error(t, pool.Run())
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	first, second := spans[0], spans[1]
+	assert.Equal(t, "work", first.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), first.Parent().SpanID())
+	assert.Equal(t, codes.Error, first.Status().Code)
+	assert.Contains(t, first.Attributes(), FoundWorkKey.Bool(true))
+	assert.Len(t, first.Events(), 1)
+
+	assert.Equal(t, codes.Unset, second.Status().Code)
+	assert.Equal(t, []attribute.KeyValue{FoundWorkKey.Bool(false), AbortedKey.Bool(false)}, second.Attributes())
+}
+
+func TestInstrument(t *testing.T) {
+	tracer, recorder := newTracer()
+
+	started := make(chan struct{})
+	worker := func(abort <-chan struct{}) bool {
+		close(started)
+		<-abort
+		return false
+	}
+	pool := workpool.New(1, worker)
+	Instrument(pool, tracer, "work")
+
+	go func() {
+		<-started
+		pool.Cancel()
+	}()
+	require.NoError(t, pool.Run())
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes(), AbortedKey.Bool(true))
+}
+
+func TestInstrumentContextHandler(t *testing.T) {
+	tracer, recorder := newTracer()
+	parentCtx, parent := tracer.Start(context.Background(), "parent")
+
+	var spanCtx trace.SpanContext
+	pool := workpool.NewWithContext(1, func(ctx context.Context) bool {
+		spanCtx = trace.SpanContextFromContext(ctx)
+		_, ok := workpool.WorkerID(ctx)
+		assert.True(t, ok, "the span context is derived from the call context")
+		return false
+	})
+	Instrument(pool, tracer, "work")
+	require.NoError(t, pool.RunContext(parentCtx))
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, parent.SpanContext().TraceID(), spanCtx.TraceID())
+	assert.Equal(t, spans[0].SpanContext().SpanID(), spanCtx.SpanID(), "the handler is given the span of its call")
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+}
@@ -0,0 +1,81 @@
+package workpool
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+// PanicPolicy decides what happens to a worker after its handler panics and the panic is recovered.
+type PanicPolicy int
+
+const (
+	// PanicRestart keeps the worker running, the handler is called again as if it had returned true once PanicBackoff
+	// has passed.
+	PanicRestart PanicPolicy = iota
+
+	// PanicFinish counts the worker as finished, as if the handler had returned false.
+	PanicFinish
+)
+
+// invoke calls the handler once, recovering from panics if the pool is configured to do so. Panicked is set if a panic
+// was recovered.
+func (p *WorkPool) invoke(handler ErrWorkHandler, abort <-chan struct{}) (foundWork, panicked bool, err error) {
+	if p.RecoverPanics || p.Supervisor != nil {
+		defer func() {
+			if r := recover(); r != nil {
+				stack := debug.Stack()
+				p.log(slog.LevelError, "handler panicked", "panic", r, "stack", string(stack))
+				if p.OnPanic != nil {
+					p.OnPanic(r, stack)
+				}
+				foundWork = p.PanicPolicy == PanicRestart
+				panicked = true
+				err = nil
+				if p.Supervisor != nil {
+					err = Fatal(fmt.Errorf("workpool: handler panicked: %v", r))
+				}
+			}
+		}()
+	}
+	foundWork, err = handler(abort)
+	return foundWork, false, err
+}
+
+// panicBackoff waits before a worker calls its handler again after it panicked, see PanicBackoff, and reports whether
+// it waited. Panics which the Supervisor handles, or after which the worker finishes, do not wait.
+func (p *WorkPool) panicBackoff(w *worker, panicked bool) bool {
+	if !panicked {
+		w.panics = 0
+		return false
+	}
+	if p.Supervisor != nil || p.PanicPolicy != PanicRestart {
+		return false
+	}
+	w.panics++
+
+	backoff := p.PanicBackoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+	maxBackoff := p.MaxPanicBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 30 * time.Second
+	}
+	for i := 1; i < w.panics && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+
+	timer := p.clock().NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+	case <-w.quit:
+	case <-p.ctx.Done():
+	}
+	return true
+}
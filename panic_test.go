@@ -0,0 +1,86 @@
+package workpool
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverPanicsRestart(t *testing.T) {
+	var calls int32
+	worker := func(abort <-chan struct{}) bool {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			panic("boom")
+		}
+		return false
+	}
+
+	var panics []any
+	pool := New(1, worker)
+	pool.RecoverPanics = true
+	pool.OnPanic = func(recovered any, stack []byte) {
+		assert.NotEmpty(t, stack)
+		panics = append(panics, recovered)
+	}
+
+	assert.NoError(t, pool.Run())
+	assert.Equal(t, int32(3), calls)
+	assert.Equal(t, []any{"boom", "boom"}, panics)
+}
+
+func TestRecoverPanicsBackoff(t *testing.T) {
+	var calls int32
+	worker := func(abort <-chan struct{}) bool {
+		if atomic.AddInt32(&calls, 1) <= 4 {
+			panic("boom")
+		}
+		return false
+	}
+
+	clock := &manualClock{now: time.Unix(1000, 0), timers: make(chan *manualTimer)}
+	pool := New(1, worker)
+	pool.Clock = clock
+	pool.RecoverPanics = true
+	pool.PanicBackoff = time.Second
+	pool.MaxPanicBackoff = 3 * time.Second
+	pool.Start()
+
+	// The wait doubles with every consecutive panic, up to MaxPanicBackoff.
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		timer := <-clock.timers
+		assert.Equal(t, want, timer.d)
+		timer.c <- clock.Now()
+	}
+	assert.NoError(t, pool.Wait())
+	assert.Equal(t, int32(5), calls)
+}
+
+func TestRecoverPanicsFinish(t *testing.T) {
+	var calls int32
+	worker := func(abort <-chan struct{}) bool {
+		atomic.AddInt32(&calls, 1)
+		panic("boom")
+	}
+
+	pool := New(3, worker)
+	pool.RecoverPanics = true
+	pool.PanicPolicy = PanicFinish
+
+	assert.NoError(t, pool.Run())
+	assert.Equal(t, int32(3), calls)
+}
+
+func TestPanicsNotRecoveredByDefault(t *testing.T) {
+	worker := func(abort <-chan struct{}) bool {
+		panic("boom")
+	}
+
+	pool := New(1, worker)
+	assert.PanicsWithValue(t, "boom", func() {
+		pool.invoke(func(abort <-chan struct{}) (bool, error) {
+			return pool.Handler(abort), nil
+		}, nil)
+	})
+}
@@ -0,0 +1,53 @@
+package workpool
+
+// Pause stops workers from calling the handler. Calls which are already in progress are allowed to finish, after
+// which the workers wait until Resume is called or the pool is cancelled.
+func (p *WorkPool) Pause() {
+	p.init()
+	p.pauseMu.Lock()
+	defer p.pauseMu.Unlock()
+	if !p.paused {
+		p.paused = true
+		p.resumed.Store(make(chan struct{}))
+		p.pausing.Store(true)
+	}
+}
+
+// Resume allows workers to call the handler again after Pause.
+func (p *WorkPool) Resume() {
+	p.init()
+	p.pauseMu.Lock()
+	defer p.pauseMu.Unlock()
+	if p.paused {
+		p.paused = false
+		p.pausing.Store(false)
+		close(p.resumed.Load().(chan struct{}))
+	}
+}
+
+// Paused returns true if the pool has been paused.
+func (p *WorkPool) Paused() bool {
+	p.init()
+	p.pauseMu.Lock()
+	defer p.pauseMu.Unlock()
+	return p.paused
+}
+
+// waitResumed blocks while the pool is paused. False is returned if the worker should exit instead.
+func (p *WorkPool) waitResumed(w *worker, abort <-chan struct{}) bool {
+	resumed := p.resumed.Load().(chan struct{})
+	select {
+	case <-resumed:
+		return true
+	default:
+	}
+
+	select {
+	case <-resumed:
+		return true
+	case <-w.quit:
+		return false
+	case <-abort:
+		return false
+	}
+}
@@ -0,0 +1,56 @@
+package workpool
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPauseResume(t *testing.T) {
+	var calls int32
+	done := make(chan struct{})
+	worker := func(abort <-chan struct{}) bool {
+		atomic.AddInt32(&calls, 1)
+		select {
+		case <-done:
+			return false
+		case <-time.After(time.Millisecond):
+			return true
+		}
+	}
+
+	pool := New(2, worker)
+	pool.Start()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) > 0 }, time.Second, time.Millisecond)
+
+	pool.Pause()
+	assert.True(t, pool.Paused())
+	// Wait for calls in progress to finish, after that no new calls are made.
+	time.Sleep(10 * time.Millisecond)
+	paused := atomic.LoadInt32(&calls)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, paused, atomic.LoadInt32(&calls))
+
+	pool.Resume()
+	assert.False(t, pool.Paused())
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) > paused }, time.Second, time.Millisecond)
+
+	close(done)
+	assert.NoError(t, pool.Wait())
+}
+
+func TestPauseCancel(t *testing.T) {
+	worker := func(abort <-chan struct{}) bool {
+		return true
+	}
+	pool := New(2, worker)
+	pool.Pause()
+	pool.Pause()
+	pool.Start()
+
+	pool.Cancel()
+	assert.NoError(t, pool.Wait())
+	assert.Equal(t, int64(0), pool.Stats().Invocations)
+}
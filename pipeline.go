@@ -0,0 +1,212 @@
+package workpool
+
+import (
+	"context"
+	"sync"
+)
+
+// Pipeline wires WorkPools together as stages connected by channels. Each stage reads from the output channel of the
+// previous one, and closes its own output channel once it has finished, which in turn finishes the next stage.
+//
+// Stages are added with functions such as Source, Stage and Sink, then Run starts all of them. If any stage fails, or
+// the pipeline is cancelled, every stage is aborted.
+//
+//	p := NewPipeline()
+//	numbers := Source(p, 1, 2, 3)
+//	squares := Stage(p, 4, numbers, square)
+//	Sink(p, 1, squares, print)
+//	err := p.Run()
+type Pipeline struct {
+	mu     sync.Mutex
+	pools  []*WorkPool
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewPipeline creates an empty Pipeline.
+func NewPipeline() *Pipeline {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Pipeline{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Source adds a stage which sends items to the returned channel.
+func Source[T any](p *Pipeline, items ...T) <-chan T {
+	out := make(chan T)
+	next := 0
+	p.add(&WorkPool{
+		Workers: 1,
+		Handler: func(abort <-chan struct{}) bool {
+			if next == len(items) {
+				return false
+			}
+			select {
+			case out <- items[next]:
+				next++
+				return true
+			case <-abort:
+				return false
+			}
+		},
+		Close: func() {
+			close(out)
+		},
+	})
+	return out
+}
+
+// Stage adds a stage which calls fn with numWorkers workers for each item read from in. Results are sent to the returned
+// channel. An error returned by fn cancels the pipeline.
+func Stage[In, Out any](p *Pipeline, numWorkers int, in <-chan In, fn TypedHandler[In, Out]) <-chan Out {
+	out := make(chan Out)
+	p.add(&WorkPool{
+		Workers: numWorkers,
+		ErrHandler: func(abort <-chan struct{}) (bool, error) {
+			item, ok := receive(abort, in)
+			if !ok {
+				return false, nil
+			}
+			result, err := fn(abort, item)
+			if err != nil {
+				p.Cancel()
+				return false, err
+			}
+			select {
+			case out <- result:
+				return true, nil
+			case <-abort:
+				return false, nil
+			}
+		},
+		Close: func() {
+			close(out)
+		},
+	})
+	return out
+}
+
+// OrderedStage is like Stage, but the results are sent in the order their items were read from in, so that it can be
+// used on endless streams which must keep their order. Results which are ready before those of earlier items are held
+// in a reorder buffer of at most window results. Once it is full, workers wait for the oldest pending item before
+// reading more, which in turn blocks the stages sending to in. A window of zero or less is numWorkers.
+func OrderedStage[In, Out any](p *Pipeline, numWorkers, window int, in <-chan In, fn TypedHandler[In, Out]) <-chan Out {
+	if window <= 0 {
+		window = max(numWorkers, 1)
+	}
+	out := make(chan Out)
+	r := newReorder[Out]()
+	// turn is held while reading an item, so that items are numbered in the order they are read.
+	turn := make(chan struct{}, 1)
+	var next uint64
+	p.add(&WorkPool{
+		Workers: numWorkers,
+		ErrHandler: func(abort <-chan struct{}) (bool, error) {
+			select {
+			case turn <- struct{}{}:
+			case <-abort:
+				return false, nil
+			}
+			item, ok := receive(abort, in)
+			seq := next
+			if ok {
+				next++
+			}
+			<-turn
+			if !ok {
+				return false, nil
+			}
+
+			result, err := fn(abort, item)
+			if err != nil {
+				p.Cancel()
+				return false, err
+			}
+			sent := r.add(seq, result, window, abort, func(result Out) bool {
+				select {
+				case out <- result:
+					return true
+				case <-abort:
+					return false
+				}
+			})
+			return sent, nil
+		},
+		Close: func() {
+			close(out)
+		},
+	})
+	return out
+}
+
+// Sink adds a final stage which calls fn with numWorkers workers for each item read from in. An error returned by fn
+// cancels the pipeline.
+func Sink[T any](p *Pipeline, numWorkers int, in <-chan T, fn func(abort <-chan struct{}, item T) error) {
+	p.add(&WorkPool{
+		Workers: numWorkers,
+		ErrHandler: func(abort <-chan struct{}) (bool, error) {
+			item, ok := receive(abort, in)
+			if !ok {
+				return false, nil
+			}
+			if err := fn(abort, item); err != nil {
+				p.Cancel()
+				return false, err
+			}
+			return true, nil
+		},
+	})
+}
+
+// Run starts every stage and blocks until they have all finished. The first error returned by a stage is returned.
+func (p *Pipeline) Run() error {
+	return p.RunContext(context.Background())
+}
+
+// RunContext is like Run, but the pipeline is also cancelled when ctx is done.
+func (p *Pipeline) RunContext(ctx context.Context) error {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			p.Cancel()
+		case <-stop:
+		}
+	}()
+
+	p.mu.Lock()
+	pools := p.pools
+	p.mu.Unlock()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(pools))
+	wg.Add(len(pools))
+	for i, pool := range pools {
+		go func(i int, pool *WorkPool) {
+			defer wg.Done()
+			errs[i] = pool.RunContext(p.ctx)
+		}(i, pool)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cancel aborts every stage of the pipeline.
+func (p *Pipeline) Cancel() {
+	p.cancel()
+}
+
+// add registers a stage.
+func (p *Pipeline) add(pool *WorkPool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pools = append(p.pools, pool)
+}
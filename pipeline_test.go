@@ -0,0 +1,165 @@
+package workpool
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipeline(t *testing.T) {
+	p := NewPipeline()
+	numbers := Source(p, 1, 2, 3, 4, 5)
+	squares := Stage(p, 3, numbers, square)
+	plusOne := Stage(p, 2, squares, func(abort <-chan struct{}, item int) (int, error) {
+		return item + 1, nil
+	})
+
+	var mu sync.Mutex
+	sum := 0
+	Sink(p, 2, plusOne, func(abort <-chan struct{}, item int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sum += item
+		return nil
+	})
+
+	assert.NoError(t, p.Run())
+	assert.Equal(t, 1+4+9+16+25+5, sum)
+}
+
+func TestPipelineErrorCancels(t *testing.T) {
+	p := NewPipeline()
+
+	// An endless source, only cancellation can stop it.
+	counter := make(chan int)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case counter <- i:
+			case <-time.After(time.Second):
+				return
+			}
+		}
+	}()
+	numbers := Stage(p, 2, (<-chan int)(counter), func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	})
+	Sink(p, 1, numbers, func(abort <-chan struct{}, item int) error {
+		if item == 10 {
+			return errors.New("ten")
+		}
+		return nil
+	})
+
+	assert.EqualError(t, p.Run(), "ten")
+}
+
+func TestPipelineCancel(t *testing.T) {
+	p := NewPipeline()
+	numbers := Source(p, 1, 2, 3)
+	started := make(chan struct{})
+	var once sync.Once
+	Sink(p, 1, numbers, func(abort <-chan struct{}, item int) error {
+		once.Do(func() { close(started) })
+		<-abort
+		return nil
+	})
+
+	go func() {
+		<-started
+		p.Cancel()
+	}()
+	assert.NoError(t, p.Run())
+}
+
+func TestOrderedStage(t *testing.T) {
+	p := NewPipeline()
+	items := make([]int, 50)
+	for i := range items {
+		items[i] = i
+	}
+	numbers := Source(p, items...)
+	doubled := OrderedStage(p, 4, 3, numbers, func(abort <-chan struct{}, item int) (int, error) {
+		// Make early items slower, so that later ones finish first.
+		time.Sleep(time.Duration(item%4) * 100 * time.Microsecond)
+		return item * 2, nil
+	})
+
+	var got []int
+	Sink(p, 1, doubled, func(abort <-chan struct{}, item int) error {
+		got = append(got, item)
+		return nil
+	})
+
+	assert.NoError(t, p.Run())
+	want := make([]int, len(items))
+	for i := range want {
+		want[i] = i * 2
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestOrderedStageWindow(t *testing.T) {
+	p := NewPipeline()
+
+	// The first item is held until the test releases it, the others finish straight away.
+	release := make(chan struct{})
+	in := make(chan int)
+	ordered := OrderedStage(p, 4, 2, (<-chan int)(in), func(abort <-chan struct{}, item int) (int, error) {
+		if item == 0 {
+			<-release
+		}
+		return item, nil
+	})
+	var got []int
+	Sink(p, 1, ordered, func(abort <-chan struct{}, item int) error {
+		got = append(got, item)
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Run()
+	}()
+	sent := 0
+	blocked := false
+	for !blocked {
+		select {
+		case in <- sent:
+			sent++
+		case <-time.After(20 * time.Millisecond):
+			blocked = true
+		}
+	}
+	// Item 0 is held and item 1 is buffered, the other workers wait for room in the window with items 2 to 4, so no
+	// more items are read.
+	assert.Equal(t, 5, sent)
+
+	close(release)
+	close(in)
+	assert.NoError(t, <-done)
+	want := make([]int, sent)
+	for i := range want {
+		want[i] = i
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestOrderedStageErrorCancels(t *testing.T) {
+	p := NewPipeline()
+	numbers := Source(p, 1, 2, 3, 4, 5)
+	ordered := OrderedStage(p, 2, 0, numbers, func(abort <-chan struct{}, item int) (int, error) {
+		if item == 3 {
+			return 0, errors.New("three")
+		}
+		return item, nil
+	})
+	Sink(p, 1, ordered, func(abort <-chan struct{}, item int) error {
+		return nil
+	})
+
+	assert.EqualError(t, p.Run(), "three")
+}
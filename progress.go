@@ -0,0 +1,142 @@
+package workpool
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Progress is a snapshot of how far the pool has got, see WorkPool.Progress.
+type Progress struct {
+	// Done is the number of completed tasks. For a TypedPool a task is a submitted item, otherwise it is a handler
+	// call which found work.
+	Done int64
+
+	// Total is the number of tasks expected, or zero if it is not known. A TypedPool counts the submitted items,
+	// otherwise it is set with SetTotal.
+	Total int64
+
+	// Throughput is the rate tasks were completed at, in tasks per second, over the last ThroughputWindow.
+	Throughput float64
+
+	// ETA is when the remaining tasks are expected to be done at the current Throughput. It is zero if Total is not
+	// known, no tasks were completed recently, or the pool is not running.
+	ETA time.Time
+}
+
+// progressSample is the number of completed tasks at a point in time.
+type progressSample struct {
+	at   time.Time
+	done int64
+}
+
+// throughput keeps the recent progress samples used to estimate the rate of completion.
+type throughput struct {
+	mu      sync.Mutex
+	samples []progressSample
+}
+
+// rate records done at now and returns the rate since the oldest sample in window. With no earlier sample the rate is
+// measured from start.
+func (t *throughput) rate(now, start time.Time, done int64, window time.Duration) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// Keep the newest sample older than the window, so that the rate always covers the whole window.
+	cut := 0
+	for cut+1 < len(t.samples) && now.Sub(t.samples[cut+1].at) >= window {
+		cut++
+	}
+	t.samples = append(t.samples[cut:], progressSample{at: now, done: done})
+
+	from := progressSample{at: start}
+	if len(t.samples) > 1 {
+		from = t.samples[0]
+	}
+	elapsed := now.Sub(from.at).Seconds()
+	if elapsed <= 0 || start.IsZero() {
+		return 0
+	}
+	return float64(done-from.done) / elapsed
+}
+
+// Progress returns how many tasks have been completed, how many are expected, and an estimate of when they will be
+// done. It is safe to call at any time.
+func (p *WorkPool) Progress() Progress {
+	p.init()
+	progress := Progress{
+		Done:  atomic.LoadInt64(&p.counters.done),
+		Total: atomic.LoadInt64(&p.counters.total),
+	}
+
+	p.mu.Lock()
+	running, started := p.running, p.started
+	p.mu.Unlock()
+	if !running {
+		return progress
+	}
+	window := p.ThroughputWindow
+	if window <= 0 {
+		window = 10 * time.Second
+	}
+	now := p.clock().Now()
+	progress.Throughput = p.counters.throughput.rate(now, started, progress.Done, window)
+	if remaining := progress.Total - progress.Done; remaining > 0 && progress.Throughput > 0 {
+		progress.ETA = now.Add(time.Duration(float64(remaining) / progress.Throughput * float64(time.Second)))
+	}
+	return progress
+}
+
+// SetTotal sets the number of tasks the pool is expected to complete, for pools which are not fed by Submit. It may
+// be changed while the pool is running.
+func (p *WorkPool) SetTotal(total int64) {
+	p.init()
+	atomic.StoreInt64(&p.counters.total, total)
+}
+
+// addTotal counts n more expected tasks.
+func (p *WorkPool) addTotal(n int64) {
+	atomic.AddInt64(&p.counters.total, n)
+}
+
+// completeTask counts a completed task.
+func (p *WorkPool) completeTask() {
+	atomic.AddInt64(&p.counters.done, 1)
+}
+
+// startProgress starts calling OnProgress every ProgressInterval. It returns a function which stops the reports, after
+// making a final one so that the last report shows the finished state. The caller must hold p.mu.
+func (p *WorkPool) startProgress() (stop func()) {
+	if p.OnProgress == nil {
+		return func() {}
+	}
+	interval := p.ProgressInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	report := func() {
+		progress := p.Progress()
+		p.OnProgress(progress.Done, progress.Total)
+	}
+	quit := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		clock := p.clock()
+		for {
+			timer := clock.NewTimer(interval)
+			select {
+			case <-timer.C():
+				report()
+			case <-quit:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+	return func() {
+		close(quit)
+		<-stopped
+		report()
+	}
+}
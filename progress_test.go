@@ -0,0 +1,113 @@
+package workpool
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressWorkPool(t *testing.T) {
+	remaining := int64(20)
+	var mu sync.Mutex
+	var reports []Progress
+	pool := New(2, func(abort <-chan struct{}) bool {
+		time.Sleep(time.Millisecond)
+		return atomic.AddInt64(&remaining, -1) >= 0
+	})
+	pool.SetTotal(20)
+	pool.ProgressInterval = 2 * time.Millisecond
+	pool.OnProgress = func(done, total int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, Progress{Done: done, Total: total})
+	}
+
+	assert.NoError(t, pool.Run())
+	assert.Equal(t, Progress{Done: 20, Total: 20}, pool.Progress())
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Greater(t, len(reports), 1)
+	assert.Equal(t, Progress{Done: 20, Total: 20}, reports[len(reports)-1], "the last report is made as the pool finishes")
+	for i := 1; i < len(reports); i++ {
+		assert.LessOrEqual(t, reports[i-1].Done, reports[i].Done)
+	}
+}
+
+func TestProgressTypedPool(t *testing.T) {
+	pool := NewTypedPool(2, func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	})
+	var last atomic.Value
+	pool.OnProgress = func(done, total int64) {
+		last.Store(Progress{Done: done, Total: total})
+	}
+	pool.Start()
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, pool.Submit(i))
+	}
+	assert.True(t, pool.TrySubmit(10))
+	assert.NoError(t, pool.SubmitAfter(time.Millisecond, 11))
+	assert.Equal(t, int64(12), pool.Progress().Total)
+
+	pool.Finish()
+	for range pool.Results() {
+	}
+	assert.NoError(t, pool.Wait())
+	assert.Equal(t, Progress{Done: 12, Total: 12}, pool.Progress())
+	assert.Equal(t, Progress{Done: 12, Total: 12}, last.Load())
+}
+
+func TestProgressUnknownTotal(t *testing.T) {
+	calls := 0
+	pool := New(1, func(abort <-chan struct{}) bool {
+		calls++
+		return calls <= 3
+	})
+	assert.NoError(t, pool.Run())
+	assert.Equal(t, Progress{Done: 3}, pool.Progress())
+}
+
+func TestThroughputRate(t *testing.T) {
+	start := time.Unix(1000, 0)
+	var tp throughput
+	window := 10 * time.Second
+
+	// With no earlier sample the rate is measured from the start.
+	assert.Equal(t, 5.0, tp.rate(start.Add(2*time.Second), start, 10, window))
+	assert.Equal(t, 10.0, tp.rate(start.Add(4*time.Second), start, 30, window))
+
+	// Old samples drop out of the window, apart from the newest one before it.
+	tp.rate(start.Add(14*time.Second), start, 40, window)
+	assert.InDelta(t, 20.0/12, tp.rate(start.Add(16*time.Second), start, 50, window), 1e-9)
+	assert.Len(t, tp.samples, 3)
+}
+
+func TestProgressETA(t *testing.T) {
+	release := make(chan struct{})
+	remaining := int64(1000)
+	pool := New(2, func(abort <-chan struct{}) bool {
+		if atomic.AddInt64(&remaining, -1) < 990 {
+			select {
+			case <-release:
+			case <-abort:
+			}
+			return false
+		}
+		time.Sleep(time.Millisecond)
+		return true
+	})
+	pool.SetTotal(1000)
+	pool.Start()
+
+	assert.Eventually(t, func() bool { return pool.Progress().Done >= 10 }, time.Second, time.Millisecond)
+	progress := pool.Progress()
+	assert.Greater(t, progress.Throughput, 0.0)
+	assert.True(t, progress.ETA.After(time.Now()))
+
+	close(release)
+	assert.NoError(t, pool.Wait())
+	assert.Zero(t, pool.Progress().ETA, "there is no estimate once the pool has finished")
+}
@@ -0,0 +1,95 @@
+// Package promexport exports WorkPool statistics as Prometheus metrics.
+//
+// It is a separate module so that the workpool package itself does not depend on the Prometheus client.
+package promexport
+
+import (
+	"time"
+
+	"github.com/algorand/workpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector for a single WorkPool. All metrics carry a "pool" label with the name given to
+// NewCollector, so several pools can be registered with the same registry.
+type Collector struct {
+	pool *workpool.WorkPool
+
+	workers   *prometheus.Desc
+	tasks     *prometheus.Desc
+	finished  *prometheus.Desc
+	aborted   prometheus.Counter
+	durations prometheus.Histogram
+}
+
+// NewCollector creates a Collector for pool. Middleware is added to the pool to observe task durations and aborted
+// tasks, whatever kind of handler it has, so this must be called before the pool is run.
+func NewCollector(name string, pool *workpool.WorkPool) *Collector {
+	labels := prometheus.Labels{"pool": name}
+	c := &Collector{
+		pool: pool,
+		workers: prometheus.NewDesc("workpool_workers",
+			"Number of running workers.", nil, labels),
+		tasks: prometheus.NewDesc("workpool_tasks_total",
+			"Total number of handler calls.", nil, labels),
+		finished: prometheus.NewDesc("workpool_tasks_finished_total",
+			"Number of handler calls which reported that there was no more work.", nil, labels),
+		aborted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "workpool_tasks_aborted_total",
+			Help:        "Number of handler calls which returned after they were aborted, by cancellation or a timeout.",
+			ConstLabels: labels,
+		}),
+		durations: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:        "workpool_task_duration_seconds",
+			Help:        "Duration of handler calls.",
+			ConstLabels: labels,
+			Buckets:     prometheus.DefBuckets,
+		}),
+	}
+	pool.Use(c.observe)
+	return c
+}
+
+// Register creates a Collector for pool and registers it with reg.
+func Register(reg prometheus.Registerer, name string, pool *workpool.WorkPool) (*Collector, error) {
+	c := NewCollector(name, pool)
+	if err := reg.Register(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.workers
+	ch <- c.tasks
+	ch <- c.finished
+	c.aborted.Describe(ch)
+	c.durations.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.pool.Stats()
+	ch <- prometheus.MustNewConstMetric(c.workers, prometheus.GaugeValue, float64(stats.ActiveWorkers))
+	ch <- prometheus.MustNewConstMetric(c.tasks, prometheus.CounterValue, float64(stats.Invocations))
+	ch <- prometheus.MustNewConstMetric(c.finished, prometheus.CounterValue, float64(stats.Finished))
+	c.aborted.Collect(ch)
+	c.durations.Collect(ch)
+}
+
+// observe is the middleware which observes the duration of each call, and counts the calls which were aborted.
+func (c *Collector) observe(next workpool.ErrWorkHandler) workpool.ErrWorkHandler {
+	return func(abort <-chan struct{}) (bool, error) {
+		start := time.Now()
+		defer func() {
+			c.durations.Observe(time.Since(start).Seconds())
+			select {
+			case <-abort:
+				c.aborted.Inc()
+			default:
+			}
+		}()
+		return next(abort)
+	}
+}
@@ -0,0 +1,109 @@
+package promexport
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/algorand/workpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	numInputs := 5
+	inputs := make(chan int, numInputs)
+	for i := 0; i < numInputs; i++ {
+		inputs <- i
+	}
+	close(inputs)
+
+	worker := func(abort <-chan struct{}) bool {
+		for range inputs {
+			return true
+		}
+		return false
+	}
+	pool := workpool.New(2, worker)
+
+	reg := prometheus.NewPedanticRegistry()
+	c, err := Register(reg, "squares", pool)
+	require.NoError(t, err)
+	require.NoError(t, pool.Run())
+
+	expected := `
+# HELP workpool_tasks_aborted_total Number of handler calls which returned after they were aborted, by cancellation or a timeout.
+# TYPE workpool_tasks_aborted_total counter
+workpool_tasks_aborted_total{pool="squares"} 0
+# HELP workpool_tasks_finished_total Number of handler calls which reported that there was no more work.
+# TYPE workpool_tasks_finished_total counter
+workpool_tasks_finished_total{pool="squares"} 2
+# HELP workpool_tasks_total Total number of handler calls.
+# TYPE workpool_tasks_total counter
+workpool_tasks_total{pool="squares"} 7
+# HELP workpool_workers Number of running workers.
+# TYPE workpool_workers gauge
+workpool_workers{pool="squares"} 0
+`
+	assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"workpool_tasks_aborted_total", "workpool_tasks_finished_total", "workpool_tasks_total", "workpool_workers"))
+
+	// Every handler call is observed by the histogram.
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	var samples uint64
+	for _, family := range families {
+		if family.GetName() == "workpool_task_duration_seconds" {
+			samples = family.GetMetric()[0].GetHistogram().GetSampleCount()
+		}
+	}
+	assert.Equal(t, uint64(7), samples)
+}
+
+func TestCollectorOtherHandlers(t *testing.T) {
+	started := make(chan struct{})
+	var first sync.Once
+	pool := workpool.NewIndexed(2, func(workerID int, abort <-chan struct{}) bool {
+		blocked := false
+		first.Do(func() { blocked = true })
+		if blocked {
+			close(started)
+			<-abort
+		}
+		return false
+	})
+	reg := prometheus.NewPedanticRegistry()
+	c, err := Register(reg, "indexed", pool)
+	require.NoError(t, err)
+	go func() {
+		<-started
+		pool.Cancel()
+	}()
+	require.NoError(t, pool.Run())
+
+	expected := `
+# HELP workpool_tasks_aborted_total Number of handler calls which returned after they were aborted, by cancellation or a timeout.
+# TYPE workpool_tasks_aborted_total counter
+workpool_tasks_aborted_total{pool="indexed"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expected), "workpool_tasks_aborted_total"))
+}
+
+func TestMultiplePools(t *testing.T) {
+	worker := func(abort <-chan struct{}) bool {
+		return false
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	_, err := Register(reg, "a", workpool.New(1, worker))
+	require.NoError(t, err)
+	_, err = Register(reg, "b", workpool.NewWithError(1, func(abort <-chan struct{}) (bool, error) {
+		return false, nil
+	}))
+	require.NoError(t, err)
+
+	_, err = reg.Gather()
+	assert.NoError(t, err)
+}
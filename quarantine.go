@@ -0,0 +1,172 @@
+package workpool
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrQuarantined matches the QuarantineError of items quarantined because of MaxFailures, with errors.Is.
+var ErrQuarantined = errors.New("workpool: item quarantined")
+
+// QuarantineError is the error of an item quarantined because of MaxFailures. It is given to DeadLetters along with
+// the item, and is the error of the result of the item.
+type QuarantineError struct {
+	// Key identifies the deliveries of the item, see FailureKey.
+	Key string
+
+	// Failures is the number of deliveries of the item which failed, Panics how many of them panicked.
+	Failures int
+	Panics   int
+
+	// First and Last are when the first and the last failure happened.
+	First time.Time
+	Last  time.Time
+
+	// Err is the error of the last failure, ErrPanicked if it panicked.
+	Err error
+}
+
+func (e *QuarantineError) Error() string {
+	return fmt.Sprintf("workpool: item %s quarantined after %d failures, %d panics: %v", e.Key, e.Failures, e.Panics, e.Err)
+}
+
+func (e *QuarantineError) Is(target error) bool {
+	return target == ErrQuarantined
+}
+
+func (e *QuarantineError) Unwrap() error {
+	return e.Err
+}
+
+// defaultQuarantineSize is QuarantineSize when it is not set.
+const defaultQuarantineSize = 10000
+
+// failures counts the failed deliveries of the items of a TypedPool by key, for MaxFailures.
+type failures struct {
+	mu sync.Mutex
+
+	// counts holds the failures of the keys which failed and have not succeeded since. quarantined holds the keys which
+	// reached MaxFailures. Both are bounded by QuarantineSize.
+	counts      records
+	quarantined records
+}
+
+// records holds QuarantineErrors by key, forgetting the least recently used ones once there are too many.
+type records struct {
+	keys  map[string]*list.Element
+	order *list.List
+}
+
+// get returns the record of key, and marks it as used.
+func (r *records) get(key string) *QuarantineError {
+	element, ok := r.keys[key]
+	if !ok {
+		return nil
+	}
+	r.order.MoveToBack(element)
+	return element.Value.(*QuarantineError)
+}
+
+// put adds a record, forgetting the least recently used one if there are more than size.
+func (r *records) put(record *QuarantineError, size int) {
+	if r.keys == nil {
+		r.keys = make(map[string]*list.Element)
+		r.order = list.New()
+	}
+	r.keys[record.Key] = r.order.PushBack(record)
+	if r.order.Len() > size {
+		oldest := r.order.Front()
+		delete(r.keys, oldest.Value.(*QuarantineError).Key)
+		r.order.Remove(oldest)
+	}
+}
+
+// remove forgets the record of key.
+func (r *records) remove(key string) {
+	if element, ok := r.keys[key]; ok {
+		delete(r.keys, key)
+		r.order.Remove(element)
+	}
+}
+
+// quarantineSize returns the bound of the failure records, see QuarantineSize.
+func (p *TypedPool[In, Out]) quarantineSize() int {
+	if p.QuarantineSize > 0 {
+		return p.QuarantineSize
+	}
+	return defaultQuarantineSize
+}
+
+// failureKey returns the key which identifies the deliveries of an item, and false if failures are not tracked.
+func (p *TypedPool[In, Out]) failureKey(item In) (string, bool) {
+	switch {
+	case p.MaxFailures <= 0:
+		return "", false
+	case p.FailureKey != nil:
+		return p.FailureKey(item), true
+	case p.Key != nil:
+		return p.Key(item), true
+	default:
+		return "", false
+	}
+}
+
+// recordFailure counts a failed delivery of an item. Once the item reaches MaxFailures it is quarantined, and the
+// QuarantineError is returned instead of err along with true.
+func (p *TypedPool[In, Out]) recordFailure(item In, err error) (error, bool) {
+	key, ok := p.failureKey(item)
+	if !ok {
+		return err, false
+	}
+	now := p.clock().Now()
+
+	f := &p.failures
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	record := f.counts.get(key)
+	if record == nil {
+		record = &QuarantineError{Key: key, First: now}
+		f.counts.put(record, p.quarantineSize())
+	}
+	record.Failures++
+	if errors.Is(err, ErrPanicked) {
+		record.Panics++
+	}
+	record.Last = now
+	record.Err = err
+	if record.Failures < p.MaxFailures {
+		return err, false
+	}
+
+	f.counts.remove(key)
+	f.quarantined.put(record, p.quarantineSize())
+	return record, true
+}
+
+// forgetFailures resets the count of failures of an item which was processed.
+func (p *TypedPool[In, Out]) forgetFailures(item In) {
+	key, ok := p.failureKey(item)
+	if !ok {
+		return
+	}
+	p.failures.mu.Lock()
+	p.failures.counts.remove(key)
+	p.failures.mu.Unlock()
+}
+
+// quarantined returns the QuarantineError of an item which was already quarantined, or nil.
+func (p *TypedPool[In, Out]) quarantined(item In) error {
+	key, ok := p.failureKey(item)
+	if !ok {
+		return nil
+	}
+	p.failures.mu.Lock()
+	defer p.failures.mu.Unlock()
+	if record := p.failures.quarantined.get(key); record != nil {
+		return record
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package workpool
+
+import (
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func messageID(m message) string {
+	return strconv.Itoa(m.id)
+}
+
+func TestQuarantine(t *testing.T) {
+	msgs, acks := messages(2)
+	var calls atomic.Int32
+	dead := make(chan DeadLetter[message], 10)
+	pool := NewTypedPool(1, func(abort <-chan struct{}, m message) (int, error) {
+		calls.Add(1)
+		return failOddMessage(abort, m)
+	})
+	pool.MaxFailures = 3
+	pool.FailureKey = messageID
+	pool.RequeueFailed = true
+	pool.DeadLetters = DeadLetterChan[message](dead)
+	pool.Start()
+
+	// The source delivers the failing message again every time it is nacked.
+	var errs []error
+	for i := 0; i < 4; i++ {
+		require.NoError(t, pool.Submit(msgs[1]))
+		errs = append(errs, (<-pool.Results()).Err)
+	}
+	require.NoError(t, pool.Submit(msgs[0]))
+	assert.NoError(t, (<-pool.Results()).Err)
+	pool.Finish()
+	assert.Error(t, pool.Wait())
+	close(dead)
+
+	assert.EqualError(t, errs[0], "odd")
+	assert.EqualError(t, errs[1], "odd")
+	var quarantine *QuarantineError
+	require.ErrorAs(t, errs[2], &quarantine)
+	assert.True(t, errors.Is(errs[2], ErrQuarantined))
+	assert.Equal(t, "1", quarantine.Key)
+	assert.Equal(t, 3, quarantine.Failures)
+	assert.Equal(t, 0, quarantine.Panics)
+	assert.EqualError(t, quarantine.Err, "odd")
+	assert.Same(t, quarantine, errs[3], "a quarantined message is not processed again")
+	assert.Equal(t, int32(4), calls.Load())
+
+	// Only the quarantine is final.
+	var letters []DeadLetter[message]
+	for letter := range dead {
+		letters = append(letters, letter)
+	}
+	require.Len(t, letters, 1)
+	assert.Equal(t, 1, letters[0].Item.id)
+	assert.Same(t, quarantine, letters[0].Err)
+	assert.Equal(t, map[int]string{0: "ack", 1: "requeuerequeuenacknack"}, acks)
+}
+
+func TestQuarantineSuccessResets(t *testing.T) {
+	var calls int
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		calls++
+		if calls == 3 {
+			return item, nil
+		}
+		return 0, errors.New("flaky")
+	})
+	pool.MaxFailures = 3
+	pool.Key = func(item int) string { return "item" }
+	pool.Start()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, pool.Submit(1))
+		result := <-pool.Results()
+		assert.False(t, errors.Is(result.Err, ErrQuarantined))
+	}
+	pool.Finish()
+	assert.Error(t, pool.Wait())
+}
+
+func TestQuarantinePanics(t *testing.T) {
+	msgs, acks := messages(1)
+	dead := make(chan DeadLetter[message], 1)
+	pool := NewTypedPool(1, func(abort <-chan struct{}, m message) (int, error) {
+		panic("boom")
+	})
+	pool.RecoverPanics = true
+	pool.MaxFailures = 2
+	pool.FailureKey = messageID
+	pool.RequeueFailed = true
+	pool.DeadLetters = DeadLetterChan[message](dead)
+	pool.Start()
+	require.NoError(t, pool.Submit(msgs[0]))
+	require.NoError(t, pool.Submit(msgs[0]))
+	pool.Finish()
+	for range pool.Results() {
+	}
+	assert.NoError(t, pool.Wait())
+
+	letter := <-dead
+	var quarantine *QuarantineError
+	require.ErrorAs(t, letter.Err, &quarantine)
+	assert.Equal(t, 2, quarantine.Failures)
+	assert.Equal(t, 2, quarantine.Panics)
+	assert.Same(t, ErrPanicked, quarantine.Err)
+	assert.Equal(t, map[int]string{0: "requeuenack"}, acks)
+}
+
+func TestQuarantineNoKey(t *testing.T) {
+	dead := make(chan DeadLetter[int], 10)
+	pool := NewTypedPool(1, failOdd)
+	pool.MaxFailures = 1
+	pool.DeadLetters = DeadLetterChan[int](dead)
+	pool.Start()
+	require.NoError(t, pool.Submit(1))
+	require.NoError(t, pool.Submit(1))
+	pool.Finish()
+	for range pool.Results() {
+	}
+	assert.Error(t, pool.Wait())
+	close(dead)
+
+	// Without a key the failures are not tracked, so every failure is final.
+	count := 0
+	for letter := range dead {
+		assert.EqualError(t, letter.Err, "odd")
+		count++
+	}
+	assert.Equal(t, 2, count)
+}
+
+func TestQuarantineSize(t *testing.T) {
+	var calls atomic.Int32
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		calls.Add(1)
+		return failOdd(abort, item)
+	})
+	pool.MaxFailures = 1
+	pool.QuarantineSize = 1
+	pool.Key = strconv.Itoa
+	pool.Start()
+
+	var errs []error
+	for _, item := range []int{1, 1, 3, 1} {
+		require.NoError(t, pool.Submit(item))
+		errs = append(errs, (<-pool.Results()).Err)
+	}
+	pool.Finish()
+	assert.Error(t, pool.Wait())
+
+	for _, err := range errs {
+		assert.ErrorIs(t, err, ErrQuarantined)
+	}
+	assert.Same(t, errs[0], errs[1], "item 1 is still quarantined")
+	assert.NotSame(t, errs[0], errs[3], "quarantining item 3 made the pool forget item 1")
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Len(t, pool.failures.quarantined.keys, 1)
+}
@@ -0,0 +1,408 @@
+package workpool
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// queue is a priority queue which can be waited on with an abort signal. Items with a higher priority are
+// removed first, items with the same priority are removed in the order they were added.
+type queue[T any] struct {
+	// rank, when set, gives the rank an item is ordered by instead of its priority. It is called with the queue
+	// locked as the item is added, and must not change the order of the items already queued.
+	rank func(priority int) float64
+
+	// fair, when set, shares out the items with the same priority between their tenants.
+	fair *fairShare
+
+	// metrics, when set, returns the MetricsSink the queue reports its depth to.
+	metrics func() MetricsSink
+
+	mu         sync.Mutex
+	items      entries[T]
+	seq        uint64
+	dispatched uint64
+	closed     bool
+	counts     queueCounts
+
+	// ready is signalled when an item is added, space when one is removed, and done is closed when the queue is
+	// closed.
+	ready chan struct{}
+	space chan struct{}
+	done  chan struct{}
+}
+
+// entry is a queued item with its ordering information.
+type entry[T any] struct {
+	item     T
+	priority int
+	rank     float64
+	seq      uint64
+
+	// tenant is the tenant of an item submitted with SubmitTenant, and tag its place in the fair share.
+	tenant string
+	tag    float64
+
+	// order is set when the entry is removed from the queue.
+	order uint64
+
+	// job is the handle of an item submitted with SubmitJob.
+	job *JobHandle
+
+	// callback is the func(Out, error) of an item submitted with SubmitWithCallback.
+	callback any
+
+	// ctx is the context of an item submitted with SubmitContext.
+	ctx context.Context
+}
+
+// queueCounts track the items going through a queue, for Stats.
+type queueCounts struct {
+	max      int
+	enqueued int64
+	dequeued int64
+	blocked  time.Duration
+}
+
+func newQueue[T any]() *queue[T] {
+	return &queue[T]{
+		ready: make(chan struct{}, 1),
+		space: make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+}
+
+// push adds an item to the queue, blocking while the queue holds limit or more items. A limit of zero or less means
+// that the queue is unbounded. False is returned if the queue is closed, or abort is closed first.
+func (q *queue[T]) push(item T, priority, limit int, abort <-chan struct{}) bool {
+	return q.pushEntry(entry[T]{item: item, priority: priority}, limit, abort)
+}
+
+// pushEntry is like push, but adds a whole entry. Its sequence number is set by the queue.
+func (q *queue[T]) pushEntry(e entry[T], limit int, abort <-chan struct{}) bool {
+	var blockedSince time.Time
+	defer func() {
+		if !blockedSince.IsZero() {
+			q.recordBlocked(time.Since(blockedSince))
+		}
+	}()
+	for {
+		added, closed := q.tryAdd(e, limit)
+		if added || closed {
+			return added
+		}
+		if blockedSince.IsZero() {
+			blockedSince = time.Now()
+		}
+
+		select {
+		case <-q.space:
+		case <-q.done:
+		case <-abort:
+			return false
+		}
+	}
+}
+
+// tryPush is like push, but returns false instead of blocking when the queue is full.
+func (q *queue[T]) tryPush(item T, priority, limit int) bool {
+	added, _ := q.tryAdd(entry[T]{item: item, priority: priority}, limit)
+	return added
+}
+
+// tryAdd adds an item if the queue is open and has room for it.
+func (q *queue[T]) tryAdd(e entry[T], limit int) (added, closed bool) {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return false, true
+	}
+	if limit > 0 && q.items.len() >= limit {
+		q.mu.Unlock()
+		return false, false
+	}
+	e.seq = q.seq
+	e.rank = float64(e.priority)
+	if q.rank != nil {
+		e.rank = q.rank(e.priority)
+	}
+	if q.fair != nil {
+		e.tag = q.fair.tag(e.tenant)
+	}
+	q.items.push(e)
+	q.seq++
+	depth := q.items.len()
+	q.counts.max = max(q.counts.max, depth)
+	q.counts.enqueued++
+	room := limit <= 0 || depth < limit
+	q.mu.Unlock()
+
+	q.report("enqueued", depth)
+	q.signal()
+	// Pass the space signal along to the next blocked push.
+	if room {
+		q.signalSpace()
+	}
+	return true, false
+}
+
+// pop blocks until an item is available. False is returned if the queue is closed and empty, or abort is closed.
+func (q *queue[T]) pop(abort <-chan struct{}) (T, bool) {
+	e, ok := q.popEntry(abort, nil)
+	return e.item, ok
+}
+
+// popWithin is like pop, but also gives up when expired fires.
+func (q *queue[T]) popWithin(abort <-chan struct{}, expired <-chan time.Time) (T, bool) {
+	e, ok := q.popEntry(abort, expired)
+	return e.item, ok
+}
+
+// popEntry is like popWithin, but returns the whole entry. The order of the entry is set to the number of entries
+// removed before it.
+func (q *queue[T]) popEntry(abort <-chan struct{}, expired <-chan time.Time) (entry[T], bool) {
+	var zero entry[T]
+	for {
+		q.mu.Lock()
+		if q.items.len() > 0 {
+			e := q.items.pop()
+			if q.fair != nil {
+				q.fair.take(e.tenant, e.tag)
+			}
+			e.order = q.dispatched
+			q.dispatched++
+			q.counts.dequeued++
+			depth := q.items.len()
+			more := depth > 0
+			q.mu.Unlock()
+			q.report("dequeued", depth)
+			// Pass the signal along to the next waiter.
+			if more {
+				q.signal()
+			}
+			q.signalSpace()
+			return e, true
+		}
+		closed := q.closed
+		q.mu.Unlock()
+		if closed {
+			return zero, false
+		}
+
+		select {
+		case <-q.ready:
+		case <-q.done:
+		case <-expired:
+			return zero, false
+		case <-abort:
+			return zero, false
+		}
+	}
+}
+
+// remove takes the entries for which drop returns true out of the queue. They are counted as removed in the order of
+// the queue like the entries returned by popEntry, and their order is set accordingly.
+func (q *queue[T]) remove(drop func(e entry[T]) bool) []entry[T] {
+	q.mu.Lock()
+	removed := q.items.remove(drop)
+	if len(removed) == 0 {
+		q.mu.Unlock()
+		return nil
+	}
+	for i := range removed {
+		if q.fair != nil {
+			q.fair.take(removed[i].tenant, removed[i].tag)
+		}
+		removed[i].order = q.dispatched
+		q.dispatched++
+		q.counts.dequeued++
+	}
+	depth := q.items.len()
+	q.mu.Unlock()
+	q.report("dequeued", depth)
+	q.signalSpace()
+	return removed
+}
+
+// close prevents new items from being added. Items already in the queue may still be removed.
+func (q *queue[T]) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.closed = true
+		close(q.done)
+	}
+}
+
+// status returns the number of queued items and whether the queue is closed.
+func (q *queue[T]) status() (int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.items.len(), q.closed
+}
+
+// len returns the number of items waiting in the queue.
+func (q *queue[T]) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.items.len()
+}
+
+// stats fills in the queue counters of Stats.
+func (q *queue[T]) stats(stats *Stats) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stats.Queued = q.items.len()
+	stats.MaxQueued = q.counts.max
+	stats.Enqueued = q.counts.enqueued
+	stats.Dequeued = q.counts.dequeued
+	stats.SubmitBlocked = q.counts.blocked
+}
+
+// report counts an item added or removed with counter in the MetricsSink, and sets the "queued" gauge to the depth of
+// the queue.
+func (q *queue[T]) report(counter string, depth int) {
+	if q.metrics == nil {
+		return
+	}
+	if m := q.metrics(); m != nil {
+		m.Counter(counter, 1)
+		m.Gauge("queued", float64(depth))
+	}
+}
+
+// recordBlocked adds the time a push spent waiting for room in the queue.
+func (q *queue[T]) recordBlocked(d time.Duration) {
+	q.mu.Lock()
+	q.counts.blocked += d
+	q.mu.Unlock()
+	if q.metrics == nil {
+		return
+	}
+	if m := q.metrics(); m != nil {
+		m.Timing("submit_blocked", d)
+	}
+}
+
+// signal wakes up one waiting pop without blocking.
+func (q *queue[T]) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// signalSpace wakes up one blocked push without blocking.
+func (q *queue[T]) signalSpace() {
+	select {
+	case q.space <- struct{}{}:
+	default:
+	}
+}
+
+// entries holds the queued items by priority. Within a priority the items are ordered by the fair share, and the next
+// item is the first of the priority whose first item has the highest rank. Comparing the ranks of the first items only
+// keeps the order consistent: priority aging gives every item its own rank, which would otherwise conflict with the
+// fair share.
+type entries[T any] struct {
+	levels map[int]*level[T]
+	n      int
+}
+
+func (e *entries[T]) len() int { return e.n }
+
+func (e *entries[T]) push(x entry[T]) {
+	if e.levels == nil {
+		e.levels = make(map[int]*level[T])
+	}
+	l := e.levels[x.priority]
+	if l == nil {
+		l = &level[T]{}
+		e.levels[x.priority] = l
+	}
+	heap.Push(l, x)
+	e.n++
+}
+
+// pop removes the next item. There must be at least one.
+func (e *entries[T]) pop() entry[T] {
+	var next *level[T]
+	for _, l := range e.levels {
+		if next == nil || l.before(next) {
+			next = l
+		}
+	}
+	x := heap.Pop(next).(entry[T])
+	if next.Len() == 0 {
+		delete(e.levels, x.priority)
+	}
+	e.n--
+	return x
+}
+
+// remove takes the items for which drop returns true out, and returns them in the order they would have been popped.
+func (e *entries[T]) remove(drop func(e entry[T]) bool) []entry[T] {
+	var removed entries[T]
+	for priority, l := range e.levels {
+		kept := (*l)[:0]
+		for _, x := range *l {
+			if drop(x) {
+				removed.push(x)
+			} else {
+				kept = append(kept, x)
+			}
+		}
+		clear((*l)[len(kept):])
+		*l = kept
+		if len(kept) == 0 {
+			delete(e.levels, priority)
+		} else {
+			heap.Init(l)
+		}
+	}
+	e.n -= removed.n
+	var ordered []entry[T]
+	for removed.len() > 0 {
+		ordered = append(ordered, removed.pop())
+	}
+	return ordered
+}
+
+// level implements heap.Interface for the items of one priority, in the order of the fair share.
+type level[T any] []entry[T]
+
+// before reports whether the first item of l is taken before the first item of the other level.
+func (l level[T]) before(other *level[T]) bool {
+	a, b := l[0], (*other)[0]
+	if a.rank != b.rank {
+		return a.rank > b.rank
+	}
+	if a.tag != b.tag {
+		return a.tag < b.tag
+	}
+	return a.seq < b.seq
+}
+
+func (l level[T]) Len() int { return len(l) }
+
+func (l level[T]) Less(i, j int) bool {
+	if l[i].tag != l[j].tag {
+		return l[i].tag < l[j].tag
+	}
+	return l[i].seq < l[j].seq
+}
+
+func (l level[T]) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+
+func (l *level[T]) Push(x any) { *l = append(*l, x.(entry[T])) }
+
+func (l *level[T]) Pop() any {
+	old := *l
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = entry[T]{}
+	*l = old[:n-1]
+	return item
+}
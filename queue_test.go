@@ -0,0 +1,100 @@
+package workpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueueOrder(t *testing.T) {
+	q := newQueue[int]()
+	for i := 0; i < 5; i++ {
+		assert.True(t, q.push(i, 0, 0, nil))
+	}
+	q.close()
+	assert.False(t, q.push(5, 0, 0, nil))
+	assert.Equal(t, 5, q.len())
+
+	for i := 0; i < 5; i++ {
+		item, ok := q.pop(nil)
+		assert.True(t, ok)
+		assert.Equal(t, i, item)
+	}
+	_, ok := q.pop(nil)
+	assert.False(t, ok)
+}
+
+func TestQueueAbort(t *testing.T) {
+	q := newQueue[int]()
+	abort := make(chan struct{})
+	close(abort)
+
+	_, ok := q.pop(abort)
+	assert.False(t, ok)
+}
+
+func TestQueueWakesWaiters(t *testing.T) {
+	q := newQueue[int]()
+	results := make(chan int)
+	for i := 0; i < 3; i++ {
+		go func() {
+			item, _ := q.pop(nil)
+			results <- item
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		q.push(i, 0, 0, nil)
+	}
+
+	sum := 0
+	for i := 0; i < 3; i++ {
+		sum += <-results
+	}
+	assert.Equal(t, 0+1+2, sum)
+}
+
+func TestQueuePriority(t *testing.T) {
+	q := newQueue[string]()
+	q.push("low", -1, 0, nil)
+	q.push("first", 0, 0, nil)
+	q.push("high", 10, 0, nil)
+	q.push("second", 0, 0, nil)
+	q.close()
+
+	var order []string
+	for {
+		item, ok := q.pop(nil)
+		if !ok {
+			break
+		}
+		order = append(order, item)
+	}
+	assert.Equal(t, []string{"high", "first", "second", "low"}, order)
+}
+
+func TestQueueLimit(t *testing.T) {
+	q := newQueue[int]()
+	assert.True(t, q.tryPush(1, 0, 2))
+	assert.True(t, q.tryPush(2, 0, 2))
+	assert.False(t, q.tryPush(3, 0, 2))
+
+	// A blocked push is released when an item is removed.
+	pushed := make(chan bool)
+	go func() {
+		pushed <- q.push(3, 0, 2, nil)
+	}()
+	item, _ := q.pop(nil)
+	assert.Equal(t, 1, item)
+	assert.True(t, <-pushed)
+	assert.Equal(t, 2, q.len())
+
+	// And returns false when aborted or closed.
+	abort := make(chan struct{})
+	close(abort)
+	assert.False(t, q.push(4, 0, 2, abort))
+	go func() {
+		pushed <- q.push(4, 0, 2, nil)
+	}()
+	q.close()
+	assert.False(t, <-pushed)
+}
@@ -0,0 +1,75 @@
+package workpool
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter throttles handler calls. Wait blocks until the next call is allowed, or returns an error if ctx is done first.
+// A *rate.Limiter from golang.org/x/time/rate satisfies this interface.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// RateLimiter is a token bucket Limiter. Tokens are added at a fixed rate up to a maximum burst size, and each call to
+// Wait consumes one.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a RateLimiter allowing rps calls per second with bursts of up to burst calls. A burst smaller
+// than one is treated as one, and a non-positive rps disables limiting.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if l.rate <= 0 {
+		return nil
+	}
+
+	// Reserve a token, going into debt if none are available.
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give back the reservation.
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,81 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterBurst(t *testing.T) {
+	l := NewRateLimiter(10, 5)
+
+	// The burst is available immediately.
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, l.Wait(context.Background()))
+	}
+	assert.WithinDuration(t, start, time.Now(), 10*time.Millisecond)
+
+	// The next token takes 1/rps seconds.
+	assert.NoError(t, l.Wait(context.Background()))
+	assert.WithinDuration(t, start.Add(100*time.Millisecond), time.Now(), 30*time.Millisecond)
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	l := NewRateLimiter(1, 1)
+	assert.NoError(t, l.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.Wait(ctx), context.DeadlineExceeded)
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	l := NewRateLimiter(0, 0)
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, l.Wait(context.Background()))
+	}
+}
+
+func TestPoolLimiter(t *testing.T) {
+	numInputs := 10
+	inputs := make(chan int, numInputs)
+	for i := 0; i < numInputs; i++ {
+		inputs <- i
+	}
+	close(inputs)
+
+	worker := func(abort <-chan struct{}) bool {
+		for range inputs {
+			return true
+		}
+		return false
+	}
+
+	// One burst call plus 10 more at 200/s: roughly 50ms regardless of the worker count.
+	pool := New(4, worker)
+	pool.Limiter = NewRateLimiter(200, 1)
+
+	start := time.Now()
+	assert.NoError(t, pool.Run())
+	assert.WithinDuration(t, start.Add(50*time.Millisecond), time.Now(), 30*time.Millisecond)
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Wait(ctx context.Context) error {
+	return errors.New("limiter failed")
+}
+
+func TestPoolLimiterError(t *testing.T) {
+	worker := func(abort <-chan struct{}) bool {
+		return true
+	}
+	pool := New(2, worker)
+	pool.Limiter = failingLimiter{}
+
+	assert.EqualError(t, pool.Run(), "limiter failed")
+}
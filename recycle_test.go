@@ -0,0 +1,68 @@
+package workpool
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxTasksPerWorker(t *testing.T) {
+	var mu sync.Mutex
+	var starts, stops, exits int
+	var reasons []ExitReason
+	var calls int32
+	pool := NewIndexed(2, func(workerID int, abort <-chan struct{}) bool {
+		return atomic.AddInt32(&calls, 1) <= 10
+	})
+	pool.MaxTasksPerWorker = 3
+	pool.OnWorkerStart = func(workerID int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		starts++
+		return nil
+	}
+	pool.OnWorkerStop = func(workerID int) {
+		mu.Lock()
+		defer mu.Unlock()
+		stops++
+	}
+	pool.OnWorkerExit = func(workerID int, reason ExitReason) {
+		mu.Lock()
+		defer mu.Unlock()
+		exits++
+		reasons = append(reasons, reason)
+		assert.Less(t, workerID, 2)
+	}
+	assert.NoError(t, pool.Run())
+
+	// 10 calls found work, three workers were recycled after 3 of them.
+	recycled := 0
+	for _, reason := range reasons {
+		if reason == ExitRecycled {
+			recycled++
+		}
+	}
+	assert.Equal(t, 3, recycled)
+	assert.Equal(t, starts, stops)
+	assert.Equal(t, starts, exits)
+	assert.Equal(t, 5, starts)
+}
+
+func TestMaxTasksPerWorkerCancelled(t *testing.T) {
+	var starts int32
+	pool := New(1, func(abort <-chan struct{}) bool {
+		return true
+	})
+	pool.MaxTasksPerWorker = 1
+	pool.OnWorkerStart = func(workerID int) error {
+		if atomic.AddInt32(&starts, 1) == 5 {
+			pool.Cancel()
+		}
+		return nil
+	}
+	// No replacement is started once the pool is cancelled.
+	assert.NoError(t, pool.Run())
+	assert.EqualValues(t, 5, atomic.LoadInt32(&starts))
+}
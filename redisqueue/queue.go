@@ -0,0 +1,222 @@
+// Package redisqueue is a persistent job queue for WorkPool workers, stored in a Redis stream. Jobs survive restarts
+// of the process and are delivered at least once: a job is acknowledged after it has been processed successfully,
+// and jobs left pending by a crashed or failing consumer are claimed again once they have been idle for long enough.
+//
+// It is a separate module so that the workpool package itself does not depend on a Redis client.
+package redisqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/algorand/workpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// payloadField is the stream entry field holding the job payload.
+const payloadField = "payload"
+
+// Job is a job read from the queue.
+type Job struct {
+	// ID is the stream entry ID.
+	ID string
+
+	// Payload is the data given to Submit.
+	Payload []byte
+
+	// Claimed is true if the job was claimed from another consumer, or from an earlier run of this one, after it was
+	// left pending.
+	Claimed bool
+}
+
+// Queue is a job queue in a Redis stream, read through a consumer group. Several processes may consume the same
+// queue, each with its own consumer name.
+type Queue struct {
+	// Block is how long a read waits for new jobs. It also bounds how long a worker takes to notice that the pool was
+	// cancelled. Zero waits for one second.
+	Block time.Duration
+
+	// ClaimIdle, when positive, is how long a job may stay pending before it is claimed again. Jobs are left pending
+	// when the handler fails or the consumer crashes while processing them. Zero never claims jobs, so failed jobs
+	// are never retried.
+	ClaimIdle time.Duration
+
+	// ErrorBackoff is how long a worker waits after a failed read or claim before trying again, so that workers do
+	// not spin while Redis is unavailable. Zero waits one second.
+	ErrorBackoff time.Duration
+
+	client   redis.Cmdable
+	stream   string
+	group    string
+	consumer string
+
+	mu        sync.Mutex
+	lastClaim time.Time
+}
+
+// New creates a Queue for stream, read by consumer as part of group.
+func New(client redis.Cmdable, stream, group, consumer string) *Queue {
+	return &Queue{
+		client:   client,
+		stream:   stream,
+		group:    group,
+		consumer: consumer,
+	}
+}
+
+// Init creates the stream and the consumer group if they do not exist yet. A new group starts with the jobs already
+// in the stream.
+func (q *Queue) Init(ctx context.Context) error {
+	err := q.client.XGroupCreateMkStream(ctx, q.stream, q.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("redisqueue: create group %s: %w", q.group, err)
+	}
+	return nil
+}
+
+// Submit adds a job to the queue, returning its ID.
+func (q *Queue) Submit(ctx context.Context, payload []byte) (string, error) {
+	id, err := q.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: q.stream,
+		Values: map[string]any{payloadField: payload},
+	}).Result()
+	if err != nil {
+		return "", fmt.Errorf("redisqueue: submit: %w", err)
+	}
+	return id, nil
+}
+
+// Backlog returns the number of jobs in the stream which have not been acknowledged: the pending jobs and those which
+// have not been read yet.
+func (q *Queue) Backlog(ctx context.Context) (int64, error) {
+	groups, err := q.client.XInfoGroups(ctx, q.stream).Result()
+	if err != nil {
+		return 0, fmt.Errorf("redisqueue: backlog: %w", err)
+	}
+	for _, g := range groups {
+		if g.Name == q.group {
+			return g.Pending + g.Lag, nil
+		}
+	}
+	return 0, fmt.Errorf("redisqueue: backlog: group %s not found", q.group)
+}
+
+// Handler creates a WorkHandler which calls fn for each job. The job is acknowledged, and deleted from the stream, if
+// fn returns nil. Otherwise it stays pending and is claimed again after ClaimIdle. The context given to fn is cancelled
+// when the pool is cancelled. Errors are reported to the pool, and the worker keeps going until the pool is
+// cancelled.
+func (q *Queue) Handler(fn func(ctx context.Context, job Job) error) workpool.ErrWorkHandler {
+	return func(abort <-chan struct{}) (bool, error) {
+		ctx, cancel := workpool.AbortContext(abort)
+		defer cancel()
+
+		job, ok, err := q.next(ctx)
+		if ctx.Err() != nil {
+			return false, nil
+		}
+		if err != nil {
+			q.backoff(abort)
+			return true, err
+		}
+		if !ok {
+			return true, nil
+		}
+
+		if err := fn(ctx, job); err != nil {
+			return true, err
+		}
+		pipe := q.client.TxPipeline()
+		pipe.XAck(ctx, q.stream, q.group, job.ID)
+		pipe.XDel(ctx, q.stream, job.ID)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return true, fmt.Errorf("redisqueue: ack %s: %w", job.ID, err)
+		}
+		return true, nil
+	}
+}
+
+// next claims an idle job, or reads a new one. False is returned if there was none.
+func (q *Queue) next(ctx context.Context) (Job, bool, error) {
+	if q.claimDue() {
+		messages, _, err := q.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   q.stream,
+			Group:    q.group,
+			Consumer: q.consumer,
+			MinIdle:  q.ClaimIdle,
+			Start:    "0-0",
+			Count:    1,
+		}).Result()
+		if err != nil {
+			return Job{}, false, fmt.Errorf("redisqueue: claim: %w", err)
+		}
+		if len(messages) > 0 {
+			return newJob(messages[0], true), true, nil
+		}
+		// Nothing to claim, so there is no point checking again for a while.
+		q.mu.Lock()
+		q.lastClaim = time.Now()
+		q.mu.Unlock()
+	}
+
+	block := q.Block
+	if block <= 0 {
+		block = time.Second
+	}
+	streams, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    q.group,
+		Consumer: q.consumer,
+		Streams:  []string{q.stream, ">"},
+		Count:    1,
+		Block:    block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return Job{}, false, nil
+	}
+	if err != nil {
+		return Job{}, false, fmt.Errorf("redisqueue: read: %w", err)
+	}
+	for _, stream := range streams {
+		if len(stream.Messages) > 0 {
+			return newJob(stream.Messages[0], false), true, nil
+		}
+	}
+	return Job{}, false, nil
+}
+
+// claimDue reports whether idle jobs should be looked for. After a search finds nothing the next one waits for half
+// of ClaimIdle.
+func (q *Queue) claimDue() bool {
+	if q.ClaimIdle <= 0 {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return time.Since(q.lastClaim) >= q.ClaimIdle/2
+}
+
+// backoff waits after a failed read or claim.
+func (q *Queue) backoff(abort <-chan struct{}) {
+	d := q.ErrorBackoff
+	if d <= 0 {
+		d = time.Second
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-abort:
+	}
+}
+
+// newJob converts a stream entry.
+func newJob(message redis.XMessage, claimed bool) Job {
+	job := Job{ID: message.ID, Claimed: claimed}
+	if payload, ok := message.Values[payloadField].(string); ok {
+		job.Payload = []byte(payload)
+	}
+	return job
+}
@@ -0,0 +1,153 @@
+package redisqueue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/algorand/workpool"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newClient(t *testing.T) *redis.Client {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// processed records the payloads seen by a handler.
+type processed struct {
+	mu       sync.Mutex
+	payloads []string
+	claimed  []string
+}
+
+func (p *processed) add(job Job) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.payloads = append(p.payloads, string(job.Payload))
+	if job.Claimed {
+		p.claimed = append(p.claimed, string(job.Payload))
+	}
+}
+
+func (p *processed) len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.payloads)
+}
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t)
+	queue := New(client, "jobs", "workers", "consumer-1")
+	queue.Block = 10 * time.Millisecond
+	require.NoError(t, queue.Init(ctx))
+	require.NoError(t, queue.Init(ctx))
+
+	for _, payload := range []string{"a", "b", "c"} {
+		_, err := queue.Submit(ctx, []byte(payload))
+		require.NoError(t, err)
+	}
+	backlog, err := queue.Backlog(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, backlog)
+
+	var got processed
+	pool := workpool.NewWithError(2, queue.Handler(func(ctx context.Context, job Job) error {
+		got.add(job)
+		return nil
+	}))
+	pool.Start()
+	assert.Eventually(t, func() bool { return got.len() == 3 }, time.Second, time.Millisecond)
+	pool.Cancel()
+	assert.NoError(t, pool.Wait())
+
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, got.payloads)
+	backlog, err = queue.Backlog(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, backlog)
+	length, err := client.XLen(ctx, "jobs").Result()
+	require.NoError(t, err)
+	assert.Zero(t, length)
+}
+
+func TestQueueClaimsFailedJobs(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t)
+	queue := New(client, "jobs", "workers", "consumer-1")
+	queue.Block = 5 * time.Millisecond
+	queue.ClaimIdle = 20 * time.Millisecond
+	require.NoError(t, queue.Init(ctx))
+	_, err := queue.Submit(ctx, []byte("flaky"))
+	require.NoError(t, err)
+
+	var got processed
+	var once sync.Once
+	pool := workpool.NewWithError(1, queue.Handler(func(ctx context.Context, job Job) error {
+		got.add(job)
+		var err error
+		once.Do(func() { err = errors.New("first attempt") })
+		return err
+	}))
+	pool.Start()
+	assert.Eventually(t, func() bool { return got.len() == 2 }, 2*time.Second, time.Millisecond)
+	pool.Cancel()
+	assert.EqualError(t, pool.Wait(), "first attempt")
+	assert.Equal(t, []string{"flaky"}, got.claimed)
+}
+
+func TestQueueResumesAfterCrash(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t)
+
+	// The first consumer reads a job and crashes before acknowledging it.
+	crashed := New(client, "jobs", "workers", "crashed")
+	require.NoError(t, crashed.Init(ctx))
+	_, err := crashed.Submit(ctx, []byte("orphan"))
+	require.NoError(t, err)
+	_, ok, err := crashed.next(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	queue := New(client, "jobs", "workers", "consumer-2")
+	queue.Block = 5 * time.Millisecond
+	queue.ClaimIdle = 10 * time.Millisecond
+	var got processed
+	pool := workpool.NewWithError(1, queue.Handler(func(ctx context.Context, job Job) error {
+		got.add(job)
+		return nil
+	}))
+	pool.Start()
+	assert.Eventually(t, func() bool { return got.len() == 1 }, 2*time.Second, time.Millisecond)
+	pool.Cancel()
+	assert.NoError(t, pool.Wait())
+	assert.Equal(t, []string{"orphan"}, got.claimed)
+}
+
+func TestQueueBacksOffWhileRedisIsDown(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	queue := New(client, "jobs", "workers", "consumer-1")
+	queue.ErrorBackoff = 50 * time.Millisecond
+	require.NoError(t, queue.Init(context.Background()))
+	server.SetError("ERR unavailable")
+
+	pool := workpool.NewWithError(1, queue.Handler(func(ctx context.Context, job Job) error {
+		return nil
+	}))
+	pool.Start()
+	time.Sleep(120 * time.Millisecond)
+	pool.Cancel()
+	assert.Error(t, pool.Wait())
+	// Without the backoff the worker would have failed thousands of times.
+	assert.LessOrEqual(t, pool.ErrorCount(), 3)
+	assert.GreaterOrEqual(t, pool.ErrorCount(), 1)
+}
@@ -0,0 +1,76 @@
+package workpool
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrPanicked is the error of a result which could not be produced because the handler panicked.
+var ErrPanicked = errors.New("workpool: handler panicked")
+
+// reorder buffers values which arrive out of order and releases them in sequence.
+type reorder[T any] struct {
+	mu       sync.Mutex
+	next     uint64
+	pending  map[uint64]T
+	emitting bool
+
+	// advanced is closed and replaced whenever next changes.
+	advanced chan struct{}
+}
+
+func newReorder[T any]() *reorder[T] {
+	return &reorder[T]{
+		pending:  make(map[uint64]T),
+		advanced: make(chan struct{}),
+	}
+}
+
+// add buffers the value with sequence number seq, and then emits every value which is ready. Only sequence numbers
+// within window of the next value to emit are buffered, add blocks until seq is in range. A window of zero or less
+// means that there is no limit.
+//
+// Values are emitted by calling emit from whichever goroutine fills in the next value. False is returned if abort is
+// closed while waiting, or emit returns false.
+func (r *reorder[T]) add(seq uint64, value T, window int, abort <-chan struct{}, emit func(T) bool) bool {
+	r.mu.Lock()
+	for window > 0 && seq >= r.next+uint64(window) {
+		advanced := r.advanced
+		r.mu.Unlock()
+		select {
+		case <-advanced:
+		case <-abort:
+			return false
+		}
+		r.mu.Lock()
+	}
+
+	r.pending[seq] = value
+	if r.emitting {
+		r.mu.Unlock()
+		return true
+	}
+
+	r.emitting = true
+	for {
+		ready, ok := r.pending[r.next]
+		if !ok {
+			break
+		}
+		delete(r.pending, r.next)
+		r.mu.Unlock()
+		sent := emit(ready)
+		r.mu.Lock()
+		if !sent {
+			r.emitting = false
+			r.mu.Unlock()
+			return false
+		}
+		r.next++
+		close(r.advanced)
+		r.advanced = make(chan struct{})
+	}
+	r.emitting = false
+	r.mu.Unlock()
+	return true
+}
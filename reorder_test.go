@@ -0,0 +1,45 @@
+package workpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReorder(t *testing.T) {
+	r := newReorder[int]()
+	var emitted []int
+	emit := func(v int) bool {
+		emitted = append(emitted, v)
+		return true
+	}
+
+	assert.True(t, r.add(2, 20, 0, nil, emit))
+	assert.True(t, r.add(1, 10, 0, nil, emit))
+	assert.Empty(t, emitted)
+	assert.True(t, r.add(0, 0, 0, nil, emit))
+	assert.Equal(t, []int{0, 10, 20}, emitted)
+}
+
+func TestReorderWindow(t *testing.T) {
+	r := newReorder[int]()
+	emit := func(v int) bool { return true }
+
+	// Sequence 2 is outside of a window of 2 until 0 has been emitted.
+	added := make(chan bool)
+	go func() {
+		added <- r.add(2, 2, 2, nil, emit)
+	}()
+	select {
+	case <-added:
+		t.Fatal("add did not wait for the window")
+	case <-time.After(10 * time.Millisecond):
+	}
+	assert.True(t, r.add(0, 0, 2, nil, emit))
+	assert.True(t, <-added)
+
+	abort := make(chan struct{})
+	close(abort)
+	assert.False(t, r.add(5, 5, 2, abort, emit))
+}
@@ -0,0 +1,58 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrPoolRunning is returned by Reset while the pool is running.
+var ErrPoolRunning = errors.New("workpool: pool is running")
+
+// Reset prepares a pool which has finished, or was cancelled, to be run again with the same configuration. The abort
+// signal, the error returned by Run, the pause state and the statistics are all reset. ErrPoolRunning is returned if
+// the pool has been started and has not finished yet, that is until Wait returns.
+//
+// Reset must not be called concurrently with other methods. It only applies to a plain WorkPool: the pool types which
+// own their queue, such as TypedPool, cannot be reused once finished.
+func (p *WorkPool) Reset() error {
+	p.init()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.launched {
+		select {
+		case <-p.done:
+		default:
+			return ErrPoolRunning
+		}
+	}
+
+	p.cancel(nil)
+	p.ctx, p.cancel = context.WithCancelCause(context.Background())
+	p.cancelOnce = sync.Once{}
+	p.aborted.Store(false)
+	p.counters = &counters{}
+	p.done = make(chan struct{})
+
+	p.errMu.Lock()
+	p.err = nil
+	p.errs = nil
+	p.errCount = 0
+	p.errMu.Unlock()
+
+	p.pauseMu.Lock()
+	p.paused = false
+	p.pausing.Store(false)
+	resumed := make(chan struct{})
+	close(resumed)
+	p.resumed.Store(resumed)
+	p.pauseMu.Unlock()
+
+	p.launched = false
+	p.holding = false
+	p.parent = nil
+	p.started = time.Time{}
+	p.stopped = time.Time{}
+	return nil
+}
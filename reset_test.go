@@ -0,0 +1,49 @@
+package workpool
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReset(t *testing.T) {
+	runs := 0
+	closed := 0
+	release := make(chan struct{})
+	pool := NewWithError(2, func(abort <-chan struct{}) (bool, error) {
+		if runs == 0 {
+			<-release
+			return false, errors.New("failed")
+		}
+		return false, nil
+	})
+	pool.Close = func() { closed++ }
+
+	pool.Start()
+	assert.ErrorIs(t, pool.Reset(), ErrPoolRunning)
+	close(release)
+	assert.EqualError(t, pool.Wait(), "failed")
+	pool.Cancel()
+	assert.True(t, pool.Cancelled())
+
+	// The pool runs again with a fresh abort signal and error.
+	require.NoError(t, pool.Reset())
+	runs++
+	assert.False(t, pool.Cancelled())
+	assert.Zero(t, pool.Stats().Invocations)
+	assert.NoError(t, pool.Run())
+	assert.Equal(t, int64(2), pool.Stats().Invocations)
+	assert.Equal(t, 2, closed)
+}
+
+func TestResetNotStarted(t *testing.T) {
+	pool := New(1, func(abort <-chan struct{}) bool { return false })
+	pool.Pause()
+	require.NoError(t, pool.Reset())
+	assert.False(t, pool.Paused())
+	assert.NoError(t, pool.Run())
+	assert.NoError(t, pool.Reset())
+	assert.NoError(t, pool.Run())
+}
@@ -0,0 +1,85 @@
+package workpool
+
+import (
+	"context"
+)
+
+// Resize changes the number of workers. It may be called while the pool is running, in which case workers are started
+// or stopped to match n. Stopped workers are never interrupted: they are retiring until their current handler call
+// returns, see RetiringWorkers, and then exit. Shrinking a running pool to zero workers finishes the run.
+//
+// When the pool is not running, Resize only updates Workers.
+func (p *WorkPool) Resize(n int) {
+	if n < 0 {
+		n = 0
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Workers = n
+	if !p.running {
+		return
+	}
+
+	for len(p.workers) < n {
+		p.startWorker()
+	}
+	for len(p.workers) > n {
+		p.retireWorker()
+	}
+}
+
+// retireWorker asks the most recently started worker to exit after its current handler call. The caller must hold p.mu.
+func (p *WorkPool) retireWorker() {
+	w := p.workers[len(p.workers)-1]
+	p.workers = p.workers[:len(p.workers)-1]
+	w.retiring.Store(true)
+	close(w.quit)
+	if p.retiring == nil {
+		p.retiring = make(map[*worker]struct{})
+	}
+	p.retiring[w] = struct{}{}
+}
+
+// ResizeAndWait is like Resize, but when the pool shrinks it also waits for the retiring workers to exit. The error of
+// ctx is returned if it is done first, the workers keep retiring.
+func (p *WorkPool) ResizeAndWait(ctx context.Context, n int) error {
+	p.mu.Lock()
+	before := len(p.workers)
+	p.mu.Unlock()
+	p.Resize(n)
+	if before <= n {
+		return nil
+	}
+
+	p.mu.Lock()
+	var exited []chan struct{}
+	for w := range p.retiring {
+		exited = append(exited, w.exited)
+	}
+	p.mu.Unlock()
+	for _, ch := range exited {
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// RetiringWorkers returns the number of workers which have been asked to stop, by Resize or the scaling policies, and
+// are finishing their current handler call.
+func (p *WorkPool) RetiringWorkers() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.retiring)
+}
+
+// ActiveWorkers returns the number of workers currently running, not counting workers which have been asked to stop by
+// Resize.
+func (p *WorkPool) ActiveWorkers() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.workers)
+}
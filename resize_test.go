@@ -0,0 +1,130 @@
+package workpool
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrencyWorker returns a handler which records the number of concurrent calls until done is closed.
+func concurrencyWorker(current, peak *int32, done <-chan struct{}) WorkHandler {
+	return func(abort <-chan struct{}) bool {
+		n := atomic.AddInt32(current, 1)
+		defer atomic.AddInt32(current, -1)
+		for {
+			old := atomic.LoadInt32(peak)
+			if n <= old || atomic.CompareAndSwapInt32(peak, old, n) {
+				break
+			}
+		}
+		select {
+		case <-done:
+			return false
+		case <-abort:
+			return false
+		case <-time.After(time.Millisecond):
+			return true
+		}
+	}
+}
+
+func TestResizeGrow(t *testing.T) {
+	var current, peak int32
+	done := make(chan struct{})
+	pool := New(1, concurrencyWorker(&current, &peak, done))
+
+	finished := make(chan struct{})
+	go func() {
+		pool.Run()
+		close(finished)
+	}()
+
+	assert.Eventually(t, func() bool { return pool.ActiveWorkers() == 1 }, time.Second, time.Millisecond)
+	pool.Resize(4)
+	assert.Equal(t, 4, pool.ActiveWorkers())
+	assert.Equal(t, 4, pool.Workers)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&peak) == 4 }, time.Second, time.Millisecond)
+
+	close(done)
+	<-finished
+	assert.Equal(t, 0, pool.ActiveWorkers())
+}
+
+func TestResizeShrink(t *testing.T) {
+	var current, peak int32
+	done := make(chan struct{})
+	defer close(done)
+	pool := New(4, concurrencyWorker(&current, &peak, done))
+
+	finished := make(chan struct{})
+	go func() {
+		pool.Run()
+		close(finished)
+	}()
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&current) == 4 }, time.Second, time.Millisecond)
+	pool.Resize(1)
+	assert.Equal(t, 1, pool.ActiveWorkers())
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&current) <= 1 }, time.Second, time.Millisecond)
+
+	// Shrinking to zero finishes the run.
+	pool.Resize(0)
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("pool did not finish after resizing to zero")
+	}
+}
+
+func TestResizeNotRunning(t *testing.T) {
+	worker := func(abort <-chan struct{}) bool {
+		return false
+	}
+	pool := New(1, worker)
+	pool.Resize(3)
+	assert.Equal(t, 3, pool.Workers)
+	assert.Equal(t, 0, pool.ActiveWorkers())
+
+	pool.Resize(-1)
+	assert.Equal(t, 0, pool.Workers)
+	assert.NoError(t, pool.Run())
+}
+
+func TestResizeRetiring(t *testing.T) {
+	release := make(chan struct{})
+	var inFlight, interrupted int64
+	pool := New(3, func(abort <-chan struct{}) bool {
+		atomic.AddInt64(&inFlight, 1)
+		defer atomic.AddInt64(&inFlight, -1)
+		select {
+		case <-release:
+		case <-abort:
+			atomic.AddInt64(&interrupted, 1)
+		}
+		return true
+	})
+	pool.Start()
+	require.Eventually(t, func() bool { return atomic.LoadInt64(&inFlight) == 3 }, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, pool.ResizeAndWait(ctx, 1), context.DeadlineExceeded)
+	assert.Equal(t, 1, pool.ActiveWorkers())
+	assert.Equal(t, 2, pool.RetiringWorkers())
+	assert.Equal(t, 2, pool.Stats().RetiringWorkers)
+	assert.NoError(t, pool.ResizeAndWait(ctx, 1), "nothing to wait for when not shrinking")
+
+	close(release)
+	done := make(chan error, 1)
+	go func() {
+		done <- pool.ResizeAndWait(context.Background(), 0)
+	}()
+	require.NoError(t, <-done)
+	assert.Zero(t, pool.RetiringWorkers())
+	assert.Zero(t, atomic.LoadInt64(&interrupted), "retiring workers finish their call")
+	require.NoError(t, pool.Wait())
+}
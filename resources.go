@@ -0,0 +1,91 @@
+package workpool
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// Resources opens a resource of type T for each worker, such as a database or gRPC connection, and closes it when the
+// worker exits. The resource is only used by its worker, so it needs no synchronization.
+type Resources[T any] struct {
+	// Open creates the resource of a worker before the worker makes its first handler call.
+	Open func(workerID int) (T, error)
+
+	// Close, if set, releases the resource once the worker exits.
+	Close func(resource T)
+}
+
+// ResourceWorkHandler is like ErrWorkHandler, but it is also given the resource of the worker calling it.
+type ResourceWorkHandler[T any] func(resource T, abort <-chan struct{}) (bool, error)
+
+// NewWithResources creates a worker pool whose workers each hold a resource opened by resources, which is passed to
+// every call of handler.
+//
+// The resources of the workers started with the pool are opened by Start, before any worker runs. If one of them
+// fails to open, those already opened are closed and the pool is cancelled with the error, without starting any
+// workers. A worker started later, by Resize or the Supervisor for example, opens its resource as it starts, and exits
+// with the error recorded if that fails.
+func NewWithResources[T any](numWorkers int, resources Resources[T], handler ResourceWorkHandler[T]) *WorkPool {
+	return &WorkPool{
+		Workers: numWorkers,
+		provision: func(workerID int) (any, func(), error) {
+			resource, err := resources.Open(workerID)
+			if err != nil {
+				return nil, nil, fmt.Errorf("workpool: opening the resource of worker %d: %w", workerID, err)
+			}
+			return resource, func() {
+				if resources.Close != nil {
+					resources.Close(resource)
+				}
+			}, nil
+		},
+		resourceHandler: func(resource any, abort <-chan struct{}) (bool, error) {
+			return handler(resource.(T), abort)
+		},
+	}
+}
+
+// provisioned is a resource opened by Start for the worker which will be given its ID.
+type provisioned struct {
+	resource any
+	cleanup  func()
+}
+
+// provisionWorkers opens the resources of the n workers about to be started by Start, by worker ID. On failure the
+// resources already opened are closed. It is called without holding p.mu, as Open may take a while or call back into
+// the pool.
+func (p *WorkPool) provisionWorkers(n int) (map[int]provisioned, error) {
+	if p.provision == nil {
+		return nil, nil
+	}
+	opened := make(map[int]provisioned, n)
+	for id := 0; id < n; id++ {
+		resource, cleanup, err := p.provision(id)
+		if err != nil {
+			for _, o := range opened {
+				o.cleanup()
+			}
+			return nil, err
+		}
+		opened[id] = provisioned{resource: resource, cleanup: cleanup}
+	}
+	return opened, nil
+}
+
+// provisionWorker gives w its resource, taking the one opened by Start if there is one. The returned cleanup closes
+// the resource.
+func (p *WorkPool) provisionWorker(w *worker) (cleanup func(), err error) {
+	p.mu.Lock()
+	opened, ok := p.provisioned[w.id]
+	delete(p.provisioned, w.id)
+	p.mu.Unlock()
+	if !ok {
+		opened.resource, opened.cleanup, err = p.provision(w.id)
+		if err != nil {
+			p.log(slog.LevelError, "worker resource could not be opened", "worker", w.id, "error", err)
+			return nil, err
+		}
+	}
+	w.resource = opened.resource
+	return opened.cleanup, nil
+}
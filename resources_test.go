@@ -0,0 +1,152 @@
+package workpool
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// conn is a fake connection for testing resources.
+type conn struct {
+	workerID int
+	calls    int
+	closed   bool
+}
+
+func TestResources(t *testing.T) {
+	var mu sync.Mutex
+	var conns []*conn
+	var remaining int64 = 20
+	pool := NewWithResources(3, Resources[*conn]{
+		Open: func(workerID int) (*conn, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			c := &conn{workerID: workerID}
+			conns = append(conns, c)
+			return c, nil
+		},
+		Close: func(c *conn) {
+			c.closed = true
+		},
+	}, func(c *conn, abort <-chan struct{}) (bool, error) {
+		if atomic.AddInt64(&remaining, -1) < 0 {
+			return false, nil
+		}
+		c.calls++
+		return true, nil
+	})
+	require.NoError(t, pool.Validate())
+	require.NoError(t, pool.Run())
+
+	require.Len(t, conns, 3)
+	calls := 0
+	for i, c := range conns {
+		assert.Equal(t, i, c.workerID, "opened by Start in order")
+		assert.True(t, c.closed)
+		calls += c.calls
+	}
+	assert.Equal(t, 20, calls)
+}
+
+func TestResourcesOpenFails(t *testing.T) {
+	var closed []int
+	var calls int64
+	pool := NewWithResources(3, Resources[int]{
+		Open: func(workerID int) (int, error) {
+			if workerID == 2 {
+				return 0, errors.New("connection refused")
+			}
+			return workerID, nil
+		},
+		Close: func(resource int) {
+			closed = append(closed, resource)
+		},
+	}, func(resource int, abort <-chan struct{}) (bool, error) {
+		atomic.AddInt64(&calls, 1)
+		return false, nil
+	})
+
+	err := pool.Run()
+	assert.EqualError(t, err, "workpool: opening the resource of worker 2: connection refused")
+	assert.True(t, pool.Cancelled())
+	assert.ElementsMatch(t, []int{0, 1}, closed)
+	assert.Zero(t, atomic.LoadInt64(&calls), "no worker is started")
+}
+
+func TestResourcesOpenFailsOnCancel(t *testing.T) {
+	pool := NewWithResources(1, Resources[int]{
+		Open: func(workerID int) (int, error) {
+			return 0, errors.New("connection refused")
+		},
+	}, func(resource int, abort <-chan struct{}) (bool, error) {
+		return false, nil
+	})
+	cancels := 0
+	pool.OnCancel = func() {
+		pool.Stats()
+		cancels++
+	}
+
+	done := make(chan error)
+	go func() { done <- pool.Run() }()
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnCancel deadlocked")
+	}
+	assert.Equal(t, 1, cancels)
+}
+
+func TestResourcesOpenCallsPool(t *testing.T) {
+	var pool *WorkPool
+	pool = NewWithResources(2, Resources[int]{
+		Open: func(workerID int) (int, error) {
+			// Open runs without the lock, so it may use the pool.
+			pool.Stats()
+			return workerID, nil
+		},
+	}, func(resource int, abort <-chan struct{}) (bool, error) {
+		return false, nil
+	})
+	pool.Use(func(next ErrWorkHandler) ErrWorkHandler {
+		pool.ActiveWorkers()
+		return next
+	})
+
+	done := make(chan error)
+	go func() { done <- pool.Run() }()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Open or the middleware deadlocked")
+	}
+}
+
+func TestResourcesLaterWorker(t *testing.T) {
+	release := make(chan struct{})
+	var opened int64
+	pool := NewWithResources(1, Resources[int]{
+		Open: func(workerID int) (int, error) {
+			if atomic.AddInt64(&opened, 1) > 1 {
+				return 0, errors.New("no more connections")
+			}
+			return workerID, nil
+		},
+	}, func(resource int, abort <-chan struct{}) (bool, error) {
+		<-release
+		return false, nil
+	})
+	pool.Start()
+	pool.Resize(2)
+	close(release)
+
+	assert.EqualError(t, pool.Wait(), "workpool: opening the resource of worker 1: no more connections")
+	assert.False(t, pool.Cancelled(), "a worker which cannot open its resource does not cancel the pool")
+}
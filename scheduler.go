@@ -0,0 +1,193 @@
+package workpool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ScheduledJob is a recurring task run by a Scheduler. The abort signal is closed when the pool is cancelled, or when
+// the run is replaced by a newer one under OverlapCancel.
+type ScheduledJob func(abort <-chan struct{}) error
+
+// OverlapPolicy decides what happens when a job is due while its previous run has not finished.
+type OverlapPolicy int
+
+const (
+	// OverlapSkip drops the new run. This is the default.
+	OverlapSkip OverlapPolicy = iota
+	// OverlapQueue runs the job again once the previous run finishes, once for every run that was due in the meantime.
+	OverlapQueue
+	// OverlapCancel aborts the previous run and starts the new one once it returns.
+	OverlapCancel
+)
+
+// Scheduler is a WorkPool which runs recurring jobs, added with Every or Cron, using its workers. Runs of different
+// jobs may happen concurrently but a single job never runs concurrently with itself.
+//
+// Errors returned by jobs are reported by Run and Err like any other handler error; the job keeps being scheduled.
+//
+// The embedded WorkPool may be configured before calling Start, but its Handler, ErrHandler and Close fields are managed
+// by the Scheduler.
+type Scheduler struct {
+	*WorkPool
+
+	queue    *queue[*scheduled]
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// scheduled is the state of a single job.
+type scheduled struct {
+	job     ScheduledJob
+	overlap OverlapPolicy
+	next    func(time.Time) time.Time
+
+	mu      sync.Mutex
+	running bool
+	queued  bool
+	backlog int
+	cancel  context.CancelFunc
+}
+
+// NewScheduler creates a Scheduler which runs jobs using numWorkers goroutines.
+func NewScheduler(numWorkers int) *Scheduler {
+	s := &Scheduler{
+		queue: newQueue[*scheduled](),
+		stop:  make(chan struct{}),
+	}
+	s.WorkPool = &WorkPool{
+		ErrHandler: s.work,
+		Workers:    numWorkers,
+	}
+	return s
+}
+
+// Every runs job at a fixed interval, the first run happening one interval from now. The interval is measured between
+// the times the job is due, not between runs, so a slow job does not make the schedule drift. An error is returned if
+// the interval is not positive.
+func (s *Scheduler) Every(interval time.Duration, overlap OverlapPolicy, job ScheduledJob) error {
+	if interval <= 0 {
+		return fmt.Errorf("workpool: every %v: interval is not positive", interval)
+	}
+	s.add(&scheduled{
+		job:     job,
+		overlap: overlap,
+		next: func(t time.Time) time.Time {
+			return t.Add(interval)
+		},
+	})
+	return nil
+}
+
+// Cron runs job on a cron schedule in the local time zone. The spec has the five standard fields: minute, hour, day of
+// month, month and day of week, each of which may be a "*", a value, a range, a step or a list of these. For example,
+// "*/15 9-17 * * 1-5" runs every 15 minutes during working hours. The shorthands @hourly, @daily, @weekly, @monthly and
+// @yearly are also accepted.
+func (s *Scheduler) Cron(spec string, overlap OverlapPolicy, job ScheduledJob) error {
+	schedule, err := parseCron(spec)
+	if err != nil {
+		return err
+	}
+	s.add(&scheduled{
+		job:     job,
+		overlap: overlap,
+		next:    schedule.next,
+	})
+	return nil
+}
+
+// Finish stops scheduling jobs. The pool exits once the runs which are already due have finished.
+func (s *Scheduler) Finish() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		s.queue.close()
+	})
+}
+
+// add starts the timer of a job.
+func (s *Scheduler) add(job *scheduled) {
+	s.init()
+	go func() {
+		clock := s.clock()
+		due := clock.Now()
+		for {
+			due = job.next(due)
+			if due.IsZero() {
+				return
+			}
+			timer := clock.NewTimer(due.Sub(clock.Now()))
+			select {
+			case <-timer.C():
+				s.fire(job)
+			case <-s.stop:
+				timer.Stop()
+				return
+			case <-s.ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+	}()
+}
+
+// fire is called when a job is due.
+func (s *Scheduler) fire(job *scheduled) {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	if !job.running && !job.queued {
+		job.queued = true
+		s.queue.push(job, 0, 0, nil)
+		return
+	}
+	switch job.overlap {
+	case OverlapQueue:
+		job.backlog++
+	case OverlapCancel:
+		if job.running && job.backlog == 0 {
+			job.cancel()
+			job.backlog = 1
+		}
+	}
+}
+
+// work is the ErrWorkHandler used by the underlying WorkPool.
+func (s *Scheduler) work(abort <-chan struct{}) (bool, error) {
+	job, ok := s.queue.pop(abort)
+	if !ok {
+		return false, nil
+	}
+
+	// Runs which became due under OverlapQueue are handled by the same worker, so that they are not lost if Finish is
+	// called in the meantime.
+	var err error
+	for {
+		if runErr := s.run(job); err == nil {
+			err = runErr
+		}
+
+		job.mu.Lock()
+		if job.backlog == 0 || s.ctx.Err() != nil {
+			job.running = false
+			job.mu.Unlock()
+			return true, err
+		}
+		job.backlog--
+		job.mu.Unlock()
+	}
+}
+
+// run calls the job once.
+func (s *Scheduler) run(job *scheduled) error {
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	job.mu.Lock()
+	job.queued = false
+	job.running = true
+	job.cancel = cancel
+	job.mu.Unlock()
+
+	return job.job(ctx.Done())
+}
@@ -0,0 +1,147 @@
+package workpool
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerEvery(t *testing.T) {
+	s := NewScheduler(2)
+	var a, b int32
+	require.NoError(t, s.Every(5*time.Millisecond, OverlapSkip, func(abort <-chan struct{}) error {
+		atomic.AddInt32(&a, 1)
+		return nil
+	}))
+	require.NoError(t, s.Every(10*time.Millisecond, OverlapSkip, func(abort <-chan struct{}) error {
+		atomic.AddInt32(&b, 1)
+		return nil
+	}))
+	s.Start()
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&a) >= 4 && atomic.LoadInt32(&b) >= 2
+	}, time.Second, time.Millisecond)
+	s.Finish()
+	assert.NoError(t, s.Wait())
+}
+
+func TestSchedulerError(t *testing.T) {
+	s := NewScheduler(1)
+	var runs int32
+	require.NoError(t, s.Every(time.Millisecond, OverlapSkip, func(abort <-chan struct{}) error {
+		atomic.AddInt32(&runs, 1)
+		return errors.New("job failed")
+	}))
+	s.Start()
+
+	// The job keeps being scheduled after an error.
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&runs) >= 3 }, time.Second, time.Millisecond)
+	s.Finish()
+	assert.EqualError(t, s.Wait(), "job failed")
+}
+
+func TestSchedulerCronInvalid(t *testing.T) {
+	s := NewScheduler(1)
+	assert.Error(t, s.Cron("every minute", OverlapSkip, func(abort <-chan struct{}) error { return nil }))
+}
+
+func TestSchedulerEveryInvalid(t *testing.T) {
+	s := NewScheduler(1)
+	job := func(abort <-chan struct{}) error { return nil }
+	assert.EqualError(t, s.Every(0, OverlapSkip, job), "workpool: every 0s: interval is not positive")
+	assert.Error(t, s.Every(-time.Second, OverlapSkip, job))
+}
+
+// overlapJob blocks every run until released, recording how many runs start.
+type overlapJob struct {
+	started    chan struct{}
+	release    chan struct{}
+	starts     int32
+	aborted    int32
+	running    int32
+	overlapped int32
+}
+
+func newOverlapJob() *overlapJob {
+	return &overlapJob{
+		started: make(chan struct{}, 100),
+		release: make(chan struct{}),
+	}
+}
+
+func (j *overlapJob) run(abort <-chan struct{}) error {
+	if atomic.AddInt32(&j.running, 1) > 1 {
+		atomic.StoreInt32(&j.overlapped, 1)
+	}
+	defer atomic.AddInt32(&j.running, -1)
+	atomic.AddInt32(&j.starts, 1)
+	j.started <- struct{}{}
+	select {
+	case <-j.release:
+	case <-abort:
+		atomic.AddInt32(&j.aborted, 1)
+	}
+	return nil
+}
+
+func TestSchedulerOverlap(t *testing.T) {
+	const fired = 4
+	tests := []struct {
+		name    string
+		overlap OverlapPolicy
+		starts  int32
+		aborted int32
+	}{
+		{name: "skip", overlap: OverlapSkip, starts: 1},
+		{name: "queue", overlap: OverlapQueue, starts: fired},
+		{name: "cancel", overlap: OverlapCancel, starts: 2, aborted: 1},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			s := NewScheduler(4)
+			job := newOverlapJob()
+			entry := &scheduled{job: job.run, overlap: test.overlap}
+			s.Start()
+
+			// Fire the job by hand so that the test does not depend on timers.
+			s.fire(entry)
+			<-job.started
+			for i := 1; i < fired; i++ {
+				s.fire(entry)
+			}
+			if test.overlap == OverlapCancel {
+				<-job.started
+			}
+			close(job.release)
+			s.Finish()
+			require.NoError(t, s.Wait())
+
+			assert.Equal(t, test.starts, atomic.LoadInt32(&job.starts))
+			assert.Equal(t, test.aborted, atomic.LoadInt32(&job.aborted))
+			assert.Zero(t, atomic.LoadInt32(&job.overlapped))
+		})
+	}
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	s := NewScheduler(1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var once sync.Once
+	require.NoError(t, s.Every(time.Millisecond, OverlapSkip, func(abort <-chan struct{}) error {
+		once.Do(wg.Done)
+		<-abort
+		return nil
+	}))
+	s.Start()
+	wg.Wait()
+	s.Cancel()
+	assert.NoError(t, s.Wait())
+}
@@ -0,0 +1,106 @@
+package workpool
+
+import "iter"
+
+// FromSeq creates a handler which calls fn for each item of seq, until the sequence ends. The workers pull the items
+// one at a time, so seq is never iterated concurrently. A worker waiting for its turn also watches the abort signal,
+// but an iterator which blocks before yielding holds up the worker pulling from it. Errors returned by fn are reported
+// to the pool and the worker keeps going.
+//
+// The iterator is stopped when it ends, or when a worker asking for an item sees that the pool was cancelled, so that
+// it can release its resources. Workers which notice the cancellation between calls exit without asking, so the
+// iterator of a cancelled pool may be left suspended.
+func FromSeq[T any](seq iter.Seq[T], fn func(item T) error) ErrWorkHandler {
+	s := &seqSource[T]{seq: seq, turn: make(chan struct{}, 1)}
+	return func(abort <-chan struct{}) (bool, error) {
+		item, ok := s.pull(abort)
+		if !ok {
+			return false, nil
+		}
+		return true, fn(item)
+	}
+}
+
+// seqSource pulls the items of the sequence of FromSeq for all workers.
+type seqSource[T any] struct {
+	seq iter.Seq[T]
+
+	// turn is held by the worker pulling an item. It is a channel rather than a mutex so that workers waiting for
+	// their turn can give up when the pool is cancelled.
+	turn chan struct{}
+
+	next func() (T, bool)
+	stop func()
+	done bool
+}
+
+// pull returns the next item of the sequence. False is returned once the sequence has ended, or if abort is closed.
+func (s *seqSource[T]) pull(abort <-chan struct{}) (T, bool) {
+	var zero T
+	select {
+	case s.turn <- struct{}{}:
+	default:
+		select {
+		case s.turn <- struct{}{}:
+		case <-abort:
+			return zero, false
+		}
+	}
+	defer func() { <-s.turn }()
+
+	if s.done {
+		return zero, false
+	}
+	select {
+	case <-abort:
+		s.finish()
+		return zero, false
+	default:
+	}
+	if s.next == nil {
+		s.next, s.stop = iter.Pull(s.seq)
+	}
+	item, ok := s.next()
+	if !ok {
+		s.finish()
+	}
+	return item, ok
+}
+
+// finish stops the iterator, so that no more items are pulled.
+func (s *seqSource[T]) finish() {
+	s.done = true
+	if s.stop != nil {
+		s.stop()
+	}
+}
+
+// SubmitSeq submits every item of seq, in order, and returns the number of items submitted. It stops at the first
+// error returned by Submit, such as ErrPoolClosed, and returns it.
+func (p *TypedPool[In, Out]) SubmitSeq(seq iter.Seq[In]) (int, error) {
+	n := 0
+	for item := range seq {
+		if err := p.Submit(item); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// ResultSeq returns the results of the pool as a sequence of values and errors, to be ranged over instead of reading
+// Results. The sequence ends once the pool finishes and may only be ranged over once. If the loop stops early, the
+// remaining results are discarded in the background so that the workers are not blocked.
+func (p *TypedPool[In, Out]) ResultSeq() iter.Seq2[Out, error] {
+	return func(yield func(Out, error) bool) {
+		for r := range p.results {
+			if !yield(r.Value, r.Err) {
+				go func() {
+					for range p.results {
+					}
+				}()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package workpool
+
+import (
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromSeq(t *testing.T) {
+	var sum int64
+	handler := FromSeq(slices.Values([]int{1, 2, 3, 4}), func(item int) error {
+		atomic.AddInt64(&sum, int64(item))
+		if item == 2 {
+			return errors.New("two")
+		}
+		return nil
+	})
+
+	assert.EqualError(t, NewWithError(3, handler).Run(), "two")
+	assert.Equal(t, int64(10), sum)
+}
+
+func TestFromSeqAbort(t *testing.T) {
+	// The sequence never ends, the pool must still stop when cancelled.
+	naturals := func(yield func(int) bool) {
+		for i := 0; yield(i); i++ {
+		}
+	}
+	var pool *WorkPool
+	var calls int64
+	pool = NewWithError(2, FromSeq(naturals, func(item int) error {
+		if atomic.AddInt64(&calls, 1) == 100 {
+			pool.Cancel()
+		}
+		return nil
+	}))
+
+	assert.NoError(t, pool.Run())
+	assert.True(t, pool.Cancelled())
+}
+
+func TestFromSeqStop(t *testing.T) {
+	stopped := false
+	naturals := func(yield func(int) bool) {
+		defer func() { stopped = true }()
+		for i := 0; yield(i); i++ {
+		}
+	}
+	handler := FromSeq(naturals, func(item int) error {
+		return nil
+	})
+
+	abort := make(chan struct{})
+	foundWork, err := handler(abort)
+	assert.True(t, foundWork)
+	assert.NoError(t, err)
+	assert.False(t, stopped)
+
+	close(abort)
+	foundWork, _ = handler(abort)
+	assert.False(t, foundWork)
+	assert.True(t, stopped)
+	foundWork, _ = handler(make(chan struct{}))
+	assert.False(t, foundWork)
+}
+
+func TestTypedPoolSeq(t *testing.T) {
+	pool := NewTypedPool(2, func(abort <-chan struct{}, item int) (int, error) {
+		if item == 3 {
+			return 0, errors.New("three")
+		}
+		return item * 10, nil
+	})
+	pool.Start()
+	go func() {
+		n, err := pool.SubmitSeq(slices.Values([]int{1, 2, 3, 4}))
+		assert.NoError(t, err)
+		assert.Equal(t, 4, n)
+		pool.Finish()
+	}()
+
+	var values []int
+	var errs int
+	for value, err := range pool.ResultSeq() {
+		if err != nil {
+			errs++
+			continue
+		}
+		values = append(values, value)
+	}
+	slices.Sort(values)
+	assert.Equal(t, []int{10, 20, 40}, values)
+	assert.Equal(t, 1, errs)
+	assert.Error(t, pool.Wait())
+
+	n, err := pool.SubmitSeq(slices.Values([]int{5}))
+	assert.ErrorIs(t, err, ErrPoolClosed)
+	assert.Zero(t, n)
+}
+
+func TestTypedPoolResultSeqBreak(t *testing.T) {
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	})
+	pool.Start()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, pool.Submit(i))
+	}
+	pool.Finish()
+
+	for value := range pool.ResultSeq() {
+		assert.Equal(t, 0, value)
+		break
+	}
+	// The remaining results are drained, so the pool can finish.
+	assert.NoError(t, pool.Wait())
+}
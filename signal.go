@@ -0,0 +1,61 @@
+package workpool
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// WaitOrSignal blocks until the pool finishes, stopping it gracefully if the process receives one of signals, which
+// default to SIGINT and SIGTERM. It is Manager.WaitOrSignal for a single pool.
+func WaitOrSignal(pool Pool, grace time.Duration, signals ...os.Signal) int {
+	m := NewManager()
+	_ = m.Add("", pool)
+	return m.WaitOrSignal(grace, signals...)
+}
+
+// WaitOrSignal blocks until every pool has finished and returns an exit status for the process. If one of signals,
+// SIGINT and SIGTERM by default, is received first, the pools are shut down with ShutdownDrain. Pools still running
+// after the grace period, or when a second signal arrives, are cancelled.
+//
+// The status is 0 when the pools finished without errors, including after draining, and 1 when a pool returned an
+// error or had to be cancelled.
+func (m *Manager) WaitOrSignal(grace time.Duration, signals ...os.Signal) int {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	received := make(chan os.Signal, 2)
+	signal.Notify(received, signals...)
+	defer signal.Stop(received)
+	return m.waitOrSignal(grace, received)
+}
+
+// waitOrSignal is WaitOrSignal with the signals delivered on received.
+func (m *Manager) waitOrSignal(grace time.Duration, received <-chan os.Signal) int {
+	finished := make(chan error, 1)
+	go func() {
+		finished <- m.Wait()
+	}()
+
+	var err error
+	select {
+	case err = <-finished:
+	case <-received:
+		ctx, cancel := context.WithTimeout(context.Background(), grace)
+		defer cancel()
+		go func() {
+			select {
+			case <-received:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		err = m.Shutdown(ctx, ShutdownDrain)
+	}
+	if err != nil {
+		return 1
+	}
+	return 0
+}
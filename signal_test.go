@@ -0,0 +1,96 @@
+package workpool
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitOrSignalFinished(t *testing.T) {
+	pool := New(1, func(abort <-chan struct{}) bool { return false })
+	pool.Start()
+	assert.Equal(t, 0, WaitOrSignal(pool, time.Second))
+
+	failing := NewWithError(1, func(abort <-chan struct{}) (bool, error) { return false, assert.AnError })
+	failing.Start()
+	assert.Equal(t, 1, WaitOrSignal(failing, time.Second))
+}
+
+func TestWaitOrSignalDrain(t *testing.T) {
+	release := make(chan struct{})
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		<-release
+		return item, nil
+	})
+	go func() {
+		for range pool.Results() {
+		}
+	}()
+	m := NewManager()
+	require.NoError(t, m.Add("typed", pool))
+	m.Start()
+	require.NoError(t, pool.Submit(1))
+
+	signals := make(chan os.Signal, 1)
+	signals <- os.Interrupt
+	time.AfterFunc(10*time.Millisecond, func() { close(release) })
+	assert.Equal(t, 0, m.waitOrSignal(time.Second, signals))
+	assert.False(t, pool.Cancelled())
+	assert.Equal(t, int64(1), pool.Progress().Done)
+}
+
+func TestWaitOrSignalGrace(t *testing.T) {
+	pool := New(1, func(abort <-chan struct{}) bool {
+		<-abort
+		return false
+	})
+	m := NewManager()
+	require.NoError(t, m.Add("stuck", pool))
+	m.Start()
+
+	signals := make(chan os.Signal, 1)
+	signals <- os.Interrupt
+	assert.Equal(t, 1, m.waitOrSignal(10*time.Millisecond, signals))
+	assert.True(t, pool.Cancelled())
+}
+
+func TestWaitOrSignalSecondSignal(t *testing.T) {
+	pool := New(1, func(abort <-chan struct{}) bool {
+		<-abort
+		return false
+	})
+	m := NewManager()
+	require.NoError(t, m.Add("stuck", pool))
+	m.Start()
+
+	signals := make(chan os.Signal, 2)
+	signals <- os.Interrupt
+	signals <- os.Interrupt
+	start := time.Now()
+	assert.Equal(t, 1, m.waitOrSignal(time.Hour, signals))
+	assert.True(t, pool.Cancelled())
+	assert.Less(t, time.Since(start), time.Minute)
+}
+
+func TestWaitOrSignalProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signals cannot be sent to a process on windows")
+	}
+	pool := New(1, func(abort <-chan struct{}) bool {
+		<-abort
+		return false
+	})
+	pool.Start()
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+
+	time.AfterFunc(10*time.Millisecond, func() {
+		assert.NoError(t, process.Signal(os.Interrupt))
+	})
+	assert.Equal(t, 1, WaitOrSignal(pool, 10*time.Millisecond, os.Interrupt))
+	assert.True(t, pool.Cancelled())
+}
@@ -0,0 +1,291 @@
+// Package sqssource feeds messages from an AWS SQS queue to WorkPool workers. Messages are received with long polling,
+// deleted once they have been processed successfully, and their visibility timeout is extended while a handler is
+// still working on them. They are either processed by the workers of a WorkPool, see Handler, or submitted to a
+// TypedPool as Messages, see Feed, which acknowledges them through workpool.Acker.
+//
+// It is a separate module so that the workpool package itself does not depend on the AWS SDK.
+package sqssource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/algorand/workpool"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// API is the part of *sqs.Client used by Source.
+type API interface {
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+	ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error)
+}
+
+// Message is a received message which implements workpool.Acker, so that a TypedPool acknowledges it.
+type Message struct {
+	types.Message
+
+	source *Source
+}
+
+// Ack deletes the message from the queue.
+func (m Message) Ack() error {
+	if _, err := m.source.Client.DeleteMessage(context.Background(), &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(m.source.QueueURL),
+		ReceiptHandle: m.ReceiptHandle,
+	}); err != nil {
+		return fmt.Errorf("sqssource: delete message: %w", err)
+	}
+	return nil
+}
+
+// Nack makes the message visible again straight away if requeue is set. Otherwise it is left alone, it becomes visible
+// again once its visibility timeout expires, or is moved to a dead-letter queue by the redrive policy of the queue.
+func (m Message) Nack(requeue bool) error {
+	if !requeue {
+		return nil
+	}
+	if _, err := m.source.Client.ChangeMessageVisibility(context.Background(), &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(m.source.QueueURL),
+		ReceiptHandle:     m.ReceiptHandle,
+		VisibilityTimeout: 0,
+	}); err != nil {
+		return fmt.Errorf("sqssource: change message visibility: %w", err)
+	}
+	return nil
+}
+
+// Source receives messages from a queue. The messages are processed by the handler returned from Handler.
+type Source struct {
+	// Client is used to call SQS.
+	Client API
+
+	// QueueURL is the queue to receive messages from.
+	QueueURL string
+
+	// MaxMessages is the most messages requested by each receive call, between 1 and 10. Zero requests up to 10. Fewer
+	// are requested when fewer workers are waiting for a message, so that received messages do not sit in a buffer
+	// while their visibility timeout runs out.
+	MaxMessages int32
+
+	// WaitTime is how long a receive call waits for messages to arrive. Zero waits for 20 seconds, the maximum.
+	WaitTime time.Duration
+
+	// VisibilityTimeout, when set, is the visibility timeout requested for received messages, rounded up to whole
+	// seconds as SQS expects. While a handler is processing a message its visibility timeout is extended every half of
+	// this duration. When zero the queue's default timeout is used and it is not extended.
+	VisibilityTimeout time.Duration
+
+	// ErrorBackoff is how long a worker waits after a failed receive call before trying again. Zero waits one second.
+	ErrorBackoff time.Duration
+
+	mu       sync.Mutex
+	buffered []types.Message
+
+	// waiting counts the workers waiting for a message, turn lets one of them receive at a time.
+	waiting  atomic.Int32
+	turnOnce sync.Once
+	turn     chan struct{}
+}
+
+// Handler creates a WorkHandler which calls fn for each message. The message is deleted if fn returns nil, otherwise
+// it becomes visible again once its visibility timeout expires. The context given to fn is cancelled when the pool is
+// cancelled. Errors, including failures to extend the visibility timeout, are reported to the pool and the worker
+// keeps going until the pool is cancelled.
+func (s *Source) Handler(fn func(ctx context.Context, message types.Message) error) workpool.ErrWorkHandler {
+	return func(abort <-chan struct{}) (bool, error) {
+		ctx, cancel := workpool.AbortContext(abort)
+		defer cancel()
+
+		message, more, err := s.receive(ctx, abort)
+		if message.source == nil {
+			return more, err
+		}
+
+		err, extendErr := s.process(ctx, message.Message, fn)
+		if err != nil {
+			return true, errors.Join(err, message.Nack(false), extendErr)
+		}
+		return true, errors.Join(message.Ack(), extendErr)
+	}
+}
+
+// Feed creates a WorkHandler which passes each message to submit as a Message, usually the Submit method of a
+// TypedPool which then acknowledges it:
+//
+//	pool := workpool.NewTypedPool(4, process)
+//	pool.RequeueFailed = true
+//	pool.Start()
+//	feeder := workpool.NewWithError(1, source.Feed(pool.Submit))
+//
+// The visibility timeout of a fed message is not extended, so it has to cover the time the message waits in the queue
+// of the pool as well as its processing. A message which cannot be submitted because the pool was closed is made
+// visible again, and the worker stops. Other errors are reported to the pool like those of Handler.
+func (s *Source) Feed(submit func(Message) error) workpool.ErrWorkHandler {
+	return func(abort <-chan struct{}) (bool, error) {
+		ctx, cancel := workpool.AbortContext(abort)
+		defer cancel()
+
+		message, more, err := s.receive(ctx, abort)
+		if message.source == nil {
+			return more, err
+		}
+		if err := submit(message); err != nil {
+			closed := errors.Is(err, workpool.ErrPoolClosed)
+			if nackErr := message.Nack(true); nackErr != nil {
+				return !closed, fmt.Errorf("sqssource: requeue after %v: %w", err, nackErr)
+			}
+			if closed {
+				return false, nil
+			}
+			return true, err
+		}
+		return true, nil
+	}
+}
+
+// receive waits for a message. Without a message it returns whether the handler should be called again, and the error
+// to report, waiting for ErrorBackoff after errors.
+func (s *Source) receive(ctx context.Context, abort <-chan struct{}) (Message, bool, error) {
+	message, ok, err := s.next(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return Message{}, false, nil
+		}
+		s.backoff(abort)
+		return Message{}, true, err
+	}
+	if !ok {
+		return Message{}, ctx.Err() == nil, nil
+	}
+	return Message{Message: message, source: s}, true, nil
+}
+
+// next returns a buffered message, or receives more. False is returned if the receive call found no messages. One
+// worker receives at a time, for itself and the other waiting workers, which take the rest of the messages from the
+// buffer.
+func (s *Source) next(ctx context.Context) (types.Message, bool, error) {
+	s.waiting.Add(1)
+	defer s.waiting.Add(-1)
+	if message, ok := s.take(); ok {
+		return message, true, nil
+	}
+
+	s.turnOnce.Do(func() { s.turn = make(chan struct{}, 1) })
+	select {
+	case s.turn <- struct{}{}:
+	case <-ctx.Done():
+		return types.Message{}, false, ctx.Err()
+	}
+	defer func() { <-s.turn }()
+	// Another worker may have received messages while this one waited for its turn.
+	if message, ok := s.take(); ok {
+		return message, true, nil
+	}
+
+	limit := s.MaxMessages
+	if limit <= 0 {
+		limit = 10
+	}
+	s.mu.Lock()
+	wanted := s.waiting.Load() - int32(len(s.buffered))
+	s.mu.Unlock()
+	input := &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(s.QueueURL),
+		MaxNumberOfMessages: min(max(wanted, 1), limit),
+		WaitTimeSeconds:     int32(s.WaitTime / time.Second),
+	}
+	if s.WaitTime == 0 {
+		input.WaitTimeSeconds = 20
+	}
+	if s.VisibilityTimeout > 0 {
+		input.VisibilityTimeout = s.visibilitySeconds()
+	}
+	output, err := s.Client.ReceiveMessage(ctx, input)
+	if err != nil {
+		return types.Message{}, false, fmt.Errorf("sqssource: receive message: %w", err)
+	}
+	if len(output.Messages) == 0 {
+		return types.Message{}, false, nil
+	}
+
+	s.mu.Lock()
+	s.buffered = append(s.buffered, output.Messages[1:]...)
+	s.mu.Unlock()
+	return output.Messages[0], true, nil
+}
+
+// take removes a message from the buffer.
+func (s *Source) take() (types.Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.buffered) == 0 {
+		return types.Message{}, false
+	}
+	message := s.buffered[0]
+	s.buffered = s.buffered[1:]
+	return message, true
+}
+
+// process calls fn, extending the visibility timeout of message until it returns. The error of fn is returned along
+// with the first error extending the timeout.
+func (s *Source) process(ctx context.Context, message types.Message, fn func(context.Context, types.Message) error) (err, extendErr error) {
+	if s.VisibilityTimeout <= 0 {
+		return fn(ctx, message), nil
+	}
+
+	done := make(chan struct{})
+	extended := make(chan struct{})
+	go func() {
+		defer close(extended)
+		ticker := time.NewTicker(s.VisibilityTimeout / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_, err := s.Client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+					QueueUrl:          aws.String(s.QueueURL),
+					ReceiptHandle:     message.ReceiptHandle,
+					VisibilityTimeout: s.visibilitySeconds(),
+				})
+				if err != nil && extendErr == nil && ctx.Err() == nil {
+					extendErr = fmt.Errorf("sqssource: change message visibility: %w", err)
+				}
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	err = fn(ctx, message)
+	close(done)
+	<-extended
+	return err, extendErr
+}
+
+// visibilitySeconds returns VisibilityTimeout in whole seconds, rounded up.
+func (s *Source) visibilitySeconds() int32 {
+	return int32((s.VisibilityTimeout + time.Second - 1) / time.Second)
+}
+
+// backoff waits after a failed receive call.
+func (s *Source) backoff(abort <-chan struct{}) {
+	d := s.ErrorBackoff
+	if d == 0 {
+		d = time.Second
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-abort:
+	}
+}
@@ -0,0 +1,206 @@
+package sqssource
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/algorand/workpool"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeQueue implements API with an in memory list of messages.
+type fakeQueue struct {
+	mu          sync.Mutex
+	messages    []types.Message
+	receives    int
+	deleted     []string
+	extended    map[string]int
+	visibility  []int32
+	requested   []int32
+	receiveErrs []error
+	extendErr   error
+}
+
+func newFakeQueue(bodies ...string) *fakeQueue {
+	q := &fakeQueue{extended: make(map[string]int)}
+	for _, body := range bodies {
+		q.messages = append(q.messages, types.Message{Body: aws.String(body), ReceiptHandle: aws.String("r-" + body)})
+	}
+	return q
+}
+
+func (q *fakeQueue) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	q.mu.Lock()
+	q.receives++
+	q.requested = append(q.requested, params.MaxNumberOfMessages)
+	q.visibility = append(q.visibility, params.VisibilityTimeout)
+	if len(q.receiveErrs) > 0 {
+		err := q.receiveErrs[0]
+		q.receiveErrs = q.receiveErrs[1:]
+		q.mu.Unlock()
+		return nil, err
+	}
+	n := int(params.MaxNumberOfMessages)
+	if n > len(q.messages) {
+		n = len(q.messages)
+	}
+	messages := q.messages[:n]
+	q.messages = q.messages[n:]
+	q.mu.Unlock()
+
+	if len(messages) == 0 {
+		// Long poll until cancelled.
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &sqs.ReceiveMessageOutput{Messages: messages}, nil
+}
+
+func (q *fakeQueue) DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.deleted = append(q.deleted, *params.ReceiptHandle)
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (q *fakeQueue) ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.extended[*params.ReceiptHandle]++
+	q.visibility = append(q.visibility, params.VisibilityTimeout)
+	if q.extendErr != nil {
+		return nil, q.extendErr
+	}
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func (q *fakeQueue) deletedCount() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.deleted)
+}
+
+func TestSource(t *testing.T) {
+	queue := newFakeQueue("a", "b", "bad", "c")
+	source := &Source{Client: queue, QueueURL: "https://queue", MaxMessages: 3}
+
+	pool := workpool.NewWithError(2, source.Handler(func(ctx context.Context, message types.Message) error {
+		if *message.Body == "bad" {
+			return errors.New("bad message")
+		}
+		return nil
+	}))
+	pool.Start()
+
+	assert.Eventually(t, func() bool { return queue.deletedCount() == 3 }, time.Second, time.Millisecond)
+	pool.Cancel()
+	assert.EqualError(t, pool.Wait(), "bad message")
+	assert.ElementsMatch(t, []string{"r-a", "r-b", "r-c"}, queue.deleted)
+}
+
+func TestSourceExtendsVisibility(t *testing.T) {
+	queue := newFakeQueue("slow")
+	source := &Source{Client: queue, QueueURL: "https://queue", VisibilityTimeout: 20 * time.Millisecond}
+
+	pool := workpool.NewWithError(1, source.Handler(func(ctx context.Context, message types.Message) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}))
+	pool.Start()
+
+	assert.Eventually(t, func() bool { return queue.deletedCount() == 1 }, time.Second, time.Millisecond)
+	pool.Cancel()
+	assert.NoError(t, pool.Wait())
+	assert.GreaterOrEqual(t, queue.extended["r-slow"], 2)
+	for _, seconds := range queue.visibility {
+		assert.Equal(t, int32(1), seconds, "sub-second timeouts are rounded up")
+	}
+}
+
+func TestSourceExtendError(t *testing.T) {
+	queue := newFakeQueue("slow")
+	queue.extendErr = errors.New("receipt handle expired")
+	source := &Source{Client: queue, QueueURL: "https://queue", VisibilityTimeout: 20 * time.Millisecond}
+
+	pool := workpool.NewWithError(1, source.Handler(func(ctx context.Context, message types.Message) error {
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	}))
+	pool.Start()
+
+	assert.Eventually(t, func() bool { return queue.deletedCount() == 1 }, time.Second, time.Millisecond)
+	pool.Cancel()
+	assert.ErrorContains(t, pool.Wait(), "receipt handle expired")
+}
+
+func TestSourceReceivesForWaitingWorkers(t *testing.T) {
+	queue := newFakeQueue("a", "b", "c", "d")
+	source := &Source{Client: queue, QueueURL: "https://queue"}
+
+	pool := workpool.NewWithError(1, source.Handler(func(ctx context.Context, message types.Message) error {
+		return nil
+	}))
+	pool.Start()
+
+	assert.Eventually(t, func() bool { return queue.deletedCount() == 4 }, time.Second, time.Millisecond)
+	pool.Cancel()
+	assert.NoError(t, pool.Wait())
+	// A single worker never has messages waiting in the buffer.
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	for _, n := range queue.requested {
+		assert.Equal(t, int32(1), n)
+	}
+}
+
+func TestSourceReceiveError(t *testing.T) {
+	queue := newFakeQueue("a")
+	queue.receiveErrs = []error{errors.New("throttled")}
+	source := &Source{Client: queue, QueueURL: "https://queue", ErrorBackoff: time.Millisecond}
+
+	pool := workpool.NewWithError(1, source.Handler(func(ctx context.Context, message types.Message) error {
+		return nil
+	}))
+	pool.Start()
+
+	assert.Eventually(t, func() bool { return queue.deletedCount() == 1 }, time.Second, time.Millisecond)
+	pool.Cancel()
+	assert.ErrorContains(t, pool.Wait(), "throttled")
+}
+
+func TestSourceFeed(t *testing.T) {
+	queue := newFakeQueue("a", "bad", "b")
+	source := &Source{Client: queue, QueueURL: "https://queue"}
+
+	pool := workpool.NewTypedPool(1, func(abort <-chan struct{}, message Message) (string, error) {
+		if *message.Body == "bad" {
+			return "", errors.New("bad message")
+		}
+		return *message.Body, nil
+	})
+	pool.RequeueFailed = true
+	pool.Start()
+	go func() {
+		for range pool.Results() {
+		}
+	}()
+	feeder := workpool.NewWithError(1, source.Feed(pool.Submit))
+	feeder.Start()
+
+	assert.Eventually(t, func() bool { return queue.deletedCount() == 2 }, time.Second, time.Millisecond)
+	feeder.Cancel()
+	assert.NoError(t, feeder.Wait())
+	pool.Finish()
+	assert.EqualError(t, pool.Wait(), "bad message")
+
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	assert.ElementsMatch(t, []string{"r-a", "r-b"}, queue.deleted)
+	assert.Equal(t, map[string]int{"r-bad": 1}, queue.extended, "the failed message is made visible again")
+}
@@ -0,0 +1,88 @@
+package workpool
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Stall describes a pool in which no handler call has returned for StallTimeout, see OnStall.
+type Stall struct {
+	// Since is when a handler call last returned, or when the pool started if none has.
+	Since time.Time
+
+	// Workers is the number of running workers, Busy is how many of them are in a handler call.
+	Workers int
+	Busy    int
+
+	// Queued is the number of items waiting for a worker in a pool which owns its queue, such as a TypedPool.
+	Queued int
+}
+
+// startStallDetector starts checking that handler calls keep returning while there is work. It returns a function
+// which stops the checks. The caller must hold p.mu.
+func (p *WorkPool) startStallDetector() (stop func()) {
+	if p.StallTimeout <= 0 {
+		return func() {}
+	}
+	quit := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		clock := p.clock()
+		// reported is the last return of the stall which was reported, so that a stall is reported once.
+		var reported int64 = -1
+		for {
+			timer := clock.NewTimer(p.StallTimeout / 4)
+			select {
+			case <-timer.C():
+				p.checkStall(clock.Now(), &reported)
+			case <-quit:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+	return func() {
+		close(quit)
+		<-stopped
+	}
+}
+
+// checkStall reports a stall if no handler call has returned for StallTimeout, unless the pool is cancelled, paused
+// or idle.
+func (p *WorkPool) checkStall(now time.Time, reported *int64) {
+	last := atomic.LoadInt64(&p.counters.lastReturn)
+	if last == *reported || p.Cancelled() || p.Paused() || (p.idle != nil && p.idle()) {
+		return
+	}
+
+	p.mu.Lock()
+	since := p.started
+	stall := Stall{Workers: len(p.workers)}
+	for _, w := range p.workers {
+		if w.calling.Load() != 0 {
+			stall.Busy++
+		}
+	}
+	running := p.running
+	p.mu.Unlock()
+	if last != 0 {
+		since = time.Unix(0, last)
+	}
+	if !running || stall.Workers == 0 || now.Sub(since) < p.StallTimeout {
+		return
+	}
+	stall.Since = since
+	if p.demand != nil {
+		_, stall.Queued, _ = p.demand()
+	}
+
+	*reported = last
+	if p.OnStall != nil {
+		p.OnStall(stall)
+		return
+	}
+	p.log(slog.LevelWarn, "workpool stalled", "since", stall.Since, "workers", stall.Workers, "busy", stall.Busy,
+		"queued", stall.Queued)
+}
@@ -0,0 +1,70 @@
+package workpool
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStall(t *testing.T) {
+	var mu sync.Mutex
+	var stalls []Stall
+	pool := NewTypedPool(2, func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	})
+	pool.StallTimeout = 20 * time.Millisecond
+	pool.OnStall = func(stall Stall) {
+		mu.Lock()
+		defer mu.Unlock()
+		stalls = append(stalls, stall)
+	}
+	pool.Start()
+	// Nobody reads the results, so the workers block sending them.
+	for i := 0; i < 5; i++ {
+		require.NoError(t, pool.Submit(i))
+	}
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(stalls) > 0
+	}, time.Second, time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	mu.Lock()
+	require.Len(t, stalls, 1, "a stall is reported once")
+	stall := stalls[0]
+	mu.Unlock()
+	assert.Equal(t, 2, stall.Workers)
+	assert.Equal(t, 2, stall.Busy)
+	assert.Equal(t, 3, stall.Queued)
+
+	pool.Finish()
+	count := 0
+	for range pool.Results() {
+		count++
+	}
+	assert.Equal(t, 5, count)
+}
+
+func TestStallIdle(t *testing.T) {
+	stalled := make(chan Stall, 1)
+	pool := NewTypedPool(2, func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	})
+	pool.StallTimeout = 10 * time.Millisecond
+	pool.OnStall = func(stall Stall) { stalled <- stall }
+	pool.Start()
+
+	// Waiting for items is not a stall.
+	time.Sleep(40 * time.Millisecond)
+	pool.Pause()
+	time.Sleep(20 * time.Millisecond)
+	pool.Resume()
+	pool.Finish()
+	for range pool.Results() {
+	}
+	assert.Empty(t, stalled)
+}
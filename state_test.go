@@ -0,0 +1,72 @@
+package workpool
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerState(t *testing.T) {
+	var mu sync.Mutex
+	created := make(map[int]*bytes.Buffer)
+	var cleaned int32
+	var remaining int32 = 100
+
+	pool := NewWithState(3, func(workerID int) (any, func()) {
+		buf := &bytes.Buffer{}
+		mu.Lock()
+		created[workerID] = buf
+		mu.Unlock()
+		return buf, func() { atomic.AddInt32(&cleaned, 1) }
+	}, func(state any, abort <-chan struct{}) bool {
+		if atomic.AddInt32(&remaining, -1) < 0 {
+			return false
+		}
+		state.(*bytes.Buffer).WriteByte('x')
+		return true
+	})
+
+	assert.NoError(t, pool.Run())
+	assert.Len(t, created, 3)
+	assert.Equal(t, int32(3), cleaned)
+	total := 0
+	for _, buf := range created {
+		total += buf.Len()
+	}
+	assert.Equal(t, 100, total)
+}
+
+func TestWorkerStateRecycled(t *testing.T) {
+	var created, cleaned int32
+	var calls int32
+	pool := NewWithState(1, func(workerID int) (any, func()) {
+		atomic.AddInt32(&created, 1)
+		return workerID, func() { atomic.AddInt32(&cleaned, 1) }
+	}, func(state any, abort <-chan struct{}) bool {
+		return atomic.AddInt32(&calls, 1) < 6
+	})
+	pool.MaxTasksPerWorker = 2
+
+	assert.NoError(t, pool.Run())
+	assert.Equal(t, int32(3), created)
+	assert.Equal(t, created, cleaned)
+}
+
+func TestWorkerStateContext(t *testing.T) {
+	var states []any
+	pool := NewWithContext(1, func(ctx context.Context) bool {
+		states = append(states, State(ctx))
+		return false
+	})
+	pool.WorkerState = func(workerID int) (any, func()) {
+		return "conn", nil
+	}
+
+	assert.NoError(t, pool.Run())
+	assert.Equal(t, []any{"conn"}, states)
+	assert.Nil(t, State(context.Background()))
+}
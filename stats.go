@@ -0,0 +1,111 @@
+package workpool
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the pool's activity, see WorkPool.Stats.
+type Stats struct {
+	// ActiveWorkers is the number of running workers.
+	ActiveWorkers int
+
+	// RetiringWorkers is the number of workers which were asked to stop and are finishing their current handler call.
+	RetiringWorkers int
+
+	// Invocations is the total number of handler calls.
+	Invocations int64
+
+	// Finished is the number of handler calls which returned false.
+	Finished int64
+
+	// Timeouts is the number of handler calls which exceeded TaskTimeout.
+	Timeouts int64
+
+	// Duration is how long the pool has been running, or how long the last run took once it has finished.
+	Duration time.Duration
+
+	// Cancelled is true once the pool has been cancelled.
+	Cancelled bool
+
+	// Running is true from Start until the pool finishes.
+	Running bool
+
+	// The queue counters are set for pools which queue their work, such as TypedPool, BatchPool and Executor, and are
+	// zero for other pools.
+
+	// Queued is the number of items waiting in the queue.
+	Queued int
+
+	// MaxQueued is the largest number of items which were waiting in the queue at once.
+	MaxQueued int
+
+	// Enqueued and Dequeued are the total numbers of items added to the queue and taken from it by workers.
+	Enqueued int64
+	Dequeued int64
+
+	// SubmitBlocked is the total time that submitting items spent waiting for room in a full queue.
+	SubmitBlocked time.Duration
+}
+
+// counters track handler calls. They are kept in their own allocation so that the 64-bit atomic operations are
+// aligned on 32-bit platforms.
+type counters struct {
+	invocations int64
+	finished    int64
+	timeouts    int64
+
+	// lastReturn is when a handler call last returned in Unix nanoseconds.
+	lastReturn int64
+
+	// lastWork is when a handler call last returned having found work in Unix nanoseconds.
+	lastWork int64
+
+	// done and total are the completed and expected tasks for Progress.
+	done       int64
+	total      int64
+	throughput throughput
+}
+
+// record counts a single handler call.
+func (c *counters) record(foundWork bool) {
+	atomic.AddInt64(&c.invocations, 1)
+	if !foundWork {
+		atomic.AddInt64(&c.finished, 1)
+	}
+}
+
+// Stats returns a snapshot of the pool's activity. It is safe to call at any time, including while the pool is running.
+func (p *WorkPool) Stats() Stats {
+	p.init()
+	p.mu.Lock()
+	stats := Stats{
+		ActiveWorkers:   len(p.workers),
+		RetiringWorkers: len(p.retiring),
+	}
+	stats.Running = p.running
+	switch {
+	case p.running:
+		stats.Duration = p.since(p.started)
+	case !p.started.IsZero():
+		stats.Duration = p.stopped.Sub(p.started)
+	}
+	p.mu.Unlock()
+
+	stats.Invocations = atomic.LoadInt64(&p.counters.invocations)
+	stats.Finished = atomic.LoadInt64(&p.counters.finished)
+	stats.Timeouts = atomic.LoadInt64(&p.counters.timeouts)
+	stats.Cancelled = p.Cancelled()
+	if p.queueStats != nil {
+		p.queueStats(&stats)
+	}
+	return stats
+}
+
+// trackQueue makes the queue of a pool which owns one report to the Stats and the MetricsSink of the pool.
+func trackQueue[T any](p *WorkPool, q *queue[T]) {
+	p.queueStats = q.stats
+	q.metrics = func() MetricsSink {
+		return p.Metrics
+	}
+}
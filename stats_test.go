@@ -0,0 +1,105 @@
+package workpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	numInputs := 10
+	inputs := make(chan int, numInputs)
+	for i := 0; i < numInputs; i++ {
+		inputs <- i
+	}
+	close(inputs)
+
+	worker := func(abort <-chan struct{}) bool {
+		for range inputs {
+			time.Sleep(time.Millisecond)
+			return true
+		}
+		return false
+	}
+	pool := New(3, worker)
+	assert.Equal(t, Stats{}, pool.Stats())
+
+	assert.NoError(t, pool.Run())
+	stats := pool.Stats()
+	assert.Equal(t, 0, stats.ActiveWorkers)
+	assert.Equal(t, int64(numInputs+3), stats.Invocations)
+	assert.Equal(t, int64(3), stats.Finished)
+	assert.False(t, stats.Cancelled)
+	assert.Greater(t, stats.Duration, 3*time.Millisecond)
+
+	// The duration is fixed once the pool finishes.
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, stats.Duration, pool.Stats().Duration)
+}
+
+func TestStatsWhileRunning(t *testing.T) {
+	worker := func(abort <-chan struct{}) bool {
+		<-abort
+		return false
+	}
+	pool := New(2, worker)
+
+	finished := make(chan struct{})
+	go func() {
+		pool.Run()
+		close(finished)
+	}()
+
+	assert.Eventually(t, func() bool { return pool.Stats().ActiveWorkers == 2 }, time.Second, time.Millisecond)
+	pool.Cancel()
+	<-finished
+
+	stats := pool.Stats()
+	assert.True(t, stats.Cancelled)
+	assert.Equal(t, int64(2), stats.Invocations)
+}
+
+func TestQueueStats(t *testing.T) {
+	release := make(chan struct{})
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		<-release
+		return item, nil
+	})
+	pool.QueueSize = 2
+	pool.Start()
+	go func() {
+		for range pool.Results() {
+		}
+	}()
+
+	submitted := make(chan struct{})
+	go func() {
+		defer close(submitted)
+		for i := 0; i < 4; i++ {
+			assert.NoError(t, pool.Submit(i))
+		}
+	}()
+	// The worker holds item 0, items 1 and 2 fill the queue and item 3 waits for room.
+	require.Eventually(t, func() bool {
+		stats := pool.Stats()
+		return stats.Dequeued == 1 && stats.Queued == 2
+	}, time.Second, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	close(release)
+	<-submitted
+	pool.Finish()
+	require.NoError(t, pool.Wait())
+
+	stats := pool.Stats()
+	assert.Equal(t, 0, stats.Queued)
+	assert.Equal(t, 2, stats.MaxQueued)
+	assert.Equal(t, int64(4), stats.Enqueued)
+	assert.Equal(t, int64(4), stats.Dequeued)
+	assert.GreaterOrEqual(t, stats.SubmitBlocked, 5*time.Millisecond)
+
+	plain := New(1, func(abort <-chan struct{}) bool { return false })
+	assert.NoError(t, plain.Run())
+	assert.Zero(t, plain.Stats().Enqueued)
+}
@@ -0,0 +1,87 @@
+// Package statsd sends the metrics of a workpool.WorkPool to a StatsD server, or a Datadog agent, over UDP.
+//
+// It implements workpool.MetricsSink using only the standard library:
+//
+//	client, err := statsd.New("localhost:8125", "myservice.workpool")
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//	pool.Metrics = client
+package statsd
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Client is a workpool.MetricsSink which sends every metric as a StatsD datagram. Sending is best effort: datagrams
+// which cannot be written are dropped and counted by Dropped.
+type Client struct {
+	// Tags are added to every metric with the Datadog extension, for example "pool:images". They must be set before
+	// the client is used.
+	Tags []string
+
+	conn    net.Conn
+	prefix  string
+	dropped atomic.Int64
+}
+
+// New creates a Client sending to the StatsD server at addr. Metric names are prefixed with prefix and a dot, unless
+// prefix is empty.
+func New(addr, prefix string) (*Client, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if prefix != "" {
+		prefix += "."
+	}
+	return &Client{conn: conn, prefix: prefix}, nil
+}
+
+// Counter sends a counter, of type "c".
+func (c *Client) Counter(name string, delta int64) {
+	c.send(name, strconv.FormatInt(delta, 10), "c")
+}
+
+// Gauge sends a gauge, of type "g".
+func (c *Client) Gauge(name string, value float64) {
+	c.send(name, strconv.FormatFloat(value, 'f', -1, 64), "g")
+}
+
+// Timing sends a timing in milliseconds, of type "ms".
+func (c *Client) Timing(name string, d time.Duration) {
+	c.send(name, strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64), "ms")
+}
+
+// Dropped returns the number of metrics which could not be sent.
+func (c *Client) Dropped() int64 {
+	return c.dropped.Load()
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// send writes a single metric.
+func (c *Client) send(name, value, kind string) {
+	var b strings.Builder
+	b.WriteString(c.prefix)
+	b.WriteString(name)
+	b.WriteByte(':')
+	b.WriteString(value)
+	b.WriteByte('|')
+	b.WriteString(kind)
+	if len(c.Tags) > 0 {
+		b.WriteString("|#")
+		b.WriteString(strings.Join(c.Tags, ","))
+	}
+	if _, err := c.conn.Write([]byte(b.String())); err != nil {
+		c.dropped.Add(1)
+	}
+}
@@ -0,0 +1,88 @@
+package statsd
+
+import (
+	"net"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/algorand/workpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listen starts a UDP server and returns its address and a function which reads n datagrams.
+func listen(t *testing.T) (string, func(n int) []string) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn.LocalAddr().String(), func(n int) []string {
+		var got []string
+		buf := make([]byte, 1024)
+		for i := 0; i < n; i++ {
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+			size, _, err := conn.ReadFrom(buf)
+			require.NoError(t, err)
+			got = append(got, string(buf[:size]))
+		}
+		return got
+	}
+}
+
+func TestClient(t *testing.T) {
+	addr, read := listen(t)
+	client, err := New(addr, "svc")
+	require.NoError(t, err)
+	defer client.Close()
+
+	client.Counter("invocations", 3)
+	client.Gauge("workers", 2.5)
+	client.Timing("duration", 1500*time.Microsecond)
+	client.Tags = []string{"pool:images", "env:test"}
+	client.Counter("errors", 1)
+
+	assert.Equal(t, []string{
+		"svc.invocations:3|c",
+		"svc.workers:2.5|g",
+		"svc.duration:1.5|ms",
+		"svc.errors:1|c|#pool:images,env:test",
+	}, read(4))
+	assert.Zero(t, client.Dropped())
+}
+
+func TestClientPool(t *testing.T) {
+	addr, read := listen(t)
+	client, err := New(addr, "")
+	require.NoError(t, err)
+	defer client.Close()
+
+	calls := 0
+	pool := workpool.NewWithError(1, func(abort <-chan struct{}) (bool, error) {
+		calls++
+		if calls == 1 {
+			return true, assert.AnError
+		}
+		return false, nil
+	})
+	pool.Metrics = client
+	assert.Error(t, pool.Run())
+
+	got := read(8)
+	for i, metric := range got {
+		if len(metric) > 9 && metric[:9] == "duration:" {
+			assert.Contains(t, metric, "|ms")
+			got[i] = "duration"
+		}
+	}
+	sort.Strings(got)
+	assert.Equal(t, []string{
+		"duration",
+		"duration",
+		"errors:1|c",
+		"finished:1|c",
+		"invocations:1|c",
+		"invocations:1|c",
+		"workers:0|g",
+		"workers:1|g",
+	}, got)
+}
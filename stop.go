@@ -0,0 +1,84 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+)
+
+// StopReason is why a pool stopped, see WorkPool.StopReason.
+type StopReason int
+
+const (
+	// StopNone means that the pool has not stopped, it was not started or is still running.
+	StopNone StopReason = iota
+	// StopExhausted means that the handlers ran out of work, including a pool which drained after Finish.
+	StopExhausted
+	// StopCancelled means that the pool was cancelled by Cancel, CancelWithCause or the context given to RunContext.
+	StopCancelled
+	// StopDeadline means that Deadline or MaxRuntime expired, or the deadline of the context given to RunContext.
+	StopDeadline
+	// StopIdle means that the pool shut itself down because of IdleTimeout.
+	StopIdle
+	// StopFailed means that the pool was cancelled by one of its own errors: an invalid configuration, resources
+	// which could not be opened, or a handler error with CancelOnError. It also means that the pool ran out of workers
+	// because of errors, workers which failed OnWorkerStart or a fatal error after their last Supervisor restart.
+	StopFailed
+)
+
+// String returns the name of the reason.
+func (r StopReason) String() string {
+	switch r {
+	case StopNone:
+		return "none"
+	case StopExhausted:
+		return "exhausted"
+	case StopCancelled:
+		return "cancelled"
+	case StopDeadline:
+		return "deadline exceeded"
+	case StopIdle:
+		return "idle timeout"
+	case StopFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// StopReason returns why the pool stopped, or StopNone until it has finished, that is until Wait returns.
+func (p *WorkPool) StopReason() StopReason {
+	p.init()
+	select {
+	case <-p.done:
+	default:
+		return StopNone
+	}
+	return p.stopReason()
+}
+
+// WaitReason is like Wait, but also returns why the pool stopped, so that callers can tell a pool which ran out of work
+// from one which was cancelled or timed out without inspecting the error.
+func (p *WorkPool) WaitReason() (StopReason, error) {
+	err := p.Wait()
+	return p.StopReason(), err
+}
+
+// stopReason works out why the pool stopped from the cause of its cancellation.
+func (p *WorkPool) stopReason() StopReason {
+	cause := context.Cause(p.ctx)
+	p.errMu.Lock()
+	first := p.err
+	p.errMu.Unlock()
+	switch {
+	case cause == nil && first != nil && p.workerFailed.Load():
+		return StopFailed
+	case cause == nil:
+		return StopExhausted
+	case errors.Is(cause, ErrIdleTimeout):
+		return StopIdle
+	case errors.Is(cause, context.DeadlineExceeded):
+		return StopDeadline
+	case first != nil && errors.Is(cause, first):
+		return StopFailed
+	}
+	return StopCancelled
+}
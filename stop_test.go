@@ -0,0 +1,190 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStopReason(t *testing.T) {
+	blocking := func(abort <-chan struct{}) bool {
+		<-abort
+		return false
+	}
+	for _, tc := range []struct {
+		name   string
+		run    func() (StopReason, error)
+		reason StopReason
+	}{
+		{
+			name: "exhausted",
+			run: func() (StopReason, error) {
+				pool := New(2, func(abort <-chan struct{}) bool { return false })
+				pool.Start()
+				return pool.WaitReason()
+			},
+			reason: StopExhausted,
+		},
+		{
+			name: "exhausted with errors",
+			run: func() (StopReason, error) {
+				pool := NewWithError(1, func(abort <-chan struct{}) (bool, error) {
+					return false, assert.AnError
+				})
+				pool.Start()
+				return pool.WaitReason()
+			},
+			reason: StopExhausted,
+		},
+		{
+			name: "cancelled",
+			run: func() (StopReason, error) {
+				pool := New(2, blocking)
+				pool.Start()
+				pool.Cancel()
+				return pool.WaitReason()
+			},
+			reason: StopCancelled,
+		},
+		{
+			name: "context cancelled",
+			run: func() (StopReason, error) {
+				pool := New(2, blocking)
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				err := pool.RunContext(ctx)
+				return pool.StopReason(), err
+			},
+			reason: StopCancelled,
+		},
+		{
+			name: "deadline",
+			run: func() (StopReason, error) {
+				pool := New(2, blocking)
+				pool.MaxRuntime = time.Millisecond
+				pool.Start()
+				return pool.WaitReason()
+			},
+			reason: StopDeadline,
+		},
+		{
+			name: "context deadline",
+			run: func() (StopReason, error) {
+				pool := New(2, blocking)
+				ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+				defer cancel()
+				err := pool.RunContext(ctx)
+				return pool.StopReason(), err
+			},
+			reason: StopDeadline,
+		},
+		{
+			name: "idle",
+			run: func() (StopReason, error) {
+				pool := New(1, blocking)
+				pool.IdleTimeout = time.Millisecond
+				pool.Start()
+				return pool.WaitReason()
+			},
+			reason: StopIdle,
+		},
+		{
+			name: "cancel on error",
+			run: func() (StopReason, error) {
+				calls := 0
+				pool := NewWithError(1, func(abort <-chan struct{}) (bool, error) {
+					calls++
+					if calls == 1 {
+						return true, assert.AnError
+					}
+					<-abort
+					return false, nil
+				})
+				pool.CancelOnError = true
+				pool.Start()
+				return pool.WaitReason()
+			},
+			reason: StopFailed,
+		},
+		{
+			name: "invalid configuration",
+			run: func() (StopReason, error) {
+				pool := New(1, blocking)
+				pool.TaskTimeout = -1
+				pool.Start()
+				return pool.WaitReason()
+			},
+			reason: StopFailed,
+		},
+		{
+			name: "fatal error after the last restart",
+			run: func() (StopReason, error) {
+				pool := NewWithError(1, func(abort <-chan struct{}) (bool, error) {
+					return true, Fatal(errors.New("boom"))
+				})
+				pool.Supervisor = &Supervisor{MaxRestarts: 1, Backoff: time.Millisecond}
+				pool.Start()
+				return pool.WaitReason()
+			},
+			reason: StopFailed,
+		},
+		{
+			name: "worker start failed",
+			run: func() (StopReason, error) {
+				pool := New(2, blocking)
+				pool.OnWorkerStart = func(workerID int) error { return errors.New("no db") }
+				pool.Start()
+				return pool.WaitReason()
+			},
+			reason: StopFailed,
+		},
+		{
+			name: "cancelled with a cause",
+			run: func() (StopReason, error) {
+				pool := New(1, blocking)
+				pool.Start()
+				pool.CancelWithCause(errors.New("shutting down"))
+				return pool.WaitReason()
+			},
+			reason: StopCancelled,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			reason, _ := tc.run()
+			assert.Equal(t, tc.reason, reason)
+		})
+	}
+}
+
+func TestStopReasonRunning(t *testing.T) {
+	pool := New(1, func(abort <-chan struct{}) bool {
+		<-abort
+		return false
+	})
+	assert.Equal(t, StopNone, pool.StopReason())
+	pool.Start()
+	assert.Equal(t, StopNone, pool.StopReason())
+	pool.Cancel()
+	assert.NoError(t, pool.Wait())
+	assert.Equal(t, StopCancelled, pool.StopReason())
+	assert.Equal(t, "cancelled", pool.StopReason().String())
+}
+
+func TestStopReasonTypedPool(t *testing.T) {
+	pool := NewTypedPool(2, func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	})
+	pool.Start()
+	go func() {
+		for range pool.Results() {
+		}
+	}()
+	assert.NoError(t, pool.Submit(1))
+	pool.Finish()
+	reason, err := pool.WaitReason()
+	assert.NoError(t, err)
+	assert.Equal(t, StopExhausted, reason)
+}
@@ -0,0 +1,114 @@
+package workpool
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// Supervisor restarts workers which fail. A worker fails when its handler returns an error wrapped with Fatal, or
+// when the handler panics, in which case panics are recovered even if RecoverPanics is not set and PanicPolicy does
+// not apply. The worker is restarted, calling OnWorkerStop and OnWorkerStart again, after a backoff which doubles with
+// every consecutive failure.
+//
+// A fatal error is only returned from Run if the worker runs out of restarts.
+type Supervisor struct {
+	// MaxRestarts limits the number of consecutive restarts of a worker. The count is reset once the handler returns
+	// without a fatal error. Zero means that there is no limit.
+	MaxRestarts int
+
+	// Backoff is the wait before the first restart. Zero uses 100 milliseconds.
+	Backoff time.Duration
+
+	// MaxBackoff limits the wait between restarts. Zero uses 30 seconds.
+	MaxBackoff time.Duration
+
+	// OnRestart, when set, is called before a worker is restarted, with the number of consecutive restarts including
+	// this one and the error which stopped it.
+	OnRestart func(workerID int, restarts int, cause error)
+}
+
+// fatalError marks an error which stops a supervised worker.
+type fatalError struct {
+	err error
+}
+
+func (e fatalError) Error() string {
+	return e.err.Error()
+}
+
+func (e fatalError) Unwrap() error {
+	return e.err
+}
+
+// Fatal marks err as fatal for the worker which returned it. A supervised worker is restarted after a fatal error,
+// see Supervisor. Without a Supervisor a fatal error is like any other error. Fatal returns nil if err is nil.
+func Fatal(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fatalError{err: err}
+}
+
+// IsFatal reports whether err, or an error it wraps, was marked with Fatal.
+func IsFatal(err error) bool {
+	var fatal fatalError
+	return errors.As(err, &fatal)
+}
+
+// supervised checks the result of a handler call for a failure of a supervised worker.
+func (p *WorkPool) supervised(w *worker, err error) bool {
+	if p.Supervisor == nil {
+		return false
+	}
+	if !IsFatal(err) {
+		w.restarts = 0
+		return false
+	}
+	w.failure = err
+	return true
+}
+
+// restart waits for the backoff before restarting a failed worker. False is returned if the worker should not be
+// restarted, because it ran out of restarts or the pool is stopping.
+func (p *WorkPool) restart(w *worker) bool {
+	s := p.Supervisor
+	if s.MaxRestarts > 0 && w.restarts >= s.MaxRestarts {
+		p.log(slog.LevelError, "worker failed", "worker", w.id, "restarts", w.restarts, "error", w.failure)
+		return false
+	}
+	w.restarts++
+
+	backoff := s.Backoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+	maxBackoff := s.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 30 * time.Second
+	}
+	for i := 1; i < w.restarts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+
+	p.log(slog.LevelWarn, "worker restarting", "worker", w.id, "restarts", w.restarts, "backoff", backoff,
+		"error", w.failure)
+	if s.OnRestart != nil {
+		s.OnRestart(w.id, w.restarts, w.failure)
+	}
+
+	timer := p.clock().NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return true
+	case <-w.quit:
+	case <-p.ctx.Done():
+	}
+	// The worker is stopping anyway, the failure is not an error of the run.
+	w.failure = nil
+	return false
+}
@@ -0,0 +1,114 @@
+package workpool
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupervisorRestarts(t *testing.T) {
+	var calls int32
+	var mu sync.Mutex
+	var restarts []int
+	var starts int
+	pool := NewWithError(1, func(abort <-chan struct{}) (bool, error) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			return true, Fatal(errors.New("connection lost"))
+		case 2:
+			panic("boom")
+		case 3:
+			return true, errors.New("not fatal")
+		}
+		return false, nil
+	})
+	pool.OnWorkerStart = func(workerID int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		starts++
+		return nil
+	}
+	pool.Supervisor = &Supervisor{
+		Backoff: time.Millisecond,
+		OnRestart: func(workerID int, n int, cause error) {
+			mu.Lock()
+			defer mu.Unlock()
+			restarts = append(restarts, n)
+		},
+	}
+
+	// The fatal error and the panic are absorbed by restarts, the ordinary error is returned as usual.
+	assert.EqualError(t, pool.Run(), "not fatal")
+	assert.EqualValues(t, 4, atomic.LoadInt32(&calls))
+	assert.Equal(t, []int{1, 2}, restarts)
+	assert.Equal(t, 3, starts)
+}
+
+func TestSupervisorMaxRestarts(t *testing.T) {
+	var calls int32
+	var reasons []ExitReason
+	backoffs := make(chan time.Time, 10)
+	pool := NewWithError(1, func(abort <-chan struct{}) (bool, error) {
+		atomic.AddInt32(&calls, 1)
+		backoffs <- time.Now()
+		return true, Fatal(fmt.Errorf("attempt %d", atomic.LoadInt32(&calls)))
+	})
+	pool.OnWorkerExit = func(workerID int, reason ExitReason) {
+		reasons = append(reasons, reason)
+	}
+	pool.Supervisor = &Supervisor{
+		MaxRestarts: 3,
+		Backoff:     2 * time.Millisecond,
+		MaxBackoff:  5 * time.Millisecond,
+	}
+	err := pool.Run()
+	assert.EqualError(t, err, "attempt 4")
+	assert.True(t, IsFatal(err))
+	assert.EqualValues(t, 4, atomic.LoadInt32(&calls))
+	assert.Equal(t, []ExitReason{ExitFailed, ExitFailed, ExitFailed, ExitFailed}, reasons)
+
+	// The backoff doubles up to MaxBackoff: 2, 4 and 5 milliseconds.
+	close(backoffs)
+	var times []time.Time
+	for t := range backoffs {
+		times = append(times, t)
+	}
+	require.Len(t, times, 4)
+	assert.GreaterOrEqual(t, times[1].Sub(times[0]), 2*time.Millisecond)
+	assert.GreaterOrEqual(t, times[2].Sub(times[1]), 4*time.Millisecond)
+	assert.GreaterOrEqual(t, times[3].Sub(times[2]), 5*time.Millisecond)
+}
+
+func TestSupervisorCancelDuringBackoff(t *testing.T) {
+	restarting := make(chan struct{})
+	pool := NewWithError(1, func(abort <-chan struct{}) (bool, error) {
+		return true, Fatal(errors.New("down"))
+	})
+	pool.Supervisor = &Supervisor{
+		Backoff: time.Hour,
+		OnRestart: func(workerID int, n int, cause error) {
+			close(restarting)
+		},
+	}
+	pool.Start()
+	<-restarting
+	pool.Cancel()
+	// A worker stopped during its backoff does not report the failure.
+	assert.NoError(t, pool.Wait())
+}
+
+func TestFatal(t *testing.T) {
+	assert.Nil(t, Fatal(nil))
+	base := errors.New("base")
+	err := fmt.Errorf("wrapped: %w", Fatal(base))
+	assert.True(t, IsFatal(err))
+	assert.ErrorIs(t, err, base)
+	assert.EqualError(t, err, "wrapped: base")
+	assert.False(t, IsFatal(base))
+}
@@ -0,0 +1,104 @@
+package workpool
+
+// TeePolicy is what a Tee stage does with an item for a branch whose buffer is full.
+type TeePolicy int
+
+const (
+	// TeeBlock waits for the branch to have room, so that the slowest branch sets the pace of the stage.
+	TeeBlock TeePolicy = iota
+	// TeeDropNewest drops the item for the branch, keeping the items already buffered.
+	TeeDropNewest
+	// TeeDropOldest drops the oldest buffered item of the branch to make room for the new one.
+	TeeDropOldest
+)
+
+// TeeBranch configures one of the outputs of a Tee stage.
+type TeeBranch[T any] struct {
+	// Buffer is the number of items which can wait for the branch to read them.
+	Buffer int
+
+	// Policy decides what happens to an item when the buffer is full. With TeeBlock and no buffer, the branch is read
+	// in lock step with the others.
+	Policy TeePolicy
+
+	// OnDrop, when set, is called with each item dropped because of Policy.
+	OnDrop func(item T)
+}
+
+// Tee adds a stage which sends every item read from in to each of the branches, so that one stream can feed several
+// stages, such as a writer and a metrics collector. One channel is returned for each branch, in order, and they are
+// closed once in is closed.
+func Tee[T any](p *Pipeline, in <-chan T, branches ...TeeBranch[T]) []<-chan T {
+	outs := make([]chan T, len(branches))
+	results := make([]<-chan T, len(branches))
+	for i, branch := range branches {
+		outs[i] = make(chan T, max(branch.Buffer, 0))
+		results[i] = outs[i]
+	}
+	p.add(&WorkPool{
+		Workers: 1,
+		Handler: func(abort <-chan struct{}) bool {
+			item, ok := receive(abort, in)
+			if !ok {
+				return false
+			}
+			for i, out := range outs {
+				if !teeSend(out, item, branches[i], abort) {
+					return false
+				}
+			}
+			return true
+		},
+		Close: func() {
+			for _, out := range outs {
+				close(out)
+			}
+		},
+	})
+	return results
+}
+
+// teeSend sends an item to a branch following its policy. False is returned if abort is closed while blocking.
+func teeSend[T any](out chan T, item T, branch TeeBranch[T], abort <-chan struct{}) bool {
+	switch branch.Policy {
+	case TeeDropNewest:
+		select {
+		case out <- item:
+		default:
+			branch.drop(item)
+		}
+		return true
+	case TeeDropOldest:
+		for {
+			select {
+			case out <- item:
+				return true
+			default:
+			}
+			// The branch may read the oldest item first, in which case there is room on the next attempt.
+			select {
+			case oldest := <-out:
+				branch.drop(oldest)
+			default:
+			}
+			if cap(out) == 0 {
+				// Without a buffer there is nothing to drop, the item itself is.
+				branch.drop(item)
+				return true
+			}
+		}
+	}
+	select {
+	case out <- item:
+		return true
+	case <-abort:
+		return false
+	}
+}
+
+// drop reports an item dropped by the policy.
+func (b TeeBranch[T]) drop(item T) {
+	if b.OnDrop != nil {
+		b.OnDrop(item)
+	}
+}
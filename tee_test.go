@@ -0,0 +1,77 @@
+package workpool
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTee(t *testing.T) {
+	p := NewPipeline()
+	numbers := Source(p, 1, 2, 3, 4)
+	branches := Tee(p, numbers, TeeBranch[int]{}, TeeBranch[int]{Buffer: 2})
+	var mu sync.Mutex
+	sums := make([]int, 2)
+	for i, branch := range branches {
+		Sink(p, 1, branch, func(abort <-chan struct{}, item int) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sums[i] += item
+			return nil
+		})
+	}
+
+	assert.NoError(t, p.Run())
+	assert.Equal(t, []int{10, 10}, sums)
+}
+
+func TestTeeDropPolicies(t *testing.T) {
+	p := NewPipeline()
+	numbers := Source(p, 1, 2, 3, 4, 5)
+	var droppedNewest, droppedOldest []int
+	branches := Tee(p, numbers,
+		TeeBranch[int]{},
+		TeeBranch[int]{Buffer: 2, Policy: TeeDropNewest, OnDrop: func(item int) {
+			droppedNewest = append(droppedNewest, item)
+		}},
+		TeeBranch[int]{Buffer: 2, Policy: TeeDropOldest, OnDrop: func(item int) {
+			droppedOldest = append(droppedOldest, item)
+		}},
+	)
+	var all []int
+	Sink(p, 1, branches[0], func(abort <-chan struct{}, item int) error {
+		all = append(all, item)
+		return nil
+	})
+
+	// The dropping branches are only read once the pipeline has finished.
+	assert.NoError(t, p.Run())
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, all)
+	assert.Equal(t, []int{3, 4, 5}, droppedNewest)
+	assert.Equal(t, []int{1, 2, 3}, droppedOldest)
+	assert.Equal(t, []int{1, 2}, drain(branches[1]))
+	assert.Equal(t, []int{4, 5}, drain(branches[2]))
+}
+
+func TestTeeBlockCancel(t *testing.T) {
+	p := NewPipeline()
+	numbers := Source(p, 1, 2, 3)
+	branches := Tee(p, numbers, TeeBranch[int]{})
+	Sink(p, 1, branches[0], func(abort <-chan struct{}, item int) error {
+		p.Cancel()
+		<-abort
+		return nil
+	})
+
+	assert.NoError(t, p.Run())
+}
+
+// drain reads a channel until it is closed.
+func drain[T any](ch <-chan T) []T {
+	var items []T
+	for item := range ch {
+		items = append(items, item)
+	}
+	return items
+}
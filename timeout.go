@@ -0,0 +1,31 @@
+package workpool
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// invokeTimed calls the handler once, see invoke. When TaskTimeout is set the handler is given its own abort signal,
+// which is closed when the pool is cancelled or the timeout expires.
+//
+// A call which times out is counted in Stats.Timeouts and the worker keeps going, even if the handler returned false
+// in response to the abort signal.
+func (p *WorkPool) invokeTimed(w *worker, handler ErrWorkHandler, abort <-chan struct{}) (foundWork, panicked bool, err error) {
+	if p.TaskTimeout <= 0 {
+		w.ctx = p.ctx
+		return p.invoke(handler, abort)
+	}
+
+	ctx, cancel := context.WithTimeout(p.ctx, p.TaskTimeout)
+	defer cancel()
+	w.ctx = ctx
+	foundWork, panicked, err = p.invoke(handler, ctx.Done())
+	if ctx.Err() == context.DeadlineExceeded && p.ctx.Err() == nil {
+		atomic.AddInt64(&p.counters.timeouts, 1)
+		if p.Metrics != nil {
+			p.Metrics.Counter("timeouts", 1)
+		}
+		foundWork = true
+	}
+	return foundWork, panicked, err
+}
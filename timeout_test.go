@@ -0,0 +1,59 @@
+package workpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskTimeout(t *testing.T) {
+	inputs := make(chan time.Duration, 3)
+	inputs <- time.Hour
+	inputs <- time.Millisecond
+	inputs <- time.Hour
+	close(inputs)
+
+	completed := 0
+	worker := func(abort <-chan struct{}) bool {
+		for d := range inputs {
+			select {
+			case <-time.After(d):
+				completed++
+				return true
+			case <-abort:
+				// Conventional handlers stop on abort, the pool keeps the worker going after a timeout.
+				return false
+			}
+		}
+		return false
+	}
+
+	pool := New(1, worker)
+	pool.TaskTimeout = 10 * time.Millisecond
+
+	start := time.Now()
+	assert.NoError(t, pool.Run())
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 1, completed)
+	assert.Equal(t, int64(2), pool.Stats().Timeouts)
+}
+
+func TestTaskTimeoutCancel(t *testing.T) {
+	started := make(chan struct{})
+	worker := func(abort <-chan struct{}) bool {
+		close(started)
+		<-abort
+		return false
+	}
+
+	pool := New(1, worker)
+	pool.TaskTimeout = time.Hour
+	go func() {
+		<-started
+		pool.Cancel()
+	}()
+
+	assert.NoError(t, pool.Run())
+	assert.Equal(t, int64(0), pool.Stats().Timeouts)
+}
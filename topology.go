@@ -0,0 +1,101 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoBranches is returned by Topology.Run when no branches were declared.
+var ErrNoBranches = errors.New("workpool: topology has no branches")
+
+// Branch is one of the parallel stages of a Topology.
+type Branch[In, Out any] struct {
+	// Name identifies the branch in the errors it returns.
+	Name string
+
+	// Workers is the number of workers calling Handler.
+	Workers int
+
+	// Handler is called for every item produced by the source.
+	Handler TypedHandler[In, Out]
+}
+
+// Topology declares a fan-out, fan-in graph: a single source whose items are sent to every branch, and a single sink
+// which receives the results of all branches. It is run as a Pipeline, so channels are created and closed by the
+// stages: once the source returns each stage finishes after the one before it, and an error anywhere aborts every
+// stage, including the source.
+type Topology[In, Out any] struct {
+	// Source produces the items, passing each one to emit. Emit returns false once the topology has been aborted,
+	// after which Source should return. An error returned by Source aborts the topology.
+	Source func(abort <-chan struct{}, emit func(item In) bool) error
+
+	// Branches process every item from the source in parallel.
+	Branches []Branch[In, Out]
+
+	// Sink is called with SinkWorkers workers for each result of the branches.
+	Sink        func(abort <-chan struct{}, item Out) error
+	SinkWorkers int
+}
+
+// Run builds the pipeline and blocks until every stage has finished, returning the first error. It is cancelled when
+// ctx is done.
+func (t *Topology[In, Out]) Run(ctx context.Context) error {
+	if len(t.Branches) == 0 {
+		return ErrNoBranches
+	}
+
+	p := NewPipeline()
+	source := t.source(p)
+	outputs := make([]<-chan Out, len(t.Branches))
+	for i, input := range broadcast(p, source, len(t.Branches)) {
+		outputs[i] = Stage(p, t.Branches[i].Workers, input, t.Branches[i].handler())
+	}
+	Sink(p, t.SinkWorkers, Merge(p, outputs...), t.Sink)
+	return p.RunContext(ctx)
+}
+
+// source adds the stage which runs Source.
+func (t *Topology[In, Out]) source(p *Pipeline) <-chan In {
+	out := make(chan In)
+	p.add(&WorkPool{
+		Workers: 1,
+		ErrHandler: func(abort <-chan struct{}) (bool, error) {
+			err := t.Source(abort, func(item In) bool {
+				select {
+				case out <- item:
+					return true
+				case <-abort:
+					return false
+				}
+			})
+			if err != nil {
+				p.Cancel()
+			}
+			return false, err
+		},
+		Close: func() {
+			close(out)
+		},
+	})
+	return out
+}
+
+// handler returns the branch handler, with the branch name added to its errors.
+func (b Branch[In, Out]) handler() TypedHandler[In, Out] {
+	if b.Name == "" {
+		return b.Handler
+	}
+	return func(abort <-chan struct{}, item In) (Out, error) {
+		out, err := b.Handler(abort, item)
+		if err != nil {
+			err = fmt.Errorf("branch %s: %w", b.Name, err)
+		}
+		return out, err
+	}
+}
+
+// broadcast adds a stage which sends every item read from in to each of n returned channels.
+func broadcast[T any](p *Pipeline, in <-chan T, n int) []<-chan T {
+	return Tee(p, in, make([]TeeBranch[T], n)...)
+}
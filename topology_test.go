@@ -0,0 +1,121 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countTo emits the numbers from 1 to n.
+func countTo(n int) func(abort <-chan struct{}, emit func(int) bool) error {
+	return func(abort <-chan struct{}, emit func(int) bool) error {
+		for i := 1; i <= n; i++ {
+			if !emit(i) {
+				return nil
+			}
+		}
+		return nil
+	}
+}
+
+func TestTopology(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	topology := Topology[int, string]{
+		Source: countTo(3),
+		Branches: []Branch[int, string]{
+			{Workers: 2, Handler: func(abort <-chan struct{}, item int) (string, error) {
+				return "double " + strconv.Itoa(item*2), nil
+			}},
+			{Workers: 1, Handler: func(abort <-chan struct{}, item int) (string, error) {
+				return "square " + strconv.Itoa(item*item), nil
+			}},
+		},
+		SinkWorkers: 2,
+		Sink: func(abort <-chan struct{}, item string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, item)
+			return nil
+		},
+	}
+
+	assert.NoError(t, topology.Run(context.Background()))
+	sort.Strings(got)
+	assert.Equal(t, []string{"double 2", "double 4", "double 6", "square 1", "square 4", "square 9"}, got)
+}
+
+func TestTopologyBranchErrorAbortsSource(t *testing.T) {
+	sourceStopped := make(chan struct{})
+	topology := Topology[int, int]{
+		Source: func(abort <-chan struct{}, emit func(int) bool) error {
+			defer close(sourceStopped)
+			for i := 0; emit(i); i++ {
+			}
+			return nil
+		},
+		Branches: []Branch[int, int]{
+			{Name: "ok", Workers: 1, Handler: func(abort <-chan struct{}, item int) (int, error) { return item, nil }},
+			{Name: "picky", Workers: 1, Handler: func(abort <-chan struct{}, item int) (int, error) {
+				if item == 5 {
+					return 0, errors.New("five")
+				}
+				return item, nil
+			}},
+		},
+		Sink: func(abort <-chan struct{}, item int) error { return nil },
+	}
+
+	assert.EqualError(t, topology.Run(context.Background()), "branch picky: five")
+	select {
+	case <-sourceStopped:
+	case <-time.After(time.Second):
+		t.Fatal("the source was not aborted")
+	}
+}
+
+func TestTopologySourceError(t *testing.T) {
+	topology := Topology[int, int]{
+		Source: func(abort <-chan struct{}, emit func(int) bool) error {
+			emit(1)
+			return errors.New("source failed")
+		},
+		Branches: []Branch[int, int]{
+			{Workers: 1, Handler: func(abort <-chan struct{}, item int) (int, error) { return item, nil }},
+		},
+		Sink: func(abort <-chan struct{}, item int) error { return nil },
+	}
+	assert.EqualError(t, topology.Run(context.Background()), "source failed")
+}
+
+func TestTopologyCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	topology := Topology[int, int]{
+		Source: func(abort <-chan struct{}, emit func(int) bool) error {
+			for i := 0; emit(i); i++ {
+			}
+			return nil
+		},
+		Branches: []Branch[int, int]{
+			{Workers: 1, Handler: func(abort <-chan struct{}, item int) (int, error) { return item, nil }},
+		},
+		Sink: func(abort <-chan struct{}, item int) error {
+			if item == 100 {
+				cancel()
+			}
+			return nil
+		},
+	}
+	assert.NoError(t, topology.Run(ctx))
+}
+
+func TestTopologyNoBranches(t *testing.T) {
+	topology := Topology[int, int]{Source: countTo(1)}
+	assert.ErrorIs(t, topology.Run(context.Background()), ErrNoBranches)
+}
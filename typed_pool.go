@@ -0,0 +1,463 @@
+package workpool
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrPoolClosed is returned when submitting work to a pool which is no longer accepting it.
+var ErrPoolClosed = errors.New("workpool: pool is closed")
+
+// TypedHandler processes a single item submitted to a TypedPool. The abort signal is the same one given to a
+// WorkHandler.
+type TypedHandler[In, Out any] func(abort <-chan struct{}, item In) (Out, error)
+
+// Result is the outcome of processing a single item in a TypedPool.
+type Result[Out any] struct {
+	Value Out
+	Err   error
+}
+
+// TypedPool is a WorkPool which owns the distribution of work. Items are given to the pool with Submit, and the result
+// of each item is available from Results.
+//
+// The embedded WorkPool may be configured before calling Start, but its Handler, ErrHandler and Close fields are managed
+// by the TypedPool.
+type TypedPool[In, Out any] struct {
+	*WorkPool
+
+	// DeadLetters, when set, receives every item for which the handler returns an error, or only the quarantined items
+	// when MaxFailures is set. The failed result is still sent to Results.
+	DeadLetters DeadLetterSink[In]
+
+	// QueueSize limits the number of submitted items waiting for a worker. When the queue is full Submit blocks and
+	// TrySubmit fails, which gives producers backpressure. Zero means that the queue is unbounded.
+	QueueSize int
+
+	// PriorityAging, when positive, raises the priority of queued items by one for every PriorityAging they wait, so
+	// that items with a low priority are not starved by a steady flow of items with a high priority. Items submitted
+	// PriorityAging apart with priorities one apart are taken in the order they were submitted.
+	PriorityAging time.Duration
+
+	// TenantWeights gives the share of the workers of the tenants of items submitted with SubmitTenant, relative to
+	// each other. Tenants which are not listed, or have a weight below one, have a weight of one.
+	TenantWeights map[string]int
+
+	// Ordered sends results in the order their items were submitted, even though they are processed concurrently.
+	// Items with a higher priority are taken from the queue first, and their results are ordered accordingly.
+	Ordered bool
+
+	// OrderWindow limits how far ahead of the oldest unfinished item results are buffered in Ordered mode. A worker
+	// finishing an item outside of the window waits for the window to move on. Zero means that there is no limit.
+	OrderWindow int
+
+	// Key, when set, identifies duplicate items. An item submitted while another item with the same key is queued or
+	// being processed is dropped, and Submit reports success.
+	Key func(item In) string
+
+	// OnDuplicate, when set, is called with every item dropped because of Key.
+	OnDuplicate func(item In)
+
+	// MaxWeight, when set, limits the total weight of the items being processed at the same time. A worker which takes
+	// an item that does not fit waits for running items to finish. Items heavier than MaxWeight are processed on their
+	// own.
+	MaxWeight int64
+
+	// Weight returns the weight of an item for MaxWeight. When nil every item weighs 1.
+	Weight func(item In) int64
+
+	// LazyWorkers starts workers as items are submitted, up to Workers, instead of starting all of them in Start. A
+	// worker is added whenever an item is submitted while there are more queued items than idle workers.
+	LazyWorkers bool
+
+	// Callbacks, when set, runs the callbacks of items submitted with SubmitWithCallback, callbacks still queued when
+	// it is cancelled are dropped. Otherwise each callback runs on its own goroutine, so a slow callback never holds
+	// up a worker.
+	Callbacks *Executor
+
+	// Affinity, when set, returns the key of an item. Items with the same key are processed one at a time in the
+	// order they were taken from the queue, while items with different keys are processed concurrently. The worker
+	// processing a key also processes the items with that key which arrive in the meantime, so a key sticks to one
+	// worker as long as it has work.
+	Affinity func(item In) string
+
+	// Checkpointer, when set along with Position, is given the position up to which every submitted item has been
+	// processed, so that a streaming consumer can resume from there after a restart. Items are processed once the
+	// handler returns without an error, once its failure is given to DeadLetters, or once it is quarantined. A failed
+	// item otherwise holds the checkpoint back, so that it is processed again after a restart. Items still queued
+	// when the pool is cancelled hold it back too.
+	Checkpointer Checkpointer
+
+	// Position returns the position of an item in its source for Checkpointer, such as an offset in a log or a
+	// partition. Items must be submitted in the order of their positions.
+	Position func(item In) int64
+
+	// CheckpointInterval, when positive, flushes the checkpoint to Checkpointer every CheckpointInterval in the
+	// background, and once more when the pool finishes. Otherwise the checkpoint is flushed by the worker which moved
+	// it, every time it moves.
+	CheckpointInterval time.Duration
+
+	// RequeueFailed nacks the items which implement Acker with requeue set when the handler fails, so that their source
+	// delivers them again. Otherwise they are nacked without requeue, and the source drops or dead-letters them.
+	RequeueFailed bool
+
+	// MaxFailures, when positive, quarantines items which failed or panicked for MaxFailures of their deliveries, so
+	// that a poison message which a source keeps delivering again, see Acker and RequeueFailed, is not retried
+	// forever. The deliveries of an item are identified by FailureKey, or by Key when FailureKey is nil. A quarantined
+	// item is given to DeadLetters with a QuarantineError, it is nacked without requeue, and later deliveries of it
+	// are not processed. The failures before an item is quarantined are not final, so they are not given to
+	// DeadLetters.
+	MaxFailures int
+
+	// FailureKey returns the key which identifies the deliveries of an item for MaxFailures, such as a message ID.
+	// MaxFailures has no effect when neither FailureKey nor Key is set.
+	FailureKey func(item In) string
+
+	// QuarantineSize bounds the number of keys whose failures are counted, and the number of quarantined keys, for
+	// MaxFailures. Once a bound is reached the key which was delivered the longest time ago is forgotten, a forgotten
+	// quarantined item is processed again if it is delivered again. Zero or less keeps 10000 keys of each.
+	QuarantineSize int
+
+	// contextHandler replaces the handler given to work when the pool was created by NewTypedPoolContext.
+	contextHandler TypedContextHandler[In, Out]
+
+	queue   *queue[In]
+	reorder *reorder[delivery[Out]]
+	results chan Result[Out]
+
+	// delayMu guards the count of delayed items which have not been queued yet. The queue is closed by Finish once
+	// they have been.
+	delayMu   sync.Mutex
+	delayed   int
+	finishing bool
+
+	// keys holds the keys of queued and running items when Key is set.
+	keysMu sync.Mutex
+	keys   map[string]struct{}
+
+	semOnce sync.Once
+	sem     *semaphore
+
+	// waiting counts the workers waiting for an item, for LazyWorkers.
+	waiting atomic.Int32
+
+	// keyed holds the items waiting for the worker processing their key when Affinity is set. orphans are keys whose
+	// worker panicked before processing all of them.
+	keyedMu sync.Mutex
+	keyed   map[string][]entry[In]
+	orphans []string
+
+	// jobs holds the handles of the queued and running items submitted with SubmitJob.
+	jobIDs atomic.Uint64
+	jobsMu sync.Mutex
+	jobs   map[uint64]*JobHandle
+
+	// dropping counts the deliveries of the results of cancelled jobs removed from the queue, see dropCancelled.
+	dropping sync.WaitGroup
+
+	// checkpoints tracks the positions of submitted items for Checkpointer, checkpointMu serializes flushes.
+	checkpoints  checkpoints
+	checkpointMu sync.Mutex
+
+	// failures counts the failed deliveries of items for MaxFailures.
+	failures failures
+}
+
+// NewTypedPool creates a TypedPool which calls handler for each submitted item using numWorkers goroutines.
+func NewTypedPool[In, Out any](numWorkers int, handler TypedHandler[In, Out]) *TypedPool[In, Out] {
+	p := &TypedPool[In, Out]{
+		queue:   newQueue[In](),
+		reorder: newReorder[delivery[Out]](),
+		results: make(chan Result[Out]),
+	}
+	p.WorkPool = &WorkPool{
+		ErrHandler: p.work(handler),
+		Workers:    numWorkers,
+		Close: func() {
+			p.dropping.Wait()
+			p.stopCheckpoints()
+			close(p.results)
+		},
+		demand: func() (bool, int, bool) {
+			queued, closed := p.queue.status()
+			return p.LazyWorkers, queued, closed
+		},
+		itemized: true,
+	}
+	p.queue.rank = func(priority int) float64 {
+		if p.PriorityAging <= 0 {
+			return float64(priority)
+		}
+		// Every item ages at the same rate, so the rank only depends on when the item was added.
+		return float64(priority) - float64(p.clock().Now().UnixNano())/float64(p.PriorityAging)
+	}
+	p.queue.fair = newFairShare(func(tenant string) float64 {
+		if weight := p.TenantWeights[tenant]; weight > 1 {
+			return float64(weight)
+		}
+		return 1
+	})
+	p.WorkPool.idle = func() bool {
+		return int(p.waiting.Load()) >= p.ActiveWorkers()
+	}
+	trackQueue(p.WorkPool, p.queue)
+	return p
+}
+
+// Submit adds an item to the pool, blocking while the queue is full. ErrPoolClosed is returned if Finish or Cancel
+// have been called.
+func (p *TypedPool[In, Out]) Submit(item In) error {
+	return p.SubmitWithPriority(item, 0)
+}
+
+// SubmitWithPriority adds an item to the pool with a priority. Items with a higher priority are given to workers
+// before items with a lower priority, items with the same priority are processed in the order they were submitted.
+// Submit uses a priority of zero.
+func (p *TypedPool[In, Out]) SubmitWithPriority(item In, priority int) error {
+	p.init()
+	if p.ctx.Err() != nil || p.isFinishing() {
+		return ErrPoolClosed
+	}
+	if !p.claim(item) {
+		return nil
+	}
+	if !p.queue.push(item, priority, p.QueueSize, p.ctx.Done()) {
+		p.unclaim(item)
+		return ErrPoolClosed
+	}
+	p.addTotal(1)
+	p.wake()
+	return nil
+}
+
+// TrySubmit is like Submit, but returns false instead of blocking when the queue is full. False is also returned if
+// the pool is no longer accepting work.
+func (p *TypedPool[In, Out]) TrySubmit(item In) bool {
+	p.init()
+	if p.ctx.Err() != nil || p.isFinishing() {
+		return false
+	}
+	if !p.claim(item) {
+		return true
+	}
+	if !p.queue.tryPush(item, 0, p.QueueSize) {
+		p.unclaim(item)
+		return false
+	}
+	p.addTotal(1)
+	p.wake()
+	return true
+}
+
+// QueueLen returns the number of submitted items waiting for a worker.
+func (p *TypedPool[In, Out]) QueueLen() int {
+	return p.queue.len()
+}
+
+// Finish signals that no more items will be submitted. The pool exits once the submitted items, including delayed
+// items which are not due yet, have been processed.
+func (p *TypedPool[In, Out]) Finish() {
+	p.delayMu.Lock()
+	defer p.delayMu.Unlock()
+	p.finishing = true
+	if p.delayed == 0 {
+		p.close()
+	}
+}
+
+// isFinishing reports whether Finish was called.
+func (p *TypedPool[In, Out]) isFinishing() bool {
+	p.delayMu.Lock()
+	defer p.delayMu.Unlock()
+	return p.finishing
+}
+
+// Results returns the channel where results are sent. It must be read until closed, which happens after the pool
+// finishes. Otherwise the workers will block.
+func (p *TypedPool[In, Out]) Results() <-chan Result[Out] {
+	return p.results
+}
+
+// work creates the ErrWorkHandler used by the underlying WorkPool.
+func (p *TypedPool[In, Out]) work(handler TypedHandler[In, Out]) ErrWorkHandler {
+	return func(abort <-chan struct{}) (bool, error) {
+		if p.Affinity != nil {
+			if key, e, ok := p.takeOrphan(); ok {
+				return p.processKeyed(handler, key, e, abort)
+			}
+		}
+
+		p.waiting.Add(1)
+		e, ok := p.queue.popEntry(abort, nil)
+		p.waiting.Add(-1)
+		if !ok {
+			return false, nil
+		}
+		if p.Affinity != nil {
+			key := p.Affinity(e.item)
+			if !p.claimKey(key, e) {
+				// Another worker is processing the key and takes care of the item.
+				return true, nil
+			}
+			return p.processKeyed(handler, key, e, abort)
+		}
+		return p.process(handler, e, abort)
+	}
+}
+
+// process calls the handler for a single item.
+func (p *TypedPool[In, Out]) process(handler TypedHandler[In, Out], e entry[In], abort <-chan struct{}) (bool, error) {
+	defer p.release(e.item)
+	callback, _ := e.callback.(func(Out, error))
+	item, _ := any(e.item).(Acker)
+	ack := acker{acker: item}
+	returned := false
+	defer func() {
+		if returned {
+			return
+		}
+		// The handler panicked, which only counts towards MaxFailures.
+		if _, tracked := p.failureKey(e.item); tracked {
+			p.failed(e.item, ErrPanicked, &ack, false)
+		} else {
+			ack.ack(false, p.RequeueFailed)
+		}
+	}()
+	if e.job != nil {
+		defer e.job.settle()
+		defer p.forgetJob(e.job)
+		if !e.job.start() {
+			returned = true
+			p.completeTask()
+			p.checkpoint(e.item)
+			ackErr := ack.ack(false, false)
+			return p.deliver(e.order, Result[Out]{Err: ErrJobCancelled}, callback), ackErr
+		}
+		jobAbort, stop := e.job.abort(abort)
+		defer stop()
+		abort = jobAbort
+	}
+	if err := p.quarantined(e.item); err != nil {
+		// The item was given to DeadLetters when it was quarantined, it is not processed again.
+		returned = true
+		p.completeTask()
+		p.checkpoint(e.item)
+		if e.job != nil {
+			e.job.finish(err)
+		}
+		ackErr := ack.ack(false, false)
+		return p.deliver(e.order, Result[Out]{Err: err}, callback), ackErr
+	}
+
+	releaseWeight, ok := p.acquireWeight(e.item, abort)
+	if !ok {
+		returned = true
+		return false, ack.ack(false, e.job == nil || !e.job.isCancelled())
+	}
+	defer releaseWeight()
+
+	// In order mode every item needs a result, otherwise the items after it are never released.
+	delivered := false
+	if p.Ordered {
+		defer func() {
+			if !delivered {
+				p.deliver(e.order, Result[Out]{Err: ErrPanicked}, callback)
+			}
+		}()
+	}
+
+	out, err := p.call(handler, e, abort)
+	returned = true
+	p.completeTask()
+	var ackErr error
+	switch {
+	case err == nil:
+		p.forgetFailures(e.item)
+		p.checkpoint(e.item)
+		ackErr = ack.ack(true, false)
+	case e.job != nil && e.job.isCancelled():
+		p.checkpoint(e.item)
+		ackErr = ack.ack(false, false)
+	default:
+		err, ackErr = p.failed(e.item, err, &ack, p.ctx.Err() != nil)
+	}
+	result := Result[Out]{Value: out, Err: err}
+	if e.job != nil {
+		e.job.finish(err)
+		if e.job.isCancelled() {
+			// The job was aborted on purpose, so its error is not a failure of the pool.
+			err = nil
+		}
+	}
+	if ackErr != nil {
+		err = errors.Join(err, ackErr)
+	}
+	delivered = true
+	return p.deliver(e.order, result, callback), err
+}
+
+// failed handles an item for which the handler failed. Its failure counts towards MaxFailures unless the pool was
+// cancelled while it was processed, and the error is replaced by a QuarantineError once it is quarantined. The final
+// failures are given to DeadLetters, which are every failure unless MaxFailures is set. The error is returned, along
+// with the error acknowledging the item.
+func (p *TypedPool[In, Out]) failed(item In, err error, ack *acker, interrupted bool) (error, error) {
+	quarantined := false
+	if !interrupted {
+		err, quarantined = p.recordFailure(item, err)
+	}
+	_, tracked := p.failureKey(item)
+	final := !tracked || quarantined
+	if final && p.DeadLetters != nil {
+		p.deadLetter(item, err)
+	}
+	if quarantined || final && p.DeadLetters != nil {
+		p.checkpoint(item)
+	}
+
+	requeue := p.RequeueFailed
+	switch {
+	case quarantined:
+		requeue = false
+	case interrupted:
+		requeue = true
+	}
+	return err, ack.ack(false, requeue)
+}
+
+// delivery is a result with the callback of its item, if it was submitted with SubmitWithCallback.
+type delivery[Out any] struct {
+	result   Result[Out]
+	callback func(Out, error)
+}
+
+// deliver sends a result, or in order mode adds it to the reorder buffer. A result with a callback is passed to the
+// callback instead of being sent. False is returned if the pool was cancelled.
+func (p *TypedPool[In, Out]) deliver(order uint64, result Result[Out], callback func(Out, error)) bool {
+	cancelled := p.ctx.Done()
+	send := func(d delivery[Out]) bool {
+		if d.callback != nil {
+			p.runCallback(d.callback, d.result)
+			return true
+		}
+		// Results of aborted work are discarded.
+		select {
+		case <-cancelled:
+			return false
+		default:
+		}
+		select {
+		case p.results <- d.result:
+			return true
+		case <-cancelled:
+			return false
+		}
+	}
+
+	d := delivery[Out]{result: result, callback: callback}
+	if !p.Ordered {
+		return send(d)
+	}
+	return p.reorder.add(order, d, p.OrderWindow, cancelled, send)
+}
@@ -0,0 +1,244 @@
+package workpool
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func square(abort <-chan struct{}, item int) (int, error) {
+	return item * item, nil
+}
+
+func TestTypedPool(t *testing.T) {
+	pool := NewTypedPool(4, square)
+	pool.Start()
+
+	go func() {
+		for i := 1; i <= 100; i++ {
+			require.NoError(t, pool.Submit(i))
+		}
+		pool.Finish()
+	}()
+
+	sum := 0
+	for result := range pool.Results() {
+		assert.NoError(t, result.Err)
+		sum += result.Value
+	}
+
+	assert.NoError(t, pool.Wait())
+	assert.Equal(t, 100*(100+1)*(2*100+1)/6, sum)
+}
+
+func TestTypedPoolSubmitAfterFinish(t *testing.T) {
+	pool := NewTypedPool(1, square)
+	pool.Start()
+	pool.Finish()
+
+	assert.ErrorIs(t, pool.Submit(1), ErrPoolClosed)
+	for range pool.Results() {
+	}
+	assert.NoError(t, pool.Wait())
+}
+
+func TestTypedPoolError(t *testing.T) {
+	handler := func(abort <-chan struct{}, item int) (int, error) {
+		if item == 2 {
+			return 0, errors.New("two")
+		}
+		return item, nil
+	}
+	pool := NewTypedPool(1, handler)
+	pool.Start()
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, pool.Submit(i))
+	}
+	pool.Finish()
+
+	var errs []error
+	for result := range pool.Results() {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+		}
+	}
+
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, pool.Wait(), "two")
+}
+
+func TestTypedPoolCancel(t *testing.T) {
+	started := make(chan struct{})
+	handler := func(abort <-chan struct{}, item int) (int, error) {
+		close(started)
+		<-abort
+		return 0, nil
+	}
+	pool := NewTypedPool(1, handler)
+	pool.Start()
+	require.NoError(t, pool.Submit(1))
+
+	<-started
+	pool.Cancel()
+
+	select {
+	case _, ok := <-pool.Results():
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("results were not closed after cancel")
+	}
+	assert.NoError(t, pool.Wait())
+	assert.ErrorIs(t, pool.Submit(2), ErrPoolClosed)
+}
+
+func TestTypedPoolPriority(t *testing.T) {
+	identity := func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	}
+	pool := NewTypedPool(1, identity)
+
+	// Queue everything before starting so that the order only depends on priority.
+	require.NoError(t, pool.SubmitWithPriority(1, 1))
+	require.NoError(t, pool.Submit(0))
+	require.NoError(t, pool.SubmitWithPriority(2, 2))
+	pool.Finish()
+	pool.Start()
+
+	var order []int
+	for result := range pool.Results() {
+		order = append(order, result.Value)
+	}
+	assert.Equal(t, []int{2, 1, 0}, order)
+}
+
+func TestTypedPoolPriorityAging(t *testing.T) {
+	identity := func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	}
+	pool := NewTypedPool(1, identity)
+	clock := &manualClock{now: time.Unix(1000, 0)}
+	pool.Clock = clock
+	pool.PriorityAging = time.Second
+
+	require.NoError(t, pool.Submit(0))
+	clock.now = clock.now.Add(3 * time.Second)
+	// Item 0 has waited long enough to overtake item 1, but not item 2.
+	require.NoError(t, pool.SubmitWithPriority(1, 2))
+	require.NoError(t, pool.SubmitWithPriority(2, 4))
+	pool.Finish()
+	pool.Start()
+
+	var order []int
+	for result := range pool.Results() {
+		order = append(order, result.Value)
+	}
+	assert.Equal(t, []int{2, 0, 1}, order)
+}
+
+func TestTypedPoolQueueSize(t *testing.T) {
+	release := make(chan struct{})
+	handler := func(abort <-chan struct{}, item int) (int, error) {
+		<-release
+		return item, nil
+	}
+	pool := NewTypedPool(1, handler)
+	pool.QueueSize = 2
+
+	assert.True(t, pool.TrySubmit(1))
+	assert.True(t, pool.TrySubmit(2))
+	assert.False(t, pool.TrySubmit(3))
+	assert.Equal(t, 2, pool.QueueLen())
+
+	// Submit blocks until a worker takes an item.
+	submitted := make(chan error)
+	go func() {
+		submitted <- pool.Submit(3)
+	}()
+	select {
+	case <-submitted:
+		t.Fatal("submit did not block on a full queue")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	pool.Start()
+	assert.NoError(t, <-submitted)
+	close(release)
+	pool.Finish()
+
+	count := 0
+	for range pool.Results() {
+		count++
+	}
+	assert.Equal(t, 3, count)
+	assert.NoError(t, pool.Wait())
+}
+
+func TestTypedPoolBlockedSubmitCancel(t *testing.T) {
+	pool := NewTypedPool(1, square)
+	pool.QueueSize = 1
+	require.NoError(t, pool.Submit(1))
+
+	submitted := make(chan error)
+	go func() {
+		submitted <- pool.Submit(2)
+	}()
+	pool.Cancel()
+	assert.ErrorIs(t, <-submitted, ErrPoolClosed)
+	assert.False(t, pool.TrySubmit(3))
+}
+
+func TestTypedPoolOrdered(t *testing.T) {
+	// Later items finish first.
+	handler := func(abort <-chan struct{}, item int) (int, error) {
+		time.Sleep(time.Duration(20-item) * time.Millisecond / 4)
+		return item, nil
+	}
+	for _, window := range []int{0, 3} {
+		pool := NewTypedPool(4, handler)
+		pool.Ordered = true
+		pool.OrderWindow = window
+		pool.Start()
+		go func() {
+			for i := 0; i < 20; i++ {
+				pool.Submit(i)
+			}
+			pool.Finish()
+		}()
+
+		var order []int
+		for result := range pool.Results() {
+			order = append(order, result.Value)
+		}
+		assert.NoError(t, pool.Wait())
+		assert.Len(t, order, 20)
+		for i, v := range order {
+			assert.Equal(t, i, v)
+		}
+	}
+}
+
+func TestTypedPoolOrderedPanic(t *testing.T) {
+	handler := func(abort <-chan struct{}, item int) (int, error) {
+		if item == 1 {
+			panic("boom")
+		}
+		return item, nil
+	}
+	pool := NewTypedPool(2, handler)
+	pool.Ordered = true
+	pool.RecoverPanics = true
+	for i := 0; i < 3; i++ {
+		require.NoError(t, pool.Submit(i))
+	}
+	pool.Finish()
+	pool.Start()
+
+	var results []Result[int]
+	for result := range pool.Results() {
+		results = append(results, result)
+	}
+	assert.Equal(t, []Result[int]{{Value: 0}, {Err: ErrPanicked}, {Value: 2}}, results)
+}
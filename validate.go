@@ -0,0 +1,100 @@
+package workpool
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidConfig is wrapped by the errors returned from Validate.
+var ErrInvalidConfig = errors.New("workpool: invalid configuration")
+
+// Validate checks the configuration of the pool, returning every problem found joined with errors.Join. Each of them
+// wraps ErrInvalidConfig. Start calls Validate, and a pool which fails it is cancelled with the error instead of
+// starting any workers, so Run returns the error straight away.
+//
+// Zero values are valid wherever they select a default, as are the values of Workers below one which select
+// GOMAXPROCS.
+func (p *WorkPool) Validate() error {
+	var problems []error
+	invalid := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf("%w: "+format, append([]any{ErrInvalidConfig}, args...)...))
+	}
+
+	handlers := 0
+	for _, set := range []bool{p.Handler != nil, p.ErrHandler != nil, p.ContextHandler != nil, p.IndexedHandler != nil, p.StateHandler != nil, p.resourceHandler != nil} {
+		if set {
+			handlers++
+		}
+	}
+	switch {
+	case handlers == 0:
+		invalid("no handler is set")
+	case handlers > 1:
+		invalid("only one of Handler, ErrHandler, ContextHandler, IndexedHandler or StateHandler may be set")
+	}
+
+	if p.MaxTasksPerWorker < 0 {
+		invalid("MaxTasksPerWorker is negative")
+	}
+	if p.CollectErrors < 0 {
+		invalid("CollectErrors is negative")
+	}
+	if p.TaskTimeout < 0 {
+		invalid("TaskTimeout is negative")
+	}
+	if p.MaxRuntime < 0 {
+		invalid("MaxRuntime is negative")
+	}
+	if p.StuckThreshold < 0 {
+		invalid("StuckThreshold is negative")
+	}
+	if p.StallTimeout < 0 {
+		invalid("StallTimeout is negative")
+	}
+	if p.IdleTimeout < 0 {
+		invalid("IdleTimeout is negative")
+	}
+	if p.StateHandler != nil && p.WorkerState == nil {
+		invalid("StateHandler requires WorkerState")
+	}
+	if p.OnPanic != nil && !p.RecoverPanics && p.Supervisor == nil {
+		invalid("OnPanic requires RecoverPanics or a Supervisor")
+	}
+	if p.StuckThreshold > 0 && p.OnStuck == nil && p.Logger == nil {
+		invalid("StuckThreshold requires OnStuck or Logger")
+	}
+	if (p.StuckStacks || p.OnStuck != nil) && p.StuckThreshold <= 0 {
+		invalid("StuckStacks and OnStuck require StuckThreshold")
+	}
+	if p.StallTimeout > 0 && p.OnStall == nil && p.Logger == nil {
+		invalid("StallTimeout requires OnStall or Logger")
+	}
+	if p.OnStall != nil && p.StallTimeout <= 0 {
+		invalid("OnStall requires StallTimeout")
+	}
+	if p.SlowTaskThreshold > 0 && p.Logger == nil {
+		invalid("SlowTaskThreshold requires Logger")
+	}
+	if p.Autoscale != nil && p.Adaptive != nil {
+		invalid("Autoscale and Adaptive cannot both be set")
+	}
+	if p.Autoscale != nil && p.Workers > 0 && p.Autoscale.MinWorkers > p.Workers {
+		invalid("Autoscale.MinWorkers is more than Workers")
+	}
+	if p.Adaptive != nil && p.Adaptive.TargetLatency <= 0 {
+		invalid("Adaptive.TargetLatency must be positive")
+	}
+	if p.Inline && (p.Autoscale != nil || p.Adaptive != nil || p.MaxTasksPerWorker > 0 || p.lazy()) {
+		invalid("Inline cannot be combined with Autoscale, Adaptive, MaxTasksPerWorker or LazyWorkers")
+	}
+	return errors.Join(problems...)
+}
+
+// lazy reports whether the workers are started lazily.
+func (p *WorkPool) lazy() bool {
+	if p.demand == nil {
+		return false
+	}
+	lazy, _, _ := p.demand()
+	return lazy
+}
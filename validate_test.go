@@ -0,0 +1,128 @@
+package workpool
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	handler := func(abort <-chan struct{}) bool { return false }
+	assert.NoError(t, New(0, handler).Validate())
+	assert.NoError(t, NewTypedPool(2, func(abort <-chan struct{}, item int) (int, error) { return item, nil }).Validate())
+
+	for _, tc := range []struct {
+		name   string
+		pool   *WorkPool
+		errMsg string
+	}{
+		{
+			name:   "no handler",
+			pool:   &WorkPool{Workers: 1},
+			errMsg: "no handler is set",
+		},
+		{
+			name:   "two handlers",
+			pool:   &WorkPool{Handler: handler, ErrHandler: func(abort <-chan struct{}) (bool, error) { return false, nil }},
+			errMsg: "only one of Handler, ErrHandler, ContextHandler, IndexedHandler or StateHandler may be set",
+		},
+		{
+			name:   "state without factory",
+			pool:   &WorkPool{StateHandler: func(state any, abort <-chan struct{}) bool { return false }},
+			errMsg: "StateHandler requires WorkerState",
+		},
+		{
+			name:   "OnPanic without recovery",
+			pool:   &WorkPool{Handler: handler, OnPanic: func(any, []byte) {}},
+			errMsg: "OnPanic requires RecoverPanics or a Supervisor",
+		},
+		{
+			name:   "slow tasks without logger",
+			pool:   &WorkPool{Handler: handler, SlowTaskThreshold: time.Second},
+			errMsg: "SlowTaskThreshold requires Logger",
+		},
+		{
+			name:   "autoscale and adaptive",
+			pool:   &WorkPool{Handler: handler, Autoscale: &Autoscale{}, Adaptive: &AdaptiveConcurrency{TargetLatency: time.Second}},
+			errMsg: "Autoscale and Adaptive cannot both be set",
+		},
+		{
+			name:   "inline with recycling",
+			pool:   &WorkPool{Handler: handler, Inline: true, MaxTasksPerWorker: 10},
+			errMsg: "Inline cannot be combined with Autoscale, Adaptive, MaxTasksPerWorker or LazyWorkers",
+		},
+		{
+			name:   "stuck report without threshold",
+			pool:   &WorkPool{Handler: handler, OnStuck: func(StuckWorker, []byte) {}},
+			errMsg: "StuckStacks and OnStuck require StuckThreshold",
+		},
+		{
+			name:   "stall report without timeout",
+			pool:   &WorkPool{Handler: handler, OnStall: func(Stall) {}},
+			errMsg: "OnStall requires StallTimeout",
+		},
+		{
+			name:   "negative idle timeout",
+			pool:   &WorkPool{Handler: handler, IdleTimeout: -time.Second},
+			errMsg: "IdleTimeout is negative",
+		},
+		{
+			name:   "autoscale minimum",
+			pool:   &WorkPool{Handler: handler, Workers: 2, Autoscale: &Autoscale{MinWorkers: 3}},
+			errMsg: "Autoscale.MinWorkers is more than Workers",
+		},
+		{
+			name:   "adaptive target",
+			pool:   &WorkPool{Handler: handler, Adaptive: &AdaptiveConcurrency{}},
+			errMsg: "Adaptive.TargetLatency must be positive",
+		},
+		{
+			name:   "negative limits",
+			pool:   &WorkPool{Handler: handler, MaxTasksPerWorker: -1, TaskTimeout: -1},
+			errMsg: "MaxTasksPerWorker is negative\nworkpool: invalid configuration: TaskTimeout is negative",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.pool.Validate()
+			assert.ErrorIs(t, err, ErrInvalidConfig)
+			assert.EqualError(t, err, "workpool: invalid configuration: "+tc.errMsg)
+		})
+	}
+}
+
+func TestRunInvalid(t *testing.T) {
+	pool := &WorkPool{Workers: 2, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	err := pool.Run()
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.True(t, pool.Cancelled())
+	assert.ErrorIs(t, pool.AbortCause(), ErrInvalidConfig)
+}
+
+func TestRunInvalidOnCancel(t *testing.T) {
+	pool := &WorkPool{Workers: 2, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	var stats Stats
+	pool.OnCancel = func() { stats = pool.Stats() }
+
+	done := make(chan error)
+	go func() { done <- pool.Run() }()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnCancel deadlocked")
+	}
+	assert.Zero(t, stats.ActiveWorkers)
+}
+
+func TestInvalidTypedPoolRejectsSubmit(t *testing.T) {
+	pool := NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) { return item, nil })
+	pool.Handler = func(abort <-chan struct{}) bool { return false }
+	pool.Start()
+	assert.ErrorIs(t, pool.Submit(1), ErrPoolClosed)
+	for range pool.Results() {
+	}
+	assert.ErrorIs(t, pool.Wait(), ErrInvalidConfig)
+}
@@ -0,0 +1,63 @@
+package workpool
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+)
+
+// walkEntry is a file found by WalkDir.
+type walkEntry struct {
+	path  string
+	entry fs.DirEntry
+}
+
+// WalkDir walks the file tree rooted at root like filepath.WalkDir, and calls fn for every file and directory using
+// numWorkers workers. The tree is walked by a single goroutine while the workers process the entries already found.
+//
+// Errors are handled like ForEach: the first error returned by fn stops the walk as well as the workers, and is
+// returned. An error from the walk itself, such as an unreadable directory, also stops it and is returned once the
+// entries found before it have been processed. Because fn runs concurrently with the walk it cannot return
+// fs.SkipDir to skip a directory, it is treated like any other error.
+func WalkDir(ctx context.Context, root string, numWorkers int, fn func(ctx context.Context, path string, d fs.DirEntry) error) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	entries := make(chan walkEntry, numWorkers)
+	var walkErr error
+	go func() {
+		defer close(entries)
+		// Written before entries is closed, so it is visible once the workers have drained it.
+		walkErr = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			select {
+			case entries <- walkEntry{path: path, entry: d}:
+				return nil
+			case <-runCtx.Done():
+				return fs.SkipAll
+			}
+		})
+	}()
+
+	pool := NewWithError(numWorkers, func(abort <-chan struct{}) (bool, error) {
+		e, ok := receive(abort, entries)
+		if !ok {
+			return false, nil
+		}
+		if err := fn(runCtx, e.path, e.entry); err != nil {
+			cancel()
+			return false, err
+		}
+		return true, nil
+	})
+
+	if err := pool.RunContext(runCtx); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return walkErr
+}
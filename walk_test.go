@@ -0,0 +1,86 @@
+package workpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeTree creates the files, and their directories, under a temporary root.
+func makeTree(t *testing.T, files ...string) string {
+	root := t.TempDir()
+	for _, file := range files {
+		path := filepath.Join(root, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(file), 0o644))
+	}
+	return root
+}
+
+func TestWalkDir(t *testing.T) {
+	root := makeTree(t, "a.txt", "sub/b.txt", "sub/deeper/c.txt")
+
+	var mu sync.Mutex
+	var files, dirs []string
+	err := WalkDir(context.Background(), root, 3, func(ctx context.Context, path string, d fs.DirEntry) error {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if d.IsDir() {
+			dirs = append(dirs, filepath.ToSlash(rel))
+		} else {
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a.txt", "sub/b.txt", "sub/deeper/c.txt"}, files)
+	assert.ElementsMatch(t, []string{".", "sub", "sub/deeper"}, dirs)
+}
+
+func TestWalkDirError(t *testing.T) {
+	var files []string
+	for i := 0; i < 200; i++ {
+		files = append(files, fmt.Sprintf("dir%d/file%d.txt", i%10, i))
+	}
+	root := makeTree(t, files...)
+
+	var calls int64
+	err := WalkDir(context.Background(), root, 2, func(ctx context.Context, path string, d fs.DirEntry) error {
+		if atomic.AddInt64(&calls, 1) == 5 {
+			return errors.New("stop")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "stop")
+	assert.Less(t, atomic.LoadInt64(&calls), int64(len(files)))
+}
+
+func TestWalkDirMissingRoot(t *testing.T) {
+	err := WalkDir(context.Background(), filepath.Join(t.TempDir(), "missing"), 2, func(ctx context.Context, path string, d fs.DirEntry) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestWalkDirCancelled(t *testing.T) {
+	root := makeTree(t, "a.txt", "b.txt")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := WalkDir(ctx, root, 2, func(ctx context.Context, path string, d fs.DirEntry) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
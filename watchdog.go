@@ -0,0 +1,100 @@
+package workpool
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// startWatchdog starts checking for handler calls running for longer than StuckThreshold. It returns a function which
+// stops the watchdog. The caller must hold p.mu.
+func (p *WorkPool) startWatchdog() (stop func()) {
+	if p.StuckThreshold <= 0 {
+		return func() {}
+	}
+	quit := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		clock := p.clock()
+		// reported holds the start of the call each worker was last reported for, so a call is reported once.
+		reported := make(map[int]int64)
+		for {
+			timer := clock.NewTimer(p.StuckThreshold / 4)
+			select {
+			case <-timer.C():
+				p.checkStuck(clock.Now(), reported)
+			case <-quit:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+	return func() {
+		close(quit)
+		<-stopped
+	}
+}
+
+// checkStuck reports the calls which have become stuck since the last check.
+func (p *WorkPool) checkStuck(now time.Time, reported map[int]int64) {
+	var stuck []StuckWorker
+	p.mu.Lock()
+	running := make(map[int]bool, len(p.workers))
+	for _, w := range p.workers {
+		running[w.id] = true
+		started := w.calling.Load()
+		if started == 0 || reported[w.id] == started {
+			continue
+		}
+		if elapsed := now.Sub(time.Unix(0, started)); elapsed > p.StuckThreshold {
+			reported[w.id] = started
+			stuck = append(stuck, StuckWorker{ID: w.id, Running: elapsed})
+		}
+	}
+	p.mu.Unlock()
+	for id := range reported {
+		if !running[id] {
+			delete(reported, id)
+		}
+	}
+
+	for _, s := range stuck {
+		var stack []byte
+		if p.StuckStacks {
+			stack = p.workerStack(s.ID)
+		}
+		if p.OnStuck != nil {
+			p.OnStuck(s, stack)
+			continue
+		}
+		attrs := []any{"worker", s.ID, "duration", s.Running}
+		if stack != nil {
+			attrs = append(attrs, "stack", string(stack))
+		}
+		p.log(slog.LevelWarn, "handler call stuck", attrs...)
+	}
+}
+
+// workerStack returns the stacks of the goroutines carrying the profiler labels of a worker, which are the worker
+// and the goroutines started by its handler.
+func (p *WorkPool) workerStack(workerID int) []byte {
+	var profile bytes.Buffer
+	_ = pprof.Lookup("goroutine").WriteTo(&profile, 1)
+	pool := fmt.Sprintf("%q:%q", "workpool", p.Name)
+	worker := fmt.Sprintf("%q:%q", "worker", strconv.Itoa(workerID))
+	var stacks []string
+	for _, stack := range strings.Split(profile.String(), "\n\n") {
+		for _, line := range strings.Split(stack, "\n") {
+			if strings.HasPrefix(line, "# labels: ") && strings.Contains(line, pool) && strings.Contains(line, worker) {
+				stacks = append(stacks, stack)
+				break
+			}
+		}
+	}
+	return []byte(strings.Join(stacks, "\n\n"))
+}
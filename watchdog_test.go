@@ -0,0 +1,87 @@
+package workpool
+
+import (
+	"bytes"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchdog(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var reports []StuckWorker
+	var stacks [][]byte
+	pool := NewIndexed(2, func(workerID int, abort <-chan struct{}) bool {
+		if workerID == 1 {
+			<-release
+		}
+		return false
+	})
+	pool.Name = "watchdog"
+	pool.StuckThreshold = 20 * time.Millisecond
+	pool.StuckStacks = true
+	pool.OnStuck = func(stuck StuckWorker, stack []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, stuck)
+		stacks = append(stacks, stack)
+	}
+	pool.Start()
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(reports) > 0
+	}, time.Second, time.Millisecond)
+	// The same call is not reported again.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	require.NoError(t, pool.Wait())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, reports, 1)
+	assert.Equal(t, 1, reports[0].ID)
+	assert.Greater(t, reports[0].Running, 20*time.Millisecond)
+	assert.Contains(t, string(stacks[0]), `"worker":"1"`)
+	assert.Contains(t, string(stacks[0]), "TestWatchdog")
+}
+
+func TestWatchdogLog(t *testing.T) {
+	var buf bytes.Buffer
+	var mu sync.Mutex
+	release := make(chan struct{})
+	pool := New(1, func(abort <-chan struct{}) bool {
+		<-release
+		return false
+	})
+	pool.StuckThreshold = 10 * time.Millisecond
+	pool.Logger = slog.New(slog.NewTextHandler(&lockedWriter{mu: &mu, w: &buf}, nil))
+	pool.Start()
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return bytes.Contains(buf.Bytes(), []byte("handler call stuck"))
+	}, time.Second, time.Millisecond)
+	close(release)
+	require.NoError(t, pool.Wait())
+	assert.NotContains(t, buf.String(), "stack=")
+}
+
+// lockedWriter serializes writes to w.
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  *bytes.Buffer
+}
+
+func (l *lockedWriter) Write(b []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(b)
+}
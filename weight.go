@@ -0,0 +1,112 @@
+package workpool
+
+import (
+	"container/list"
+	"sync"
+)
+
+// weight returns the weight of an item, clamped to MaxWeight so that an item heavier than the limit can still run on
+// its own.
+func (p *TypedPool[In, Out]) weight(item In) int64 {
+	w := int64(1)
+	if p.Weight != nil {
+		w = p.Weight(item)
+	}
+	if w < 0 {
+		w = 0
+	}
+	if w > p.MaxWeight {
+		w = p.MaxWeight
+	}
+	return w
+}
+
+// acquireWeight waits until the item fits within MaxWeight. It returns a function releasing the weight, or false if
+// abort was closed first.
+func (p *TypedPool[In, Out]) acquireWeight(item In, abort <-chan struct{}) (func(), bool) {
+	if p.MaxWeight <= 0 {
+		return func() {}, true
+	}
+	p.semOnce.Do(func() {
+		p.sem = newSemaphore(p.MaxWeight)
+	})
+	w := p.weight(item)
+	if !p.sem.acquire(w, abort) {
+		return nil, false
+	}
+	return func() { p.sem.release(w) }, true
+}
+
+// semaphore is a weighted semaphore. Waiters are served in order, so that a heavy item is not starved by lighter ones.
+type semaphore struct {
+	mu      sync.Mutex
+	size    int64
+	cur     int64
+	waiters list.List
+}
+
+// waiter is a blocked call to acquire.
+type waiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+func newSemaphore(size int64) *semaphore {
+	return &semaphore{size: size}
+}
+
+// acquire takes n units, blocking until they are available. False is returned if abort is closed first.
+func (s *semaphore) acquire(n int64, abort <-chan struct{}) bool {
+	s.mu.Lock()
+	if s.waiters.Len() == 0 && s.cur+n <= s.size {
+		s.cur += n
+		s.mu.Unlock()
+		return true
+	}
+	w := waiter{n: n, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return true
+	case <-abort:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-w.ready:
+		// Acquired while aborting, give the units back.
+		s.cur -= n
+	default:
+		s.waiters.Remove(elem)
+	}
+	s.notify()
+	return false
+}
+
+// release returns n units.
+func (s *semaphore) release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cur -= n
+	s.notify()
+}
+
+// notify wakes up the waiters which fit, in order. The caller holds mu.
+func (s *semaphore) notify() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(waiter)
+		if s.cur+w.n > s.size {
+			return
+		}
+		s.cur += w.n
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,96 @@
+package workpool
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxWeight(t *testing.T) {
+	var inFlight, peak int64
+	pool := NewTypedPool(8, func(abort <-chan struct{}, item int) (int, error) {
+		n := atomic.AddInt64(&inFlight, int64(item))
+		defer atomic.AddInt64(&inFlight, -int64(item))
+		for {
+			p := atomic.LoadInt64(&peak)
+			if n <= p || atomic.CompareAndSwapInt64(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return item, nil
+	})
+	pool.MaxWeight = 10
+	pool.Weight = func(item int) int64 { return int64(item) }
+	pool.Start()
+
+	go func() {
+		for i := 0; i < 50; i++ {
+			pool.Submit(1 + i%7)
+		}
+		pool.Finish()
+	}()
+	count := 0
+	for result := range pool.Results() {
+		require.NoError(t, result.Err)
+		count++
+	}
+	assert.Equal(t, 50, count)
+	assert.LessOrEqual(t, atomic.LoadInt64(&peak), int64(10))
+	assert.NoError(t, pool.Wait())
+}
+
+func TestMaxWeightOversized(t *testing.T) {
+	pool := NewTypedPool(2, square)
+	pool.MaxWeight = 2
+	pool.Weight = func(item int) int64 { return 100 }
+	pool.Start()
+	require.NoError(t, pool.Submit(3))
+	require.NoError(t, pool.Submit(4))
+	pool.Finish()
+
+	sum := 0
+	for result := range pool.Results() {
+		sum += result.Value
+	}
+	assert.Equal(t, 25, sum)
+	assert.NoError(t, pool.Wait())
+}
+
+func TestSemaphore(t *testing.T) {
+	s := newSemaphore(3)
+	never := make(chan struct{})
+	require.True(t, s.acquire(2, never))
+
+	// A heavy waiter blocks lighter ones queued after it.
+	heavy := make(chan bool)
+	go func() { heavy <- s.acquire(3, never) }()
+	assert.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.waiters.Len() == 1
+	}, time.Second, time.Millisecond)
+
+	abort := make(chan struct{})
+	light := make(chan bool)
+	go func() { light <- s.acquire(1, abort) }()
+	select {
+	case <-light:
+		t.Fatal("light waiter jumped the queue")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(abort)
+	assert.False(t, <-light)
+	s.release(2)
+	assert.True(t, <-heavy)
+	s.release(3)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	assert.Zero(t, s.cur)
+	assert.Zero(t, s.waiters.Len())
+}
@@ -0,0 +1,122 @@
+package workpool
+
+import (
+	"fmt"
+	"time"
+)
+
+// Window is a group of items which arrived within a span of time, as sent by the TumblingWindows and SlidingWindows
+// stages.
+type Window[T any] struct {
+	// Start and End are the span of the window: it holds the items which arrived at or after Start and before End.
+	Start time.Time
+	End   time.Time
+
+	// Items are the items of the window in the order they arrived.
+	Items []T
+}
+
+// TumblingWindows adds a stage which groups the items read from in into consecutive windows of the given size, such
+// as per-minute rollups, sent to the returned channel once each window ends. Windows are aligned on multiples of size
+// since the zero time, so that windows of a minute start on the minute. Windows without items are not sent. A size of
+// zero or less fails the pipeline with an error wrapping ErrInvalidConfig.
+func TumblingWindows[T any](p *Pipeline, in <-chan T, size time.Duration) <-chan Window[T] {
+	return SlidingWindows(p, in, size, size)
+}
+
+// SlidingWindows is like TumblingWindows, but a new window starts every slide, so that windows overlap when slide is
+// less than size and every item is part of several windows. A slide of zero or less is size.
+//
+// Windows are sent in the order they start. Once in is closed, the windows which have not ended yet are sent with the
+// items they have, then the returned channel is closed.
+func SlidingWindows[T any](p *Pipeline, in <-chan T, size, slide time.Duration) <-chan Window[T] {
+	out := make(chan Window[T])
+	if size <= 0 {
+		err := fmt.Errorf("%w: window size %v is not positive", ErrInvalidConfig, size)
+		p.add(&WorkPool{
+			Workers: 1,
+			ErrHandler: func(abort <-chan struct{}) (bool, error) {
+				p.Cancel()
+				return false, err
+			},
+			Close: func() {
+				close(out)
+			},
+		})
+		return out
+	}
+	if slide <= 0 {
+		slide = size
+	}
+	// open are the windows which have items and have not ended, in the order they start.
+	var open []*Window[T]
+	send := func(w *Window[T], abort <-chan struct{}) bool {
+		select {
+		case out <- *w:
+			return true
+		case <-abort:
+			return false
+		}
+	}
+	p.add(&WorkPool{
+		Workers: 1,
+		Handler: func(abort <-chan struct{}) bool {
+			var ended <-chan time.Time
+			if len(open) > 0 {
+				timer := time.NewTimer(time.Until(open[0].End))
+				defer timer.Stop()
+				ended = timer.C
+			}
+			select {
+			case item, ok := <-in:
+				if !ok {
+					for _, w := range open {
+						if !send(w, abort) {
+							return false
+						}
+					}
+					return false
+				}
+				open = addToWindows(open, item, time.Now(), size, slide)
+			case now := <-ended:
+				for len(open) > 0 && !open[0].End.After(now) {
+					if !send(open[0], abort) {
+						return false
+					}
+					open = open[1:]
+				}
+			case <-abort:
+				return false
+			}
+			return true
+		},
+		Close: func() {
+			close(out)
+		},
+	})
+	return out
+}
+
+// addToWindows adds an item which arrived at now to every window containing now, opening the windows which do not
+// exist yet. The windows are kept in the order they start.
+func addToWindows[T any](open []*Window[T], item T, now time.Time, size, slide time.Duration) []*Window[T] {
+	// The windows containing now start at the multiples of slide after now-size, up to now.
+	first := now.Add(-size).Truncate(slide)
+	if !first.After(now.Add(-size)) {
+		first = first.Add(slide)
+	}
+	i := 0
+	for start := first; !start.After(now); start = start.Add(slide) {
+		for i < len(open) && open[i].Start.Before(start) {
+			i++
+		}
+		if i == len(open) || !open[i].Start.Equal(start) {
+			w := &Window[T]{Start: start, End: start.Add(size)}
+			open = append(open, nil)
+			copy(open[i+1:], open[i:])
+			open[i] = w
+		}
+		open[i].Items = append(open[i].Items, item)
+	}
+	return open
+}
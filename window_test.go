@@ -0,0 +1,117 @@
+package workpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddToWindows(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var open []*Window[string]
+	open = addToWindows(open, "a", base.Add(10*time.Second), time.Minute, 30*time.Second)
+	open = addToWindows(open, "b", base.Add(40*time.Second), time.Minute, 30*time.Second)
+	open = addToWindows(open, "c", base.Add(60*time.Second), time.Minute, 30*time.Second)
+
+	require.Len(t, open, 4)
+	for i, want := range []struct {
+		start time.Duration
+		items []string
+	}{
+		{-30 * time.Second, []string{"a"}},
+		{0, []string{"a", "b"}},
+		{30 * time.Second, []string{"b", "c"}},
+		{60 * time.Second, []string{"c"}},
+	} {
+		assert.Equal(t, base.Add(want.start), open[i].Start, "window %d", i)
+		assert.Equal(t, base.Add(want.start+time.Minute), open[i].End, "window %d", i)
+		assert.Equal(t, want.items, open[i].Items, "window %d", i)
+	}
+}
+
+func TestTumblingWindows(t *testing.T) {
+	p := NewPipeline()
+	in := make(chan int)
+	var windows []Window[int]
+	tumbling := TumblingWindows(p, (<-chan int)(in), 20*time.Millisecond)
+	Sink(p, 1, tumbling, func(abort <-chan struct{}, w Window[int]) error {
+		windows = append(windows, w)
+		return nil
+	})
+	done := make(chan error)
+	go func() {
+		done <- p.Run()
+	}()
+
+	in <- 1
+	in <- 2
+	time.Sleep(50 * time.Millisecond)
+	in <- 3
+	close(in)
+	require.NoError(t, <-done)
+
+	// The first two items may straddle a boundary, the third one is always in a later window.
+	require.GreaterOrEqual(t, len(windows), 2)
+	var items []int
+	for i, w := range windows {
+		assert.Equal(t, 20*time.Millisecond, w.End.Sub(w.Start))
+		assert.NotEmpty(t, w.Items)
+		if i > 0 {
+			assert.False(t, w.Start.Before(windows[i-1].End), "tumbling windows do not overlap")
+		}
+		items = append(items, w.Items...)
+	}
+	assert.Equal(t, []int{1, 2, 3}, items)
+	assert.Equal(t, []int{3}, windows[len(windows)-1].Items)
+}
+
+func TestSlidingWindows(t *testing.T) {
+	p := NewPipeline()
+	numbers := Source(p, 1, 2, 3)
+	var windows []Window[int]
+	Sink(p, 1, SlidingWindows(p, numbers, time.Hour, 20*time.Minute), func(abort <-chan struct{}, w Window[int]) error {
+		windows = append(windows, w)
+		return nil
+	})
+
+	// The windows are an hour long and still open when the source finishes, so they are all flushed, and the items
+	// are in each of the three windows containing them.
+	require.NoError(t, p.Run())
+	count := map[int]int{}
+	for i, w := range windows {
+		if i > 0 {
+			assert.Equal(t, 20*time.Minute, w.Start.Sub(windows[i-1].Start))
+		}
+		for _, item := range w.Items {
+			count[item]++
+		}
+	}
+	assert.Equal(t, map[int]int{1: 3, 2: 3, 3: 3}, count)
+}
+
+func TestWindowsCancel(t *testing.T) {
+	p := NewPipeline()
+	in := make(chan int, 1)
+	in <- 1
+	windows := TumblingWindows(p, (<-chan int)(in), time.Hour)
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		p.Cancel()
+	}()
+
+	assert.NoError(t, p.Run())
+	_, open := <-windows
+	assert.False(t, open)
+}
+
+func TestWindowsInvalidSize(t *testing.T) {
+	p := NewPipeline()
+	in := make(chan int)
+	windows := SlidingWindows(p, (<-chan int)(in), 0, time.Second)
+	Sink(p, 1, windows, func(abort <-chan struct{}, w Window[int]) error { return nil })
+	err := p.Run()
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.EqualError(t, err, "workpool: invalid configuration: window size 0s is not positive")
+}
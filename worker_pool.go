@@ -5,7 +5,14 @@
 package workpool
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // WorkHandler is a blocking call which manages the retrieval and processing of work. It should either process all work,
@@ -47,12 +54,29 @@ import (
 //   }
 type WorkHandler func(abort <-chan struct{}) bool
 
+// ErrWorkHandler is like WorkHandler, but it may also return an error. A non-nil error does not stop the worker, the
+// boolean result still decides whether the handler is called again. The first error returned by any handler is
+// returned from Run.
+type ErrWorkHandler func(abort <-chan struct{}) (bool, error)
+
+// ContextWorkHandler is like WorkHandler, but it is given a context instead of an abort signal. The pool creates a
+// context for every call, which is cancelled when the pool is cancelled or TaskTimeout expires. It carries the values
+// of the context given to RunContext, and the worker ID which can be read with WorkerID.
+type ContextWorkHandler func(ctx context.Context) bool
+
+// IndexedWorkHandler is like WorkHandler, but it is also given the ID of the worker calling it. IDs are the lowest
+// numbers not in use by another running worker, so with a fixed number of workers they range from zero to Workers-1.
+type IndexedWorkHandler func(workerID int, abort <-chan struct{}) bool
+
+// StateWorkHandler is like WorkHandler, but it is also given the state created by WorkerState for the worker calling
+// it. The state is only used by one worker, so it needs no synchronization.
+type StateWorkHandler func(state any, abort <-chan struct{}) bool
+
 // New creates a worker pool with a given handler function.
 func New(numWorkers int, handler WorkHandler) *WorkPool {
 	return &WorkPool{
 		Handler: handler,
 		Workers: numWorkers,
-		abort:   make(chan struct{}),
 	}
 }
 
@@ -61,63 +85,830 @@ func NewWithClose(numWorkers int, handler WorkHandler, close func()) *WorkPool {
 	return &WorkPool{
 		Handler: handler,
 		Workers: numWorkers,
-		abort:   make(chan struct{}),
 		Close:   close,
 	}
 }
 
+// NewWithError creates a worker pool with a given error returning handler function.
+func NewWithError(numWorkers int, handler ErrWorkHandler) *WorkPool {
+	return &WorkPool{
+		ErrHandler: handler,
+		Workers:    numWorkers,
+	}
+}
+
+// NewWithContext creates a worker pool with a given context aware handler function.
+func NewWithContext(numWorkers int, handler ContextWorkHandler) *WorkPool {
+	return &WorkPool{
+		ContextHandler: handler,
+		Workers:        numWorkers,
+	}
+}
+
+// NewIndexed creates a worker pool with a given handler function which receives the worker ID.
+func NewIndexed(numWorkers int, handler IndexedWorkHandler) *WorkPool {
+	return &WorkPool{
+		IndexedHandler: handler,
+		Workers:        numWorkers,
+	}
+}
+
+// NewWithState creates a worker pool with a given handler function, and a function creating the state of each worker.
+func NewWithState(numWorkers int, state func(workerID int) (any, func()), handler StateWorkHandler) *WorkPool {
+	return &WorkPool{
+		StateHandler: handler,
+		WorkerState:  state,
+		Workers:      numWorkers,
+	}
+}
+
 // WorkPool manages running a WorkHandler in some number of goroutines. It also manages a cancel signal to allow for
 // early termination.
 type WorkPool struct {
 	// Handler is called repeatedly until all work is finished.
 	Handler WorkHandler
 
-	// Workers is the number of go routines used to call the handler.
+	// ErrHandler is used instead of Handler when errors need to be reported.
+	ErrHandler ErrWorkHandler
+
+	// ContextHandler is used instead of Handler when the handler works with a context rather than an abort signal.
+	ContextHandler ContextWorkHandler
+
+	// IndexedHandler is used instead of Handler when the handler needs to know which worker is calling it.
+	IndexedHandler IndexedWorkHandler
+
+	// StateHandler is used instead of Handler when the handler uses the state created by WorkerState. Only one of
+	// Handler, ErrHandler, ContextHandler, IndexedHandler or StateHandler should be set.
+	StateHandler StateWorkHandler
+
+	// WorkerState, if set, is called as each worker starts to create state which the worker keeps to itself, such as
+	// a reusable buffer, parser or connection. The state is given to StateHandler, and can be read with State from
+	// the context given to ContextHandler. The returned cleanup function, if not nil, is called when the worker exits.
+	WorkerState func(workerID int) (state any, cleanup func())
+
+	// Inline runs the handler in the goroutine calling Run or Start, which then block until the pool finishes, as a
+	// single worker. Without goroutines of its own the pool executes deterministically and can be stepped through in
+	// a debugger, which helps tests and debugging sessions. Workers is set to one, and the options which add workers
+	// at runtime, Autoscale, Adaptive, MaxTasksPerWorker and LazyWorkers, cannot be used.
+	Inline bool
+
+	// Workers is the number of go routines used to call the handler. If it is zero or negative when the pool starts,
+	// it is set to runtime.GOMAXPROCS(0).
 	Workers int
 
-	// abort is used to notify workers that they should terminate early.
-	abort chan struct{}
+	// Name identifies the pool in profiles. Worker goroutines carry the pprof labels "workpool", set to Name, and
+	// "worker", set to the worker ID.
+	Name string
+
+	// Clock, when set, replaces the system clock for the pool's timing and timers.
+	Clock Clock
 
 	// Close is called after all work is finished.
 	Close func()
+
+	// CancelOnError cancels the pool as soon as the first error is recorded, like an errgroup, so that the other
+	// workers stop instead of carrying on with work which is bound to be wasted. The error is the cause returned by
+	// AbortCause, and is returned from Run.
+	CancelOnError bool
+
+	// CollectErrors, when positive, makes Run return every error recorded, joined with errors.Join, instead of only
+	// the first one. At most CollectErrors errors are kept, the number left out is noted in a final error, and
+	// ErrorCount counts all of them.
+	CollectErrors int
+
+	// RecoverPanics recovers from a panicking handler instead of crashing the program. What happens to the worker
+	// afterwards is decided by PanicPolicy.
+	RecoverPanics bool
+
+	// OnPanic is called with the recovered value and a stack trace when RecoverPanics is set.
+	OnPanic func(recovered any, stack []byte)
+
+	// PanicPolicy decides whether a worker is restarted or finished after a recovered panic.
+	PanicPolicy PanicPolicy
+
+	// PanicBackoff is how long a worker restarted by PanicRestart waits before calling the handler again, so that a
+	// handler which always panics does not spin. It doubles with every consecutive panic of the worker, up to
+	// MaxPanicBackoff. Zero waits 100 milliseconds, MaxPanicBackoff zero limits the wait to 30 seconds.
+	PanicBackoff    time.Duration
+	MaxPanicBackoff time.Duration
+
+	// OnWorkerStart, when set, is called by each worker before it calls the handler. If it returns an error the worker
+	// exits without calling the handler, and the error is returned from Run. Worker IDs are the lowest numbers not in
+	// use by another running worker, starting at zero.
+	OnWorkerStart func(workerID int) error
+
+	// OnWorkerStop, when set, is called by each worker after it has finished calling the handler. It is not called if
+	// OnWorkerStart failed.
+	OnWorkerStop func(workerID int)
+
+	// OnWorkerExit, when set, is called whenever a worker exits, including when OnWorkerStart failed, with the reason
+	// it exited.
+	OnWorkerExit func(workerID int, reason ExitReason)
+
+	// OnStart, when set, is called by Start before any worker is started.
+	OnStart func()
+
+	// OnStop, when set, is called once the run has finished and Close has returned, with the error Run returns. Wait
+	// returns after OnStop.
+	OnStop func(err error)
+
+	// OnCancel, when set, is called the first time the pool is cancelled, by Cancel, CancelWithCause or by the context
+	// given to RunContext.
+	OnCancel func()
+
+	// TaskTimeout, when positive, limits how long a single handler call may take. When it expires the abort signal
+	// given to that call is closed, and the timeout is counted in Stats.
+	TaskTimeout time.Duration
+
+	// MaxTasksPerWorker, when positive, replaces a worker with a new one after this many handler calls which found
+	// work. OnWorkerStop is called for the old worker and OnWorkerStart for its replacement, so that per-worker
+	// resources can be rebuilt periodically.
+	MaxTasksPerWorker int
+
+	// Supervisor, when set, restarts workers which fail with a fatal error or a panic.
+	Supervisor *Supervisor
+
+	// Autoscale, when set, adjusts the number of running workers between Autoscale.MinWorkers and Workers based on
+	// whether the handler reports ErrNoWork.
+	Autoscale *Autoscale
+
+	// Adaptive, when set, adjusts the number of running workers between Adaptive.MinWorkers and Workers to keep the
+	// handler latency under Adaptive.TargetLatency.
+	Adaptive *AdaptiveConcurrency
+
+	// Limiter, when set, is waited on before every handler call. Since it is shared by all workers it limits the rate
+	// of the whole pool.
+	Limiter Limiter
+
+	// Deadline, when set, cancels the pool at the given time. The cause returned by AbortCause is ErrDeadlineExceeded,
+	// and Stats reports how much work was done before the cutoff.
+	Deadline time.Time
+
+	// MaxRuntime, when positive, cancels the pool once it has been running for this long, like Deadline. If both are
+	// set the earlier one applies.
+	MaxRuntime time.Duration
+
+	// Logger, when set, receives structured events about the pool: start and finish, workers starting and stopping,
+	// cancellation, recovered panics and slow handler calls.
+	Logger *slog.Logger
+
+	// Metrics, when set, receives counters, gauges and timings of the pool's activity, see MetricsSink.
+	Metrics MetricsSink
+
+	// HealthInterval is how long a handler call may run before Healthy reports the worker as stuck. Zero uses one
+	// minute.
+	HealthInterval time.Duration
+
+	// OnProgress, when set, is called every ProgressInterval while the pool runs with the number of completed tasks
+	// and the number expected, see Progress. It is called once more when the pool finishes.
+	OnProgress func(done, total int64)
+
+	// ProgressInterval is how often OnProgress is called. Zero calls it every second.
+	ProgressInterval time.Duration
+
+	// ThroughputWindow is the period over which Progress measures the throughput. Zero uses ten seconds.
+	ThroughputWindow time.Duration
+
+	// SlowTaskThreshold, when positive, logs a warning for every handler call taking longer than this. It requires
+	// Logger.
+	SlowTaskThreshold time.Duration
+
+	// StuckThreshold, when positive, starts a watchdog which reports every handler call which has been running for
+	// longer than this, once per call, to OnStuck or otherwise to Logger. Calls are checked four times per threshold.
+	StuckThreshold time.Duration
+
+	// StuckStacks adds the goroutine stacks of a stuck worker, and of the goroutines it started, to its report.
+	StuckStacks bool
+
+	// OnStuck, when set, is called by the watchdog with each stuck handler call. The stack is nil unless StuckStacks is
+	// set.
+	OnStuck func(stuck StuckWorker, stack []byte)
+
+	// StallTimeout, when positive, detects a pool in which no handler call has returned for this long although it is
+	// running, and neither cancelled, paused nor idle, such as when every worker is blocked sending its result. The
+	// stall is reported once, to OnStall or otherwise to Logger. A TypedPool is idle while all of its workers are
+	// waiting for items.
+	StallTimeout time.Duration
+
+	// OnStall, when set, is called with a description of each stall detected because of StallTimeout.
+	OnStall func(stall Stall)
+
+	// IdleTimeout, when positive, shuts the pool down once no handler call has found work for this long, for workers
+	// which should exit when their queue runs dry. The pool is cancelled with ErrIdleTimeout, which Run returns unless
+	// a handler returned an error. A TypedPool is not idle while an item is being processed, for other pools a handler
+	// call which takes longer than IdleTimeout counts as idle, as does one blocking while it waits for work.
+	IdleTimeout time.Duration
+
+	// children are the sub-pools added with AddChild.
+	childMu  sync.Mutex
+	children []Pool
+
+	// ctx is cancelled to notify workers that they should terminate early.
+	ctx        context.Context
+	cancel     context.CancelCauseFunc
+	parent     context.Context
+	once       sync.Once
+	cancelOnce sync.Once
+
+	// aborted is set just before ctx is cancelled, so that workers can check for cancellation between handler calls
+	// without a select.
+	aborted atomic.Bool
+
+	// workerFailed is set once a worker stopped because of an error, for StopReason: its resource could not be opened,
+	// OnWorkerStart failed, or it ran out of Supervisor restarts.
+	workerFailed atomic.Bool
+
+	// err is the first error returned by a handler, errs are the errors kept for CollectErrors and errCount counts
+	// all of them.
+	errMu    sync.Mutex
+	err      error
+	errs     []error
+	errCount int
+
+	counters *counters
+
+	// resumed is closed unless the pool is paused, pausing mirrors paused for the workers to check without locking.
+	pauseMu sync.Mutex
+	paused  bool
+	pausing atomic.Bool
+	resumed atomic.Value
+
+	// mu guards the worker bookkeeping below, which allows the pool to be resized while running.
+	mu       sync.Mutex
+	launched bool
+	running  bool
+	workers  []*worker
+	retiring map[*worker]struct{}
+	live     int
+	ids      []bool
+	finished chan struct{}
+	started  time.Time
+	stopped  time.Time
+	done     chan struct{}
+
+	middleware []layer
+
+	// itemized is set by pools which count the tasks for Progress themselves.
+	itemized bool
+
+	// demand is set by pools which own their queue. It reports whether workers are started lazily, how many items
+	// are queued and whether the queue is closed. holding keeps a lazy pool running without workers until it is
+	// released.
+	demand  func() (lazy bool, queued int, closed bool)
+	holding bool
+
+	// idle is set by pools which own their queue. It reports whether all of the workers are waiting for work.
+	idle func() bool
+
+	// queueStats is set by pools which own their queue, to fill in its counters in Stats.
+	queueStats func(stats *Stats)
+
+	// provision opens the resource of a worker, and resourceHandler is given it, in a pool created by
+	// NewWithResources. provisioned holds the resources opened by Start for the first workers.
+	provision       func(workerID int) (resource any, cleanup func(), err error)
+	resourceHandler func(resource any, abort <-chan struct{}) (bool, error)
+	provisioned     map[int]provisioned
+}
+
+// worker is the bookkeeping for a single worker goroutine.
+type worker struct {
+	id int
+
+	// idle counts consecutive idle handler calls for Autoscale.
+	idle int
+
+	// quit is closed to ask the worker to exit after its current handler call, retiring is set just before. exited
+	// is closed once the worker has exited.
+	quit     chan struct{}
+	retiring atomic.Bool
+	exited   chan struct{}
+
+	// calling is when the current handler call started in Unix nanoseconds, or zero between calls.
+	calling atomic.Int64
+
+	// failure is the fatal error which stopped the worker, and restarts counts the consecutive restarts by the
+	// Supervisor.
+	failure  error
+	restarts int
+
+	// panics counts the consecutive panics of the worker for PanicBackoff.
+	panics int
+
+	// ctx is the context of the current handler call, and call the context passed on by a ContextMiddleware, if any.
+	ctx  context.Context
+	call context.Context
+
+	// tasks counts the handler calls which found work for MaxTasksPerWorker, recycle is set once the worker should be
+	// replaced.
+	tasks   int
+	recycle bool
+
+	// state is created by WorkerState.
+	state any
+
+	// resource is opened for the worker by a pool created with NewWithResources.
+	resource any
 }
 
 // Run starts the configured number of workers and calls WorkHandler until all work has been processed, or the execution
-// is cancelled.
-func (p *WorkPool) Run() {
-	if p.abort == nil {
-		p.abort = make(chan struct{})
-	}
-	if p.Close != nil {
-		defer p.Close()
-	}
-	var wg sync.WaitGroup
-	// Start workers
-	wg.Add(p.Workers)
-	for i := 0; i < p.Workers; i++ {
-		go func() {
-			defer wg.Done()
-			handler := p.Handler
-			for true {
-				select {
-				case <-p.abort:
+// is cancelled. The first error returned by an ErrWorkHandler is returned.
+func (p *WorkPool) Run() error {
+	p.Start()
+	return p.Wait()
+}
+
+// Start is like Run, but it does not block. Use Wait to block until all work has been processed, or the execution is
+// cancelled. Calling Start more than once has no effect. With Inline set Start runs the handler itself, and returns
+// once the pool has finished.
+func (p *WorkPool) Start() {
+	p.init()
+	p.mu.Lock()
+	if p.launched {
+		p.mu.Unlock()
+		return
+	}
+	p.launched = true
+	invalid := p.Validate()
+	if p.Inline {
+		p.Workers = 1
+	}
+	if p.Workers <= 0 {
+		p.Workers = runtime.GOMAXPROCS(0)
+	}
+	n := 0
+	if invalid == nil && !p.Inline {
+		n = p.initialWorkers()
+	}
+	p.mu.Unlock()
+
+	// The hook and the resources are user code, which may call back into the pool, so they run without the lock.
+	if p.OnStart != nil {
+		p.OnStart()
+	}
+	provisioned, openErr := p.provisionWorkers(n)
+
+	p.mu.Lock()
+	p.running = true
+	p.started = p.clock().Now()
+	p.finished = make(chan struct{})
+	p.log(slog.LevelInfo, "workpool started", "workers", p.Workers)
+	stopDeadline := p.startDeadline()
+	stopProgress := p.startProgress()
+	stopWatchdog := p.startWatchdog()
+	stopStallDetector := p.startStallDetector()
+	stopIdleTimeout := p.startIdleTimeout()
+	var inline func()
+	var failure error
+	switch {
+	case invalid != nil:
+		p.log(slog.LevelError, "workpool configuration is invalid", "error", invalid)
+		p.setErr(invalid)
+		failure = invalid
+	case p.Inline:
+		inline = p.newWorker()
+	case openErr != nil:
+		p.log(slog.LevelError, "workpool resources could not be opened", "error", openErr)
+		p.setErr(openErr)
+		failure = openErr
+	default:
+		p.provisioned = provisioned
+		for i := 0; i < n; i++ {
+			p.startWorker()
+		}
+	}
+	if p.live == 0 && !p.holding {
+		p.stopRunning()
+	}
+
+	// Wait until the goroutines finish. By cancellation or otherwise.
+	finish := func(finished <-chan struct{}) {
+		<-finished
+		stopDeadline()
+		stopProgress()
+		stopWatchdog()
+		stopStallDetector()
+		stopIdleTimeout()
+		if p.Close != nil {
+			p.Close()
+		}
+		p.logFinished()
+		if p.OnStop != nil {
+			p.OnStop(p.Err())
+		}
+		close(p.done)
+	}
+	finished := p.finished
+	p.mu.Unlock()
+	if failure != nil {
+		// Cancelling calls OnCancel, so it happens once the lock is released.
+		p.CancelWithCause(failure)
+	}
+	p.startChildren()
+
+	if inline != nil {
+		inline()
+		finish(finished)
+		return
+	}
+	go finish(finished)
+}
+
+// Wait blocks until the pool started by Start has finished, and Close has returned. The first error returned by an
+// ErrWorkHandler is returned. A pool with children also waits for them, see AddChild, and returns their errors joined
+// with its own. It is safe to call Wait from multiple goroutines.
+func (p *WorkPool) Wait() error {
+	p.init()
+	<-p.done
+	return p.waitChildren(p.Err())
+}
+
+// startWorker starts a new worker goroutine. The caller must hold p.mu.
+func (p *WorkPool) startWorker() {
+	go p.newWorker()()
+}
+
+// newWorker adds a worker to the bookkeeping and returns the function which runs it. The caller must hold p.mu, but
+// not while running the worker.
+func (p *WorkPool) newWorker() (run func()) {
+	w := &worker{id: p.allocID(), quit: make(chan struct{}), exited: make(chan struct{})}
+	middleware := append([]layer(nil), p.middleware...)
+	p.workers = append(p.workers, w)
+	p.live++
+	p.gaugeWorkers()
+	return func() {
+		// The middleware is built by the worker, outside of the lock.
+		handler := p.handler(w, middleware)
+		p.labelled(w, func() {
+			defer p.exitWorker(w)
+			if p.provision != nil {
+				cleanup, err := p.provisionWorker(w)
+				if err != nil {
+					p.setErr(err)
+					p.workerFailed.Store(true)
 					return
-				default:
-					foundWork := handler(p.abort)
-					if !foundWork {
-						return
-					}
 				}
+				defer cleanup()
 			}
+			if p.WorkerState != nil {
+				var cleanup func()
+				w.state, cleanup = p.WorkerState(w.id)
+				if cleanup != nil {
+					defer cleanup()
+				}
+			}
+			for {
+				reason := p.runOnce(w, handler)
+				w.recycle = reason == ExitRecycled
+				if reason != ExitFailed {
+					return
+				}
+				if !p.restart(w) {
+					p.setErr(w.failure)
+					p.workerFailed.Store(true)
+					return
+				}
+			}
+		})
+	}
+}
+
+// runOnce runs a worker with its hooks, returning why it exited.
+func (p *WorkPool) runOnce(w *worker, handler ErrWorkHandler) (reason ExitReason) {
+	reason = ExitStartFailed
+	if p.OnWorkerExit != nil {
+		defer func() {
+			p.OnWorkerExit(w.id, reason)
 		}()
 	}
+	if p.OnWorkerStart != nil {
+		if err := p.OnWorkerStart(w.id); err != nil {
+			p.log(slog.LevelError, "worker failed to start", "worker", w.id, "error", err)
+			p.setErr(err)
+			p.workerFailed.Store(true)
+			return reason
+		}
+	}
+	p.log(slog.LevelDebug, "worker started", "worker", w.id)
+	defer p.log(slog.LevelDebug, "worker stopped", "worker", w.id)
+	if p.OnWorkerStop != nil {
+		defer p.OnWorkerStop(w.id)
+	}
+	return p.runWorker(w, handler, p.ctx.Done())
+}
 
-	// Wait until the goroutines finish. By cancellation or otherwise.
-	wg.Wait()
+// allocID returns the lowest worker ID which is not in use. The caller must hold p.mu.
+func (p *WorkPool) allocID() int {
+	for id, used := range p.ids {
+		if !used {
+			p.ids[id] = true
+			return id
+		}
+	}
+	p.ids = append(p.ids, true)
+	return len(p.ids) - 1
+}
+
+// exitWorker removes the bookkeeping for w, the last worker to exit finishes the run. A recycled worker is replaced
+// by a new one unless the pool is stopping.
+func (p *WorkPool) exitWorker(w *worker) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.removeWorker(w)
+	delete(p.retiring, w)
+	close(w.exited)
+	p.ids[w.id] = false
+	if w.recycle && p.ctx.Err() == nil {
+		p.startWorker()
+	}
+	p.live--
+	p.gaugeWorkers()
+	if p.live == 0 && !p.holding {
+		p.stopRunning()
+	}
+}
+
+// removeWorker removes w from the active workers if it is there. The caller must hold p.mu.
+func (p *WorkPool) removeWorker(w *worker) {
+	for i, active := range p.workers {
+		if active == w {
+			p.workers = append(p.workers[:i], p.workers[i+1:]...)
+			return
+		}
+	}
+}
+
+// stopRunning marks the run as finished. The caller must hold p.mu.
+func (p *WorkPool) stopRunning() {
+	p.running = false
+	p.stopped = p.clock().Now()
+	close(p.finished)
+}
+
+// runWorker calls handler until it reports that there is no more work, the worker is asked to quit, or the pool is
+// cancelled. The reason for stopping is returned.
+func (p *WorkPool) runWorker(w *worker, handler ErrWorkHandler, abort <-chan struct{}) ExitReason {
+	// The clock is read once per call: the end of a call is taken as the start of the next, unless the worker blocked
+	// in between.
+	clock := p.clock()
+	now := clock.Now()
+	for {
+		if p.aborted.Load() {
+			return ExitCancelled
+		}
+		if w.retiring.Load() {
+			return ExitRetired
+		}
+		if p.pausing.Load() || p.Limiter != nil {
+			if !p.waitResumed(w, abort) || !p.wait() {
+				return p.exitReason(w, abort)
+			}
+			now = clock.Now()
+		}
+
+		start := now
+		w.calling.Store(start.UnixNano())
+		foundWork, panicked, err := p.invokeTimed(w, handler, abort)
+		now = clock.Now()
+		w.calling.Store(0)
+		atomic.StoreInt64(&p.counters.lastReturn, now.UnixNano())
+		if foundWork && !errors.Is(err, ErrNoWork) {
+			atomic.StoreInt64(&p.counters.lastWork, now.UnixNano())
+		}
+		p.logSlow(w, now.Sub(start))
+		p.counters.record(foundWork)
+		p.recordMetrics(foundWork, err, now.Sub(start))
+		if foundWork && !p.itemized {
+			p.completeTask()
+		}
+		if p.supervised(w, err) {
+			return ExitFailed
+		}
+		p.adapt(foundWork, now.Sub(start))
+		scaleDown, err := p.autoscale(w, foundWork, err)
+		if err != nil {
+			p.setErr(err)
+		}
+		if scaleDown {
+			return ExitRetired
+		}
+		if !foundWork {
+			return p.exitReason(w, abort)
+		}
+		if p.MaxTasksPerWorker > 0 {
+			w.tasks++
+			if w.tasks >= p.MaxTasksPerWorker {
+				return ExitRecycled
+			}
+		}
+		if p.panicBackoff(w, panicked) {
+			now = clock.Now()
+		}
+	}
+}
+
+// wait blocks on the Limiter if there is one. False is returned if the worker should exit.
+func (p *WorkPool) wait() bool {
+	if p.Limiter == nil {
+		return true
+	}
+	if err := p.Limiter.Wait(p.ctx); err != nil {
+		if p.ctx.Err() == nil {
+			p.setErr(err)
+		}
+		return false
+	}
+	return true
+}
+
+// RunContext is like Run, but the pool is also cancelled when ctx is done. The abort channel given to the WorkHandler is
+// closed when either ctx is done or Cancel is called.
+func (p *WorkPool) RunContext(ctx context.Context) error {
+	p.init()
+	p.parent = ctx
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			p.CancelWithCause(context.Cause(ctx))
+		case <-stop:
+		}
+	}()
+	return p.Run()
 }
 
 // Cancel may be called asynchronously to signal that the pool should stop processing work and return to the caller. An
-// abort signal will be sent to each WorkHandler to allow for graceful shutdown.
+// abort signal will be sent to each WorkHandler to allow for graceful shutdown. It is safe to call Cancel more than
+// once, from multiple goroutines, before the pool starts or after it has finished.
 func (p *WorkPool) Cancel() {
-	close(p.abort)
+	p.CancelWithCause(nil)
+}
+
+// CancelWithCause is like Cancel, but also records why the pool was cancelled. The cause is returned by AbortCause,
+// a nil cause is recorded as context.Canceled. Only the first cancellation is recorded.
+func (p *WorkPool) CancelWithCause(cause error) {
+	p.init()
+	p.cancelOnce.Do(func() {
+		p.aborted.Store(true)
+		p.cancel(cause)
+		p.log(slog.LevelInfo, "workpool cancelled", "cause", p.AbortCause())
+		if p.OnCancel != nil {
+			p.OnCancel()
+		}
+		p.cancelChildren()
+	})
+}
+
+// AbortCause returns why the pool was cancelled: the cause given to CancelWithCause, context.Canceled after Cancel, or
+// the cause of the context given to RunContext. Nil is returned if the pool has not been cancelled. Handlers may call
+// it once their abort signal is closed to find out why.
+func (p *WorkPool) AbortCause() error {
+	p.init()
+	return context.Cause(p.ctx)
+}
+
+// Cancelled returns true once the pool has been cancelled.
+func (p *WorkPool) Cancelled() bool {
+	p.init()
+	return p.ctx.Err() != nil
+}
+
+// CancelReport describes the workers of a pool after CancelAndWait.
+type CancelReport struct {
+	// Exited is the number of workers which stopped within the grace period.
+	Exited int
+
+	// Abandoned is the number of workers which were still running when the grace period expired.
+	Abandoned int
+}
+
+// CancelAndWait cancels the pool and waits up to grace for the workers to return from their current handler call.
+// Workers which have not stopped by then are abandoned, they are left running in the background and are reported as
+// such.
+func (p *WorkPool) CancelAndWait(grace time.Duration) CancelReport {
+	p.mu.Lock()
+	running := p.running
+	live := p.live
+	finished := p.finished
+	p.mu.Unlock()
+	p.startChildren()
+
+	p.Cancel()
+	if !running {
+		return CancelReport{}
+	}
+
+	timer := p.clock().NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-finished:
+		return CancelReport{Exited: live}
+	case <-timer.C():
+	}
+
+	p.mu.Lock()
+	remaining := p.live
+	p.mu.Unlock()
+	return CancelReport{Exited: live - remaining, Abandoned: remaining}
+}
+
+// Err returns the first error returned by a handler, or nil. With CollectErrors it returns all of the errors kept,
+// joined with errors.Join. A pool which shut down because of IdleTimeout without errors returns ErrIdleTimeout.
+func (p *WorkPool) Err() error {
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+	if p.err == nil && errors.Is(context.Cause(p.ctx), ErrIdleTimeout) {
+		return ErrIdleTimeout
+	}
+	if p.CollectErrors <= 0 || p.err == nil {
+		return p.err
+	}
+	errs := p.errs
+	if dropped := p.errCount - len(p.errs); dropped > 0 {
+		errs = append(errs[:len(errs):len(errs)], fmt.Errorf("workpool: %d more errors", dropped))
+	}
+	return errors.Join(errs...)
+}
+
+// ErrorCount returns the number of errors recorded, including those not kept because of CollectErrors.
+func (p *WorkPool) ErrorCount() int {
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+	return p.errCount
+}
+
+// setErr records err, keeping it if it is the first error or CollectErrors has room for it, and cancels the pool if
+// CancelOnError is set.
+func (p *WorkPool) setErr(err error) {
+	p.errMu.Lock()
+	first := p.err == nil
+	if first {
+		p.err = err
+	}
+	p.errCount++
+	if len(p.errs) < p.CollectErrors {
+		p.errs = append(p.errs, err)
+	}
+	p.errMu.Unlock()
+	if first && p.CancelOnError {
+		p.CancelWithCause(err)
+	}
+}
+
+// handler returns the configured handler as an ErrWorkHandler for the worker, wrapped in middleware, the middleware
+// added with Use or UseContext when the worker was created. It must be called without holding p.mu, the middleware is user code.
+func (p *WorkPool) handler(w *worker, middleware []layer) ErrWorkHandler {
+	handler := p.baseHandler(w)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](w, handler)
+	}
+	return handler
+}
+
+// baseHandler returns the configured handler as an ErrWorkHandler for the worker.
+func (p *WorkPool) baseHandler(w *worker) ErrWorkHandler {
+	if p.ErrHandler != nil {
+		return p.ErrHandler
+	}
+	if p.ContextHandler != nil {
+		contextual := p.ContextHandler
+		return func(abort <-chan struct{}) (bool, error) {
+			return contextual(p.handlerContext(w)), nil
+		}
+	}
+	if p.IndexedHandler != nil {
+		indexed := p.IndexedHandler
+		workerID := w.id
+		return func(abort <-chan struct{}) (bool, error) {
+			return indexed(workerID, abort), nil
+		}
+	}
+	if p.resourceHandler != nil {
+		resourceful := p.resourceHandler
+		return func(abort <-chan struct{}) (bool, error) {
+			return resourceful(w.resource, abort)
+		}
+	}
+	if p.StateHandler != nil {
+		stateful := p.StateHandler
+		return func(abort <-chan struct{}) (bool, error) {
+			return stateful(w.state, abort), nil
+		}
+	}
+	handler := p.Handler
+	return func(abort <-chan struct{}) (bool, error) {
+		return handler(abort), nil
+	}
+}
+
+// init lazily creates the abort context so that a zero value WorkPool is usable.
+func (p *WorkPool) init() {
+	p.once.Do(func() {
+		p.ctx, p.cancel = context.WithCancelCause(context.Background())
+		p.counters = &counters{}
+		p.done = make(chan struct{})
+		resumed := make(chan struct{})
+		close(resumed)
+		p.resumed.Store(resumed)
+	})
 }
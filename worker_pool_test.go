@@ -1,10 +1,17 @@
 package workpool
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWorkerPoolExitWhenNoWork(t *testing.T) {
@@ -249,3 +256,391 @@ func TestCancelWithOpenInputChannel(t *testing.T) {
 
 	pool.Run()
 }
+
+// TestRunContextCancel ensures that the pool stops when the context is cancelled.
+func TestRunContextCancel(t *testing.T) {
+	started := make(chan struct{})
+	worker := func(abort <-chan struct{}) bool {
+		close(started)
+		<-abort
+		return false
+	}
+
+	pool := New(1, worker)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	pool.RunContext(ctx)
+}
+
+// TestRunContextDeadline ensures that a context deadline aborts the pool.
+func TestRunContextDeadline(t *testing.T) {
+	worker := func(abort <-chan struct{}) bool {
+		select {
+		case <-time.After(1 * time.Hour):
+			return true
+		case <-abort:
+			return false
+		}
+	}
+
+	pool := New(2, worker)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	pool.RunContext(ctx)
+	assert.WithinDuration(t, start.Add(10*time.Millisecond), time.Now(), 50*time.Millisecond)
+}
+
+// TestRunContextWithCancel ensures that Cancel still works, and can be combined with context cancellation.
+func TestRunContextWithCancel(t *testing.T) {
+	started := make(chan struct{})
+	worker := func(abort <-chan struct{}) bool {
+		close(started)
+		<-abort
+		return false
+	}
+
+	pool := New(1, worker)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		<-started
+		pool.Cancel()
+		cancel()
+	}()
+
+	pool.RunContext(ctx)
+}
+
+// TestRunReturnsError ensures that the first handler error is returned from Run and that errors do not stop workers.
+func TestRunReturnsError(t *testing.T) {
+	numInputs := 10
+	inputs := make(chan int, numInputs)
+	for i := 0; i < numInputs; i++ {
+		inputs <- i
+	}
+	close(inputs)
+
+	processed := 0
+	worker := func(abort <-chan struct{}) (bool, error) {
+		for i := range inputs {
+			processed++
+			if i == 3 {
+				return true, errors.New("bad input")
+			}
+			return true, nil
+		}
+		return false, nil
+	}
+
+	pool := NewWithError(1, worker)
+	err := pool.Run()
+
+	assert.EqualError(t, err, "bad input")
+	assert.Equal(t, err, pool.Err())
+	assert.Equal(t, numInputs, processed)
+}
+
+// TestRunNoError ensures that a plain WorkHandler never produces an error.
+func TestRunNoError(t *testing.T) {
+	worker := func(abort <-chan struct{}) bool {
+		return false
+	}
+
+	assert.NoError(t, New(3, worker).Run())
+}
+
+func TestCancelAndWait(t *testing.T) {
+	stuck := make(chan struct{})
+	defer close(stuck)
+
+	var started sync.WaitGroup
+	started.Add(3)
+	worker := func(workerID int, abort <-chan struct{}) bool {
+		started.Done()
+		if workerID == 0 {
+			// Ignores the abort signal.
+			<-stuck
+			return false
+		}
+		<-abort
+		return false
+	}
+
+	pool := NewIndexed(3, worker)
+	go pool.Run()
+	started.Wait()
+
+	report := pool.CancelAndWait(20 * time.Millisecond)
+	assert.Equal(t, CancelReport{Exited: 2, Abandoned: 1}, report)
+}
+
+func TestCancelAndWaitAllExit(t *testing.T) {
+	started := make(chan struct{}, 2)
+	worker := func(abort <-chan struct{}) bool {
+		started <- struct{}{}
+		<-abort
+		return false
+	}
+
+	pool := New(2, worker)
+	go pool.Run()
+	<-started
+	<-started
+
+	report := pool.CancelAndWait(time.Hour)
+	assert.Equal(t, CancelReport{Exited: 2}, report)
+}
+
+func TestCancelAndWaitNotRunning(t *testing.T) {
+	pool := New(2, func(abort <-chan struct{}) bool { return false })
+	assert.Equal(t, CancelReport{}, pool.CancelAndWait(time.Hour))
+	assert.True(t, pool.Stats().Cancelled)
+}
+
+func TestStartWait(t *testing.T) {
+	closed := 0
+	worker := func(abort <-chan struct{}) (bool, error) {
+		time.Sleep(time.Millisecond)
+		return false, errors.New("done")
+	}
+	pool := NewWithError(2, worker)
+	pool.Close = func() {
+		closed++
+	}
+
+	// Wait may be called before Start, and from multiple goroutines.
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = pool.Wait()
+		}(i)
+	}
+
+	pool.Start()
+	pool.Start()
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.EqualError(t, err, "done")
+	}
+	assert.EqualError(t, pool.Wait(), "done")
+	assert.Equal(t, 1, closed)
+	assert.Equal(t, int64(2), pool.Stats().Invocations)
+}
+
+// TestCancelConcurrent ensures that Cancel may be called many times while the pool is finishing.
+func TestCancelConcurrent(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		pool := New(4, func(abort <-chan struct{}) bool {
+			return false
+		})
+		assert.False(t, pool.Cancelled())
+
+		var wg sync.WaitGroup
+		for j := 0; j < 8; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				pool.Cancel()
+			}()
+		}
+		assert.NoError(t, pool.Run())
+		wg.Wait()
+		pool.Cancel()
+		assert.True(t, pool.Cancelled())
+	}
+}
+
+func TestCancelWithCause(t *testing.T) {
+	errShutdown := errors.New("shutting down")
+	var seen error
+	pool := New(1, func(abort <-chan struct{}) bool {
+		<-abort
+		return false
+	})
+	pool.OnCancel = func() {
+		seen = pool.AbortCause()
+	}
+	assert.NoError(t, pool.AbortCause())
+
+	pool.Start()
+	pool.CancelWithCause(errShutdown)
+	pool.CancelWithCause(errors.New("ignored"))
+	assert.NoError(t, pool.Wait())
+	assert.Equal(t, errShutdown, pool.AbortCause())
+	assert.Equal(t, errShutdown, seen)
+}
+
+func TestAbortCause(t *testing.T) {
+	pool := New(1, func(abort <-chan struct{}) bool { return false })
+	pool.Cancel()
+	assert.Equal(t, context.Canceled, pool.AbortCause())
+
+	// The cause of the context given to RunContext is passed on.
+	errDeadline := errors.New("deploy deadline")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	pool = New(1, func(abort <-chan struct{}) bool {
+		cancel(errDeadline)
+		<-abort
+		return false
+	})
+	assert.NoError(t, pool.RunContext(ctx))
+	assert.Equal(t, errDeadline, pool.AbortCause())
+}
+
+func TestDefaultWorkers(t *testing.T) {
+	var mu sync.Mutex
+	ids := make(map[int]bool)
+	pool := NewIndexed(0, func(workerID int, abort <-chan struct{}) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		ids[workerID] = true
+		return false
+	})
+	assert.NoError(t, pool.Run())
+	assert.Equal(t, runtime.GOMAXPROCS(0), pool.Workers)
+	assert.Len(t, ids, runtime.GOMAXPROCS(0))
+
+	pool = New(-1, func(abort <-chan struct{}) bool { return false })
+	assert.NoError(t, pool.Run())
+	assert.Equal(t, runtime.GOMAXPROCS(0), pool.Workers)
+}
+
+// benchmarkPool runs a pool whose handler does almost nothing, so that the time is spent in the worker loop.
+func benchmarkPool(b *testing.B, workers int) {
+	remaining := int64(b.N)
+	pool := New(workers, func(abort <-chan struct{}) bool {
+		return atomic.AddInt64(&remaining, -1) >= 0
+	})
+	b.ReportAllocs()
+	b.ResetTimer()
+	if err := pool.Run(); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func BenchmarkWorkPoolOneWorker(b *testing.B) {
+	benchmarkPool(b, 1)
+}
+
+func BenchmarkWorkPoolWorkers(b *testing.B) {
+	benchmarkPool(b, runtime.GOMAXPROCS(0))
+}
+
+func TestCancelOnError(t *testing.T) {
+	var calls int64
+	pool := NewWithError(4, func(abort <-chan struct{}) (bool, error) {
+		n := atomic.AddInt64(&calls, 1)
+		if n == 10 {
+			return true, errors.New("doomed")
+		}
+		select {
+		case <-abort:
+			return false, nil
+		case <-time.After(time.Millisecond):
+			return true, nil
+		}
+	})
+	pool.CancelOnError = true
+
+	assert.EqualError(t, pool.Run(), "doomed")
+	assert.True(t, pool.Cancelled())
+	assert.EqualError(t, pool.AbortCause(), "doomed")
+	assert.Less(t, atomic.LoadInt64(&calls), int64(100))
+}
+
+func TestErrorsDoNotCancelByDefault(t *testing.T) {
+	var calls int64
+	pool := NewWithError(2, func(abort <-chan struct{}) (bool, error) {
+		if atomic.AddInt64(&calls, 1) > 20 {
+			return false, nil
+		}
+		return true, errors.New("keep going")
+	})
+	assert.EqualError(t, pool.Run(), "keep going")
+	assert.False(t, pool.Cancelled())
+	assert.GreaterOrEqual(t, atomic.LoadInt64(&calls), int64(20))
+}
+
+func TestCollectErrors(t *testing.T) {
+	var calls int64
+	pool := NewWithError(1, func(abort <-chan struct{}) (bool, error) {
+		n := atomic.AddInt64(&calls, 1)
+		if n > 5 {
+			return false, nil
+		}
+		return true, fmt.Errorf("error %d", n)
+	})
+	pool.CollectErrors = 3
+
+	err := pool.Run()
+	assert.EqualError(t, err, "error 1\nerror 2\nerror 3\nworkpool: 2 more errors")
+	assert.Equal(t, 5, pool.ErrorCount())
+	assert.Equal(t, err.Error(), pool.Err().Error(), "Err can be called repeatedly")
+
+	require.NoError(t, pool.Reset())
+	atomic.StoreInt64(&calls, 3)
+	assert.EqualError(t, pool.Run(), "error 4\nerror 5")
+	assert.Equal(t, 2, pool.ErrorCount())
+}
+
+func TestInline(t *testing.T) {
+	var calls int
+	var goroutines []int
+	before := runtime.NumGoroutine()
+	pool := New(8, func(abort <-chan struct{}) bool {
+		// Not synchronized, the handler runs in the goroutine calling Start.
+		calls++
+		goroutines = append(goroutines, runtime.NumGoroutine())
+		return calls < 10
+	})
+	pool.Inline = true
+	stopped := false
+	pool.OnStop = func(error) { stopped = true }
+
+	pool.Start()
+	assert.Equal(t, 10, calls)
+	assert.True(t, stopped, "Start returns once the pool has finished")
+	assert.Equal(t, 1, pool.Workers)
+	for _, n := range goroutines {
+		assert.Equal(t, before, n, "no goroutines are started")
+	}
+	assert.NoError(t, pool.Wait())
+}
+
+func TestInlineTypedPool(t *testing.T) {
+	pool := NewTypedPool(4, func(abort <-chan struct{}, item int) (int, error) {
+		if item == 3 {
+			return 0, errors.New("three")
+		}
+		return item * 2, nil
+	})
+	pool.Inline = true
+	pool.Callbacks = NewExecutor(1)
+	pool.Callbacks.Start()
+	var got []int
+	for i := 1; i <= 4; i++ {
+		require.NoError(t, pool.SubmitWithCallback(i, func(out int, err error) {
+			if err == nil {
+				got = append(got, out)
+			}
+		}))
+	}
+	pool.Finish()
+
+	assert.EqualError(t, pool.Run(), "three")
+	pool.Callbacks.Finish()
+	require.NoError(t, pool.Callbacks.Wait())
+	assert.Equal(t, []int{2, 4, 8}, got)
+}
@@ -0,0 +1,99 @@
+package workpooltest
+
+import (
+	"bytes"
+	"fmt"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/algorand/workpool"
+)
+
+// AssertDrained checks that the pool finishes within timeout without being cancelled, having completed every task it
+// was expected to. For a TypedPool those are the submitted items, pass its WorkPool.
+func AssertDrained(t testing.TB, p *workpool.WorkPool, timeout time.Duration) bool {
+	t.Helper()
+	if !finished(p, timeout) {
+		t.Errorf("workpool: pool did not finish within %v", timeout)
+		return false
+	}
+	if p.Cancelled() {
+		t.Errorf("workpool: pool was cancelled: %v", p.AbortCause())
+		return false
+	}
+	if progress := p.Progress(); progress.Done < progress.Total {
+		t.Errorf("workpool: pool finished with %d of %d tasks done", progress.Done, progress.Total)
+		return false
+	}
+	return true
+}
+
+// AssertCancelled checks that the pool finishes within timeout, having been cancelled.
+func AssertCancelled(t testing.TB, p *workpool.WorkPool, timeout time.Duration) bool {
+	t.Helper()
+	if !finished(p, timeout) {
+		t.Errorf("workpool: pool did not finish within %v", timeout)
+		return false
+	}
+	if !p.Cancelled() {
+		t.Errorf("workpool: pool finished without being cancelled")
+		return false
+	}
+	return true
+}
+
+// AssertNoLeakedWorkers checks that, within timeout, no goroutines are left carrying the profiler labels of the pool.
+// Those are its workers and the goroutines they started, which should all have exited once the pool finished. The
+// pool is identified by its Name, so it should be unique to the test.
+func AssertNoLeakedWorkers(t testing.TB, p *workpool.WorkPool, timeout time.Duration) bool {
+	t.Helper()
+	label := fmt.Sprintf("%q:%q", "workpool", p.Name)
+	deadline := time.Now().Add(timeout)
+	for {
+		leaked := labelledGoroutines(label)
+		if len(leaked) == 0 {
+			return true
+		}
+		if time.Now().After(deadline) {
+			t.Errorf("workpool: %d goroutines of pool %q leaked:\n\n%s", len(leaked), p.Name,
+				strings.Join(leaked, "\n\n"))
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// finished reports whether the pool finishes within timeout.
+func finished(p *workpool.WorkPool, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		_ = p.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// labelledGoroutines returns the stacks of the goroutines whose profiler labels include label.
+func labelledGoroutines(label string) []string {
+	var buf bytes.Buffer
+	_ = pprof.Lookup("goroutine").WriteTo(&buf, 1)
+	var stacks []string
+	for _, stack := range strings.Split(buf.String(), "\n\n") {
+		for _, line := range strings.Split(stack, "\n") {
+			if strings.HasPrefix(line, "# labels: ") && strings.Contains(line, label) {
+				stacks = append(stacks, stack)
+				break
+			}
+		}
+	}
+	return stacks
+}
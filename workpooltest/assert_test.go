@@ -0,0 +1,101 @@
+package workpooltest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/algorand/workpool"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder is a testing.TB which records failures instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertDrained(t *testing.T) {
+	pool := workpool.NewTypedPool(2, func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	})
+	go func() {
+		for range pool.Results() {
+		}
+	}()
+	pool.Start()
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, pool.Submit(i))
+	}
+	pool.Finish()
+	assert.True(t, AssertDrained(t, pool.WorkPool, time.Second))
+
+	r := &recorder{TB: t}
+	cancelled := workpool.New(1, func(abort <-chan struct{}) bool {
+		<-abort
+		return false
+	})
+	cancelled.Start()
+	cancelled.Cancel()
+	assert.False(t, AssertDrained(r, cancelled, time.Second))
+	assert.Equal(t, []string{"workpool: pool was cancelled: context canceled"}, r.errors)
+
+	r = &recorder{TB: t}
+	short := workpool.New(1, func(abort <-chan struct{}) bool { return false })
+	short.SetTotal(3)
+	short.Start()
+	assert.False(t, AssertDrained(r, short, time.Second))
+	assert.Equal(t, []string{"workpool: pool finished with 0 of 3 tasks done"}, r.errors)
+}
+
+func TestAssertCancelled(t *testing.T) {
+	release := make(chan struct{})
+	pool := workpool.New(1, func(abort <-chan struct{}) bool {
+		<-release
+		return false
+	})
+	pool.Start()
+
+	r := &recorder{TB: t}
+	assert.False(t, AssertCancelled(r, pool, 10*time.Millisecond))
+	assert.Equal(t, []string{"workpool: pool did not finish within 10ms"}, r.errors)
+
+	close(release)
+	r = &recorder{TB: t}
+	assert.False(t, AssertCancelled(r, pool, time.Second))
+	assert.Equal(t, []string{"workpool: pool finished without being cancelled"}, r.errors)
+
+	cancelled := workpool.New(1, func(abort <-chan struct{}) bool {
+		<-abort
+		return false
+	})
+	cancelled.Start()
+	cancelled.Cancel()
+	assert.True(t, AssertCancelled(t, cancelled, time.Second))
+}
+
+func TestAssertNoLeakedWorkers(t *testing.T) {
+	release := make(chan struct{})
+	pool := workpool.New(2, func(abort <-chan struct{}) bool {
+		// The goroutine inherits the labels of the worker.
+		go func() { <-release }()
+		return false
+	})
+	pool.Name = "leaky"
+	assert.NoError(t, pool.Run())
+
+	r := &recorder{TB: t}
+	assert.False(t, AssertNoLeakedWorkers(r, pool, 10*time.Millisecond))
+	if assert.Len(t, r.errors, 1) {
+		assert.Contains(t, r.errors[0], `workpool: 2 goroutines of pool "leaky" leaked`)
+	}
+
+	close(release)
+	assert.True(t, AssertNoLeakedWorkers(t, pool, time.Second))
+}
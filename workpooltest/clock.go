@@ -0,0 +1,114 @@
+// Package workpooltest has utilities for testing code which uses workpool deterministically: a Clock which only moves
+// when told to, a Stepper which releases one handler call at a time, and assertions about how a pool finished.
+package workpooltest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/algorand/workpool"
+)
+
+// Clock is a workpool.Clock which only moves when Advance is called. Set it as the Clock of a pool to control the
+// timing of handler calls, delayed items, scheduled jobs and other timers.
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*timer
+
+	// added is closed, and replaced, whenever a timer is created.
+	added chan struct{}
+}
+
+// timer is a timer created by a Clock.
+type timer struct {
+	clock *Clock
+	when  time.Time
+	c     chan time.Time
+}
+
+// NewClock creates a Clock set to start.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start, added: make(chan struct{})}
+}
+
+// Now returns the time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer creates a timer which fires once the clock has been advanced by d. A timer with a duration of zero or less
+// fires straight away.
+func (c *Clock) NewTimer(d time.Duration) workpool.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &timer{clock: c, when: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	close(c.added)
+	c.added = make(chan struct{})
+	return t
+}
+
+// Advance moves the clock forward by d, firing the timers which become due in the order of their deadlines.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].when.Before(c.timers[j].when)
+	})
+	fired := 0
+	for _, t := range c.timers {
+		if t.when.After(c.now) {
+			break
+		}
+		t.c <- t.when
+		fired++
+	}
+	c.timers = append(c.timers[:0], c.timers[fired:]...)
+}
+
+// Timers returns the number of timers waiting to fire.
+func (c *Clock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// BlockUntil blocks until at least n timers are waiting to fire. Waiting for the timers of the pool to be created
+// before calling Advance avoids racing with the goroutines creating them.
+func (c *Clock) BlockUntil(n int) {
+	for {
+		c.mu.Lock()
+		count, added := len(c.timers), c.added
+		c.mu.Unlock()
+		if count >= n {
+			return
+		}
+		<-added
+	}
+}
+
+func (t *timer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *timer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, other := range c.timers {
+		if other == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package workpooltest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/algorand/workpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestClock(t *testing.T) {
+	clock := NewClock(epoch)
+	assert.Equal(t, epoch, clock.Now())
+
+	late := clock.NewTimer(2 * time.Second)
+	early := clock.NewTimer(time.Second)
+	stopped := clock.NewTimer(time.Second)
+	assert.Equal(t, 3, clock.Timers())
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop())
+
+	clock.Advance(time.Second)
+	assert.Equal(t, epoch.Add(time.Second), clock.Now())
+	assert.Equal(t, epoch.Add(time.Second), <-early.C())
+	assert.False(t, early.Stop(), "fired timers cannot be stopped")
+	assert.Empty(t, late.C())
+	assert.Empty(t, stopped.C())
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, epoch.Add(2*time.Second), <-late.C())
+	assert.Zero(t, clock.Timers())
+
+	now := clock.NewTimer(0)
+	assert.Equal(t, clock.Now(), <-now.C())
+}
+
+func TestClockSubmitAfter(t *testing.T) {
+	clock := NewClock(epoch)
+	pool := workpool.NewTypedPool(1, func(abort <-chan struct{}, item int) (int, error) {
+		return item, nil
+	})
+	pool.Clock = clock
+	pool.Start()
+	require.NoError(t, pool.SubmitAfter(time.Minute, 1))
+	require.NoError(t, pool.SubmitAfter(time.Hour, 2))
+	pool.Finish()
+
+	clock.BlockUntil(2)
+	clock.Advance(time.Minute)
+	assert.Equal(t, 1, (<-pool.Results()).Value)
+	assert.Equal(t, 1, clock.Timers())
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, 2, (<-pool.Results()).Value)
+	_, ok := <-pool.Results()
+	assert.False(t, ok)
+	assert.Equal(t, time.Hour+time.Minute, pool.Stats().Duration)
+}
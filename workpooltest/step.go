@@ -0,0 +1,64 @@
+package workpooltest
+
+import (
+	"sync"
+
+	"github.com/algorand/workpool"
+)
+
+// Stepper is a workpool.Middleware which holds every handler call until Step is called, so that a test decides
+// exactly when each call happens instead of sleeping. Add it with Use before starting the pool:
+//
+//	stepper := workpooltest.NewStepper()
+//	pool.Use(stepper.Wrap)
+type Stepper struct {
+	turns chan *turn
+
+	freeOnce sync.Once
+	free     chan struct{}
+}
+
+// turn is a handler call waiting to be released.
+type turn struct {
+	done chan bool
+}
+
+// NewStepper creates a Stepper which holds every call.
+func NewStepper() *Stepper {
+	return &Stepper{
+		turns: make(chan *turn),
+		free:  make(chan struct{}),
+	}
+}
+
+// Wrap is the Middleware of the Stepper. A call held when the pool is cancelled returns without calling the handler,
+// reporting that there is no more work.
+func (s *Stepper) Wrap(next workpool.ErrWorkHandler) workpool.ErrWorkHandler {
+	return func(abort <-chan struct{}) (bool, error) {
+		t := &turn{done: make(chan bool, 1)}
+		select {
+		case s.turns <- t:
+		case <-s.free:
+			return next(abort)
+		case <-abort:
+			return false, nil
+		}
+		foundWork := false
+		defer func() { t.done <- foundWork }()
+		foundWork, err := next(abort)
+		return foundWork, err
+	}
+}
+
+// Step releases a single handler call and waits for it to return, then reports whether the handler found work. It
+// blocks until a worker calls the handler, so a test must only step as many times as there are calls to make.
+func (s *Stepper) Step() bool {
+	return <-(<-s.turns).done
+}
+
+// Release stops holding calls, letting the pool run freely, for example to let it drain at the end of a test.
+func (s *Stepper) Release() {
+	s.freeOnce.Do(func() {
+		close(s.free)
+	})
+}
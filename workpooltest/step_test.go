@@ -0,0 +1,45 @@
+package workpooltest
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/algorand/workpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepper(t *testing.T) {
+	var calls int64
+	pool := workpool.New(4, func(abort <-chan struct{}) bool {
+		return atomic.AddInt64(&calls, 1) < 5
+	})
+	stepper := NewStepper()
+	pool.Use(stepper.Wrap)
+	pool.Start()
+
+	for i := int64(1); i <= 3; i++ {
+		assert.True(t, stepper.Step())
+		assert.Equal(t, i, atomic.LoadInt64(&calls), "one call per step")
+	}
+
+	stepper.Release()
+	require.NoError(t, pool.Wait())
+	assert.GreaterOrEqual(t, atomic.LoadInt64(&calls), int64(5))
+}
+
+func TestStepperCancelled(t *testing.T) {
+	var calls int64
+	pool := workpool.New(2, func(abort <-chan struct{}) bool {
+		atomic.AddInt64(&calls, 1)
+		return true
+	})
+	stepper := NewStepper()
+	pool.Use(stepper.Wrap)
+	pool.Start()
+
+	assert.True(t, stepper.Step())
+	pool.Cancel()
+	require.NoError(t, pool.Wait())
+	assert.Equal(t, int64(1), atomic.LoadInt64(&calls), "held calls are dropped")
+}